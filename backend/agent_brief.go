@@ -0,0 +1,447 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+const (
+	defaultBriefTemplateName = "default"
+	maxBriefTemplateBytes    = 64 << 10
+	maxBriefClaimCitations   = 5
+	maxBriefDemandClaims     = 5
+	maxBriefThemeClaims      = 3
+	maxBriefRiskClaims       = 4
+	maxBriefQuoteLength      = 160
+	minBriefIndependentHosts = 3
+
+	briefSectionProblem        = "problem_statement"
+	briefSectionDemand         = "demand_evidence"
+	briefSectionCompetitors    = "competitor_coverage"
+	briefSectionRisks          = "risks"
+	briefSectionRecommendation = "recommendation"
+)
+
+// defaultBriefTemplate renders sections in the order they were built. Custom
+// workspace templates can pick sections individually with {{section "risks"}}.
+const defaultBriefTemplate = `# {{.Brief.Feature}} research brief
+
+Category: {{.Brief.Category}}{{with .Brief.Persona}} | Persona: {{.}}{{end}}
+Priority: {{.Brief.Priority}} | Confidence: {{printf "%.2f" .Brief.Confidence}}
+Generated: {{.Brief.GeneratedAt}}
+{{range .Sections}}
+## {{.Title}}
+{{range .Claims}}
+- {{.Text}} {{cite .Citations}}
+{{- end}}
+{{- range .Gaps}}
+- _{{.}}_
+{{- end}}
+{{end}}`
+
+// briefRiskTerms are matched as word prefixes, so "frustrat" covers
+// frustrated and frustrating without "lack" matching Slack.
+var briefRiskTerms = []string{
+	"bug", "broken", "churn", "complain", "confus", "crash", "difficult", "expensive", "fail",
+	"frustrat", "lack", "limitation", "missing", "privacy", "problem", "security", "slow",
+	"unreliable", "workaround",
+}
+
+type briefCitation struct {
+	EvidenceID string `json:"evidence_id"`
+	URL        string `json:"url,omitempty"`
+	Source     string `json:"source,omitempty"`
+}
+
+type briefClaim struct {
+	Text      string          `json:"text"`
+	Citations []briefCitation `json:"citations"`
+}
+
+type briefSection struct {
+	ID     string       `json:"id"`
+	Title  string       `json:"title"`
+	Claims []briefClaim `json:"claims"`
+	Gaps   []string     `json:"gaps,omitempty"`
+}
+
+type briefTemplateData struct {
+	Brief    *agentBrief
+	Sections []briefSection
+}
+
+type briefTemplateView struct {
+	Workspace string `json:"workspace"`
+	Template  string `json:"template"`
+	Custom    bool   `json:"custom"`
+}
+
+func (s *briefSection) add(text string, citations []briefCitation) {
+	// Claims without a source are dropped rather than shipped unsupported.
+	if len(citations) == 0 {
+		return
+	}
+	if len(citations) > maxBriefClaimCitations {
+		citations = citations[:maxBriefClaimCitations]
+	}
+	s.Claims = append(s.Claims, briefClaim{Text: text, Citations: citations})
+}
+
+func citeEvidence(items ...agentEvidence) []briefCitation {
+	citations := make([]briefCitation, 0, len(items))
+	for _, item := range items {
+		citations = append(citations, briefCitation{EvidenceID: item.ID, URL: item.URL, Source: item.SourceName})
+	}
+	return citations
+}
+
+func buildBriefSections(req agentFeatureResearchRequest, evidence []agentEvidence, themes []themeCluster, brief *agentBrief) []briefSection {
+	top := evidence
+	if len(top) > maxBriefClaimCitations {
+		top = top[:maxBriefClaimCitations]
+	}
+	hosts := map[string]struct{}{}
+	mentions := 0
+	for _, item := range evidence {
+		hosts[extractHost(item.URL)] = struct{}{}
+		mentions += max(item.Mentions, 1)
+	}
+
+	audience := "Teams"
+	if req.Persona != "" {
+		audience = req.Persona
+	}
+	problem := briefSection{ID: briefSectionProblem, Title: "Problem statement"}
+	problem.add(fmt.Sprintf("%s in %s are looking for %s: %d snippets (%d mentions) reference it across %d sites.",
+		audience, req.Category, req.Feature, len(evidence), mentions, len(hosts)), citeEvidence(top...))
+	for idx, theme := range themes {
+		if idx == maxBriefThemeClaims {
+			break
+		}
+		citations := make([]briefCitation, 0, len(theme.Quotes))
+		for _, quote := range theme.Quotes {
+			citations = append(citations, briefCitation{EvidenceID: quote.Ref, URL: quote.URL, Source: quote.Source})
+		}
+		problem.add(fmt.Sprintf("Recurring theme %q shows up in %d items (%.0f%% of clustered feedback).",
+			theme.Label, theme.Count, theme.Confidence*100), citations)
+	}
+	if len(problem.Claims) == 0 {
+		problem.Gaps = append(problem.Gaps, fmt.Sprintf("No evidence was found that anyone is asking for %s.", req.Feature))
+	}
+
+	demand := briefSection{ID: briefSectionDemand, Title: "Demand evidence"}
+	for idx, item := range evidence {
+		if idx == maxBriefDemandClaims {
+			break
+		}
+		text := fmt.Sprintf("%s: %q", item.SourceName, truncateText(item.Snippet, maxBriefQuoteLength))
+		if item.Mentions > 1 {
+			text += fmt.Sprintf(" (repeated on %d pages)", item.Mentions)
+		}
+		demand.add(text, citeEvidence(item))
+	}
+	if len(evidence) == 0 {
+		demand.Gaps = append(demand.Gaps, "No web evidence was captured for this run.")
+	}
+
+	competitors := briefSection{ID: briefSectionCompetitors, Title: "Competitor coverage"}
+	for _, name := range req.Competitors {
+		matched := make([]agentEvidence, 0)
+		for _, item := range evidence {
+			if mentionsCompetitor(item, name) {
+				matched = append(matched, item)
+			}
+		}
+		if len(matched) == 0 {
+			competitors.Gaps = append(competitors.Gaps, fmt.Sprintf("No captured evidence covers %s.", name))
+			continue
+		}
+		competitors.add(fmt.Sprintf("%s comes up in %d snippets, e.g. %q.", name, len(matched), truncateText(matched[0].Snippet, maxBriefQuoteLength)), citeEvidence(matched...))
+	}
+	if len(req.Competitors) == 0 {
+		competitors.Gaps = append(competitors.Gaps, "No competitors were named for this run.")
+	}
+
+	risks := briefSection{ID: briefSectionRisks, Title: "Risks"}
+	for _, item := range evidence {
+		if len(risks.Claims) == maxBriefRiskClaims {
+			break
+		}
+		if mentionsBriefRisk(item.Snippet) {
+			risks.add(fmt.Sprintf("%s flags a concern: %q", item.SourceName, truncateText(item.Snippet, maxBriefQuoteLength)), citeEvidence(item))
+		}
+	}
+	if len(evidence) > 0 && len(hosts) < minBriefIndependentHosts {
+		risks.add(fmt.Sprintf("Evidence comes from only %d independent sites, so demand may be overstated.", len(hosts)), citeEvidence(top...))
+	}
+	if len(risks.Claims) == 0 {
+		risks.Gaps = append(risks.Gaps, "No risks were surfaced by the captured evidence.")
+	}
+
+	recommendation := briefSection{ID: briefSectionRecommendation, Title: "Recommendation"}
+	switch brief.Priority {
+	case "High":
+		recommendation.add(fmt.Sprintf("Prioritize %s for the next planning cycle; demand is broad and consistent (confidence %.2f).", req.Feature, brief.Confidence), citeEvidence(top...))
+	case "Medium":
+		recommendation.add(fmt.Sprintf("Scope a limited %s pilot and validate with the most engaged sources before committing (confidence %.2f).", req.Feature, brief.Confidence), citeEvidence(top...))
+	default:
+		recommendation.add(fmt.Sprintf("Keep %s in discovery; current evidence is thin (confidence %.2f).", req.Feature, brief.Confidence), citeEvidence(top...))
+	}
+	if len(recommendation.Claims) == 0 {
+		recommendation.Gaps = append(recommendation.Gaps, "Collect evidence before making a recommendation.")
+	}
+
+	return []briefSection{problem, demand, competitors, risks, recommendation}
+}
+
+func mentionsCompetitor(item agentEvidence, name string) bool {
+	needle := strings.ToLower(strings.TrimSpace(name))
+	if needle == "" {
+		return false
+	}
+	haystack := strings.ToLower(item.Title + " " + item.Snippet + " " + strings.Join(item.Snippets, " ") + " " + item.SourceName + " " + item.URL)
+	return strings.Contains(haystack, needle) || strings.Contains(haystack, strings.ReplaceAll(needle, " ", ""))
+}
+
+func mentionsBriefRisk(text string) bool {
+	for _, token := range strings.FieldsFunc(strings.ToLower(text), isThemeSeparator) {
+		for _, term := range briefRiskTerms {
+			if strings.HasPrefix(token, term) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func briefTemplateFuncs(sections []briefSection) template.FuncMap {
+	return template.FuncMap{
+		"cite": func(citations []briefCitation) string {
+			parts := make([]string, 0, len(citations))
+			for _, citation := range citations {
+				if citation.URL == "" {
+					parts = append(parts, "["+citation.EvidenceID+"]")
+					continue
+				}
+				parts = append(parts, fmt.Sprintf("[%s](%s)", citation.EvidenceID, citation.URL))
+			}
+			return strings.Join(parts, " ")
+		},
+		"section": func(id string) *briefSection {
+			for idx := range sections {
+				if sections[idx].ID == id {
+					return &sections[idx]
+				}
+			}
+			return nil
+		},
+		"join": strings.Join,
+	}
+}
+
+func parseBriefTemplate(name string, text string, sections []briefSection) (*template.Template, error) {
+	return template.New(name).Option("missingkey=error").Funcs(briefTemplateFuncs(sections)).Parse(text)
+}
+
+func executeBriefTemplate(name string, text string, brief *agentBrief) (string, error) {
+	tmpl, err := parseBriefTemplate(name, text, brief.Sections)
+	if err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, briefTemplateData{Brief: brief, Sections: brief.Sections}); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out.String()) + "\n", nil
+}
+
+// renderAgentBrief renders with the workspace template when one exists. A
+// template that no longer executes falls back to the default so runs never
+// lose their brief to a bad edit.
+func renderAgentBrief(workspace string, brief *agentBrief) (string, string) {
+	name := defaultBriefTemplateName
+	text := defaultBriefTemplate
+	if custom, ok := loadBriefTemplate(workspace); ok {
+		name, text = workspace, custom
+	}
+	document, err := executeBriefTemplate(name, text, brief)
+	if err == nil {
+		return document, name
+	}
+	log.Printf("WARNING: brief template %q failed, using default: %v", name, err)
+	document, err = executeBriefTemplate(defaultBriefTemplateName, defaultBriefTemplate, brief)
+	if err != nil {
+		log.Printf("WARNING: default brief template failed: %v", err)
+		return brief.Summary + "\n", defaultBriefTemplateName
+	}
+	return document, defaultBriefTemplateName
+}
+
+func validBriefWorkspace(workspace string) bool {
+	if workspace == "" || len(workspace) > 64 {
+		return false
+	}
+	for idx, r := range workspace {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+		case (r == '-' || r == '_') && idx > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func briefTemplatePath(workspace string) string {
+	return filepath.Join(agentBriefTemplatesDir, workspace+".tmpl")
+}
+
+func loadBriefTemplate(workspace string) (string, bool) {
+	if !validBriefWorkspace(workspace) {
+		return "", false
+	}
+	content, err := os.ReadFile(briefTemplatePath(workspace))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("WARNING: failed to read brief template for %s: %v", workspace, err)
+		}
+		return "", false
+	}
+	return string(content), true
+}
+
+// sampleAgentBrief is what uploaded templates are test-rendered against, so
+// broken templates are rejected at save time rather than at run time.
+func sampleAgentBrief() *agentBrief {
+	req := agentFeatureResearchRequest{Feature: "Sample feature", Category: "Sample", Competitors: []string{"Acme"}}
+	evidence := []agentEvidence{{
+		ID:         "ev_001",
+		SourceName: "example.com",
+		URL:        "https://example.com/post",
+		Title:      "Acme users want a sample feature",
+		Snippet:    "Acme is missing the sample feature and the workaround is slow.",
+		Mentions:   1,
+	}}
+	return buildAgentBrief(req, evidence, []themeCluster{})
+}
+
+func handleAgentBriefTemplates(w http.ResponseWriter, r *http.Request) {
+	workspace := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/agent/brief-templates"), "/ ")
+	if workspace == "" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, listBriefTemplates())
+		return
+	}
+	if workspace == defaultBriefTemplateName && r.Method == http.MethodGet {
+		writeJSON(w, http.StatusOK, briefTemplateView{Workspace: workspace, Template: defaultBriefTemplate})
+		return
+	}
+	if !validBriefWorkspace(workspace) || workspace == defaultBriefTemplateName {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "workspace must be lowercase letters, digits, '-' or '_'"})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		view := briefTemplateView{Workspace: workspace, Template: defaultBriefTemplate}
+		if custom, ok := loadBriefTemplate(workspace); ok {
+			view.Template = custom
+			view.Custom = true
+		}
+		writeJSON(w, http.StatusOK, view)
+	case http.MethodPut:
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBriefTemplateBytes+1))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: "failed to read template"})
+			return
+		}
+		if len(body) > maxBriefTemplateBytes {
+			writeJSON(w, http.StatusRequestEntityTooLarge, errorResponse{Error: "template is too large"})
+			return
+		}
+		text := string(body)
+		if strings.TrimSpace(text) == "" {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: "template is empty"})
+			return
+		}
+		if _, err := executeBriefTemplate(workspace, text, sampleAgentBrief()); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("invalid template: %v", err)})
+			return
+		}
+		if err := writeBriefTemplate(workspace, text); err != nil {
+			log.Printf("brief template %s: persist failed: %v", workspace, err)
+			writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to store template"})
+			return
+		}
+		writeJSON(w, http.StatusOK, briefTemplateView{Workspace: workspace, Template: text, Custom: true})
+	case http.MethodDelete:
+		if err := os.Remove(briefTemplatePath(workspace)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to delete template"})
+			return
+		}
+		writeJSON(w, http.StatusOK, okResponse{Status: "deleted"})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func writeBriefTemplate(workspace string, text string) error {
+	if err := os.MkdirAll(agentBriefTemplatesDir, 0o755); err != nil {
+		return err
+	}
+	path := briefTemplatePath(workspace)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(text), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func listBriefTemplates() map[string]any {
+	workspaces := []string{}
+	files, err := os.ReadDir(agentBriefTemplatesDir)
+	if err == nil {
+		for _, file := range files {
+			name := strings.TrimSuffix(file.Name(), ".tmpl")
+			if file.IsDir() || name == file.Name() || !validBriefWorkspace(name) {
+				continue
+			}
+			workspaces = append(workspaces, name)
+		}
+	}
+	sort.Strings(workspaces)
+	return map[string]any{
+		"default":    defaultBriefTemplate,
+		"workspaces": workspaces,
+	}
+}
+
+// handleAgentRunBrief re-renders a finished run's brief, optionally with
+// another workspace's template, as markdown.
+func handleAgentRunBrief(w http.ResponseWriter, r *http.Request, run agentRunRecord) {
+	if run.Artifacts.Brief == nil {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "brief not generated yet"})
+		return
+	}
+	brief := *run.Artifacts.Brief
+	workspace := strings.TrimSpace(r.URL.Query().Get("workspace"))
+	if workspace == "" {
+		workspace = run.Request.Workspace
+	}
+	document, _ := renderAgentBrief(workspace, &brief)
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.WriteString(w, document)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+	"slices"
+	"strings"
+)
+
+// Fingerprints use single words rather than shingles because snippets are a
+// sentence or two. Short snippets share most of their bits with any text that
+// reuses a few of their words, so the allowed distance grows with length and
+// snippets under minSimhashTokens words must match exactly.
+const (
+	maxEvidenceHammingDistance = 10
+	minSimhashTokens           = 8
+	maxEvidenceExtraSnippets   = 5
+)
+
+func evidenceTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), isThemeSeparator)
+}
+
+// simhashText fingerprints text so that syndicated copies with small edits
+// land within a few bits of each other.
+func simhashText(text string) uint64 {
+	tokens := evidenceTokens(text)
+	if len(tokens) == 0 {
+		return 0
+	}
+
+	var weights [64]int
+	for _, feature := range tokens {
+		hasher := fnv.New64a()
+		_, _ = hasher.Write([]byte(feature))
+		sum := hasher.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var fingerprint uint64
+	for bit, weight := range weights {
+		if weight > 0 {
+			fingerprint |= 1 << bit
+		}
+	}
+	return fingerprint
+}
+
+// evidenceHammingThreshold is the largest fingerprint distance at which two
+// snippets of the given word counts still count as the same text, or -1 if
+// they can never match.
+func evidenceHammingThreshold(tokensA, tokensB int) int {
+	shortest := min(tokensA, tokensB)
+	switch {
+	case shortest == 0:
+		return -1
+	case shortest < minSimhashTokens:
+		return 0
+	default:
+		return min(maxEvidenceHammingDistance, shortest/3)
+	}
+}
+
+// dedupeAgentEvidence lists each source once. Evidence is first grouped by
+// canonical URL, with the page's other snippets kept alongside the first and
+// the first URL seen kept for citation.
+// Pages whose leading snippets are near-duplicates, such as syndicated copies,
+// are then merged into the first page seen.
+func dedupeAgentEvidence(evidence []agentEvidence) []agentEvidence {
+	pages := make([]agentEvidence, 0, len(evidence))
+	byURL := map[string]int{}
+	for _, item := range evidence {
+		if item.Mentions <= 0 {
+			item.Mentions = 1
+		}
+		key := canonicalizeURL(item.URL)
+		idx, seen := byURL[key]
+		if !seen {
+			byURL[key] = len(pages)
+			pages = append(pages, item)
+			continue
+		}
+		page := mergeAgentEvidence(pages[idx], item)
+		page.Snippets = slices.Clone(page.Snippets)
+		for _, snippet := range append([]string{item.Snippet}, item.Snippets...) {
+			if len(page.Snippets) == maxEvidenceExtraSnippets {
+				break
+			}
+			if sameEvidenceText(snippet, page.Snippet) || slices.ContainsFunc(page.Snippets, func(existing string) bool {
+				return sameEvidenceText(existing, snippet)
+			}) {
+				continue
+			}
+			page.Snippets = append(page.Snippets, snippet)
+		}
+		pages[idx] = page
+	}
+
+	kept := make([]agentEvidence, 0, len(pages))
+	fingerprints := make([]uint64, 0, len(pages))
+	lengths := make([]int, 0, len(pages))
+	for _, item := range pages {
+		fingerprint := simhashText(item.Snippet)
+		length := len(evidenceTokens(item.Snippet))
+
+		merged := false
+		for idx := range kept {
+			if bits.OnesCount64(fingerprint^fingerprints[idx]) <= evidenceHammingThreshold(length, lengths[idx]) {
+				kept[idx] = mergeAgentEvidence(kept[idx], item)
+				merged = true
+				break
+			}
+		}
+		if merged {
+			continue
+		}
+		item.Fingerprint = fmt.Sprintf("%016x", fingerprint)
+		kept = append(kept, item)
+		fingerprints = append(fingerprints, fingerprint)
+		lengths = append(lengths, length)
+	}
+	return kept
+}
+
+func sameEvidenceText(a, b string) bool {
+	return strings.Join(evidenceTokens(a), " ") == strings.Join(evidenceTokens(b), " ")
+}
+
+// mergeAgentEvidence folds duplicate into primary. Spellings of a URL that is
+// already listed are not listed again.
+func mergeAgentEvidence(primary agentEvidence, duplicate agentEvidence) agentEvidence {
+	primary.Mentions += duplicate.Mentions
+	primary.DuplicateURLs = slices.Clone(primary.DuplicateURLs)
+	for _, link := range append([]string{duplicate.URL}, duplicate.DuplicateURLs...) {
+		key := canonicalizeURL(link)
+		if key == canonicalizeURL(primary.URL) || slices.ContainsFunc(primary.DuplicateURLs, func(existing string) bool {
+			return canonicalizeURL(existing) == key
+		}) {
+			continue
+		}
+		primary.DuplicateURLs = append(primary.DuplicateURLs, link)
+	}
+	if duplicate.Engagement > primary.Engagement {
+		primary.Engagement = duplicate.Engagement
+	}
+	if primary.PublishedAt == "" {
+		primary.PublishedAt = duplicate.PublishedAt
+	}
+	return primary
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"math/bits"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestEvidenceHammingThreshold(t *testing.T) {
+	tests := []struct {
+		a, b int
+		want int
+	}{
+		{0, 20, -1},
+		{3, 20, 0},
+		{minSimhashTokens - 1, minSimhashTokens - 1, 0},
+		{minSimhashTokens, 40, minSimhashTokens / 3},
+		{24, 30, 8},
+		{60, 90, maxEvidenceHammingDistance},
+	}
+	for _, tt := range tests {
+		if got := evidenceHammingThreshold(tt.a, tt.b); got != tt.want {
+			t.Errorf("evidenceHammingThreshold(%d, %d) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestCanonicalizeURL(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"https://WWW.Example.com/post/?utm_source=x&b=2&a=1#top", "https://example.com/post?a=1&b=2"},
+		{"http://example.com/post", "http://example.com/post"},
+		{"http://localhost:8080/x/", "http://localhost:8080/x"},
+		{"https://example.com:443/x", "https://example.com/x"},
+		{"http://example.com:80/x", "http://example.com/x"},
+		{"https://example.com:8443/x", "https://example.com:8443/x"},
+		{"http://[::1]:8080/x", "http://[::1]:8080/x"},
+		{"https://m.example.com/news/story/amp?output=amp", "https://example.com/news/story"},
+		{"https://example-com.cdn.ampproject.org/c/s/example.com/story/amp", "https://example.com/story"},
+		{"mailto:someone@example.com", "mailto:someone@example.com"},
+	}
+	for _, tt := range tests {
+		if got := canonicalizeURL(tt.raw); got != tt.want {
+			t.Errorf("canonicalizeURL(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestDedupeAgentEvidenceGroupsByCanonicalURL(t *testing.T) {
+	evidence := []agentEvidence{
+		{URL: "https://www.example.com/post/?utm_source=x", Snippet: "Dark mode is the top request in our survey."},
+		{URL: "https://example.com/post#comments", Snippet: "Export to CSV keeps coming up too."},
+		{URL: "http://example.com/post/", Snippet: "dark mode is the top request in our survey"},
+		{URL: "https://example.com/post?fbclid=abc", Snippet: "Pricing feels steep for small teams.", Engagement: 0.9},
+		{URL: "https://other.example/thread", Snippet: "Calendar sync would help."},
+	}
+	got := dedupeAgentEvidence(evidence)
+	if len(got) != 2 {
+		t.Fatalf("got %d items, want 2: %+v", len(got), got)
+	}
+	page := got[0]
+	if page.URL != "https://www.example.com/post/?utm_source=x" || page.Mentions != 4 {
+		t.Errorf("page = %s with %d mentions, want the first URL seen with 4", page.URL, page.Mentions)
+	}
+	wantSnippets := []string{"Export to CSV keeps coming up too.", "Pricing feels steep for small teams."}
+	if !slices.Equal(page.Snippets, wantSnippets) {
+		t.Errorf("snippets = %q, want %q", page.Snippets, wantSnippets)
+	}
+	// The http copy is a different URL that repeats the first snippet, so it
+	// is merged as a duplicate; the https spellings are not listed.
+	if !slices.Equal(page.DuplicateURLs, []string{"http://example.com/post/"}) {
+		t.Errorf("duplicate URLs = %q, want only the http copy", page.DuplicateURLs)
+	}
+	if page.Engagement != 0.9 {
+		t.Errorf("engagement = %v, want the highest (0.9)", page.Engagement)
+	}
+}
+
+func TestDedupeAgentEvidenceAcrossURLs(t *testing.T) {
+	article := "After two weeks of testing we found that dark mode reduced reported eye strain for most night shift nurses and the export tool saved hours every week"
+	edited := "After two weeks of testing we found that dark mode reduced reported eye strain for most night shift nurses and the export tool saved hours each week"
+	if distance := bits.OnesCount64(simhashText(article) ^ simhashText(edited)); distance > evidenceHammingThreshold(28, 28) {
+		t.Fatalf("fixture texts are %d bits apart; pick a closer edit", distance)
+	}
+
+	tests := []struct {
+		name      string
+		a, b      string
+		wantItems int
+	}{
+		{"syndicated copy", article, edited, 1},
+		{"same short quote", "Dark mode, please!", "dark mode please", 1},
+		{"distinct short quotes", "Dark mode is great", "Dark mode is broken", 2},
+		{"short quote inside a longer one", "dark mode please", article, 2},
+		{"empty snippets", "", "", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := dedupeAgentEvidence([]agentEvidence{
+				{URL: "https://blog.example/a", Snippet: tt.a},
+				{URL: "https://news.example/b", Snippet: tt.b},
+			})
+			if len(got) != tt.wantItems {
+				t.Fatalf("got %d items, want %d: %+v", len(got), tt.wantItems, got)
+			}
+			if tt.wantItems == 1 {
+				if got[0].URL != "https://blog.example/a" || got[0].Mentions != 2 {
+					t.Errorf("kept %s with %d mentions", got[0].URL, got[0].Mentions)
+				}
+				if !slices.Equal(got[0].DuplicateURLs, []string{"https://news.example/b"}) {
+					t.Errorf("duplicate urls = %q", got[0].DuplicateURLs)
+				}
+			}
+		})
+	}
+}
+
+func TestAgentResultsKeepVisitedURL(t *testing.T) {
+	link := "http://localhost:8080/amp/post?ref=changelog"
+	results := parseAgentSearchResults(map[string]any{"results": []any{map[string]any{"url": link, "title": "Post"}}}, "q")
+	if len(results) != 1 || results[0].URL != link {
+		t.Fatalf("search results = %+v, want the URL as found", results)
+	}
+	evidence := parseAgentVisitEvidence(map[string]any{"url": link, "snippets": []any{"Dark mode please"}}, results[0], time.Now())
+	if len(evidence) != 1 || evidence[0].URL != link || evidence[0].SourceName != "localhost" {
+		t.Fatalf("evidence = %+v, want the visited URL", evidence)
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	agentScoreWeightRecency     = 0.35
+	agentScoreWeightEngagement  = 0.25
+	agentScoreWeightCredibility = 0.40
+
+	agentMinRecencyHalfLifeDays = 7
+	agentUnknownRecency         = 0.5
+	agentEngagementLogCeiling   = 3
+	defaultAgentCredibility     = 0.5
+)
+
+type agentEvidenceScore struct {
+	Total       float64 `json:"total"`
+	Recency     float64 `json:"recency"`
+	Engagement  float64 `json:"engagement"`
+	Credibility float64 `json:"credibility"`
+	AgeDays     *int    `json:"age_days,omitempty"`
+	Credited    string  `json:"credited_by"`
+}
+
+type agentCredibilityTable struct {
+	Default     float64            `json:"default"`
+	SourceTypes map[string]float64 `json:"source_types"`
+	Domains     map[string]float64 `json:"domains"`
+}
+
+var agentCredibility = defaultAgentCredibilityTable()
+
+func defaultAgentCredibilityTable() agentCredibilityTable {
+	return agentCredibilityTable{
+		Default: defaultAgentCredibility,
+		SourceTypes: map[string]float64{
+			"docs":          0.9,
+			"issue_tracker": 0.8,
+			"review":        0.75,
+			"blog":          0.6,
+			"community":     0.55,
+			"web":           0.5,
+		},
+		Domains: map[string]float64{
+			"github.com":           0.8,
+			"g2.com":               0.8,
+			"capterra.com":         0.75,
+			"trustradius.com":      0.75,
+			"news.ycombinator.com": 0.6,
+			"reddit.com":           0.55,
+		},
+	}
+}
+
+func loadAgentCredibilityTable(path string) (agentCredibilityTable, error) {
+	table := defaultAgentCredibilityTable()
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return table, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return table, fmt.Errorf("read credibility table: %w", err)
+	}
+	var override agentCredibilityTable
+	if err := json.Unmarshal(content, &override); err != nil {
+		return table, fmt.Errorf("parse credibility table: %w", err)
+	}
+
+	if override.Default > 0 {
+		table.Default = clampUnit(override.Default)
+	}
+	for sourceType, weight := range override.SourceTypes {
+		table.SourceTypes[strings.ToLower(strings.TrimSpace(sourceType))] = clampUnit(weight)
+	}
+	for domain, weight := range override.Domains {
+		domain = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "www.")
+		table.Domains[domain] = clampUnit(weight)
+	}
+	return table, nil
+}
+
+func (t agentCredibilityTable) lookup(host string, sourceType string) (float64, string) {
+	host = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(host)), "www.")
+	for candidate := host; candidate != ""; {
+		if weight, ok := t.Domains[candidate]; ok {
+			return weight, "domain:" + candidate
+		}
+		dot := strings.Index(candidate, ".")
+		if dot < 0 {
+			break
+		}
+		candidate = candidate[dot+1:]
+	}
+	if weight, ok := t.SourceTypes[sourceType]; ok {
+		return weight, "source_type:" + sourceType
+	}
+	return t.Default, "default"
+}
+
+// scoreAgentEvidence drops evidence published before the research window and
+// returns the rest ranked by score, highest first.
+func scoreAgentEvidence(req agentFeatureResearchRequest, evidence []agentEvidence, now time.Time) ([]agentEvidence, int) {
+	windowDays := req.TimeWindowDays
+	if windowDays <= 0 {
+		windowDays = defaultAgentTimeWindowDays
+	}
+	halfLife := math.Max(float64(windowDays)/3, agentMinRecencyHalfLifeDays)
+
+	kept := make([]agentEvidence, 0, len(evidence))
+	dropped := 0
+	for _, item := range evidence {
+		score := agentEvidenceScore{
+			Recency:    agentUnknownRecency,
+			Engagement: normalizeAgentEngagement(item.Engagement),
+		}
+		if published, ok := parseAgentPublishedAt(item.PublishedAt); ok {
+			age := int(math.Floor(now.Sub(published).Hours() / 24))
+			if age < 0 {
+				age = 0
+			}
+			if age > windowDays {
+				dropped++
+				continue
+			}
+			score.AgeDays = &age
+			score.Recency = math.Exp(-math.Ln2 * float64(age) / halfLife)
+		}
+		score.Credibility, score.Credited = agentCredibility.lookup(item.SourceName, item.SourceType)
+		score.Total = agentScoreWeightRecency*score.Recency +
+			agentScoreWeightEngagement*score.Engagement +
+			agentScoreWeightCredibility*score.Credibility
+
+		score.Recency = roundTo(score.Recency, 3)
+		score.Engagement = roundTo(score.Engagement, 3)
+		score.Credibility = roundTo(score.Credibility, 3)
+		score.Total = roundTo(score.Total, 3)
+		item.Score = &score
+		kept = append(kept, item)
+	}
+
+	sort.SliceStable(kept, func(i, j int) bool {
+		return kept[i].Score.Total > kept[j].Score.Total
+	})
+	return kept, dropped
+}
+
+func parseAgentPublishedAt(raw string) (time.Time, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+		if parsed, err := time.Parse(layout, raw); err == nil {
+			return parsed.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// normalizeAgentEngagement accepts either a 0-1 ratio or a raw count such as
+// upvotes; counts are log-scaled so 1000 interactions saturate at 1.
+func normalizeAgentEngagement(raw float64) float64 {
+	switch {
+	case raw <= 0:
+		return 0
+	case raw <= 1:
+		return raw
+	default:
+		return clampUnit(math.Log10(1+raw) / agentEngagementLogCeiling)
+	}
+}
+
+func clampUnit(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var agentScoreNow = time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+
+func TestScoreAgentEvidenceBreakdown(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+	tests := []struct {
+		name string
+		item agentEvidence
+		want *agentEvidenceScore
+	}{
+		{
+			name: "recent issue credited by domain",
+			item: agentEvidence{SourceName: "github.com", SourceType: "issue_tracker", PublishedAt: "2026-03-29", Engagement: 0.5},
+			want: &agentEvidenceScore{Total: 0.75, Recency: 0.871, Engagement: 0.5, Credibility: 0.8, AgeDays: intPtr(2), Credited: "domain:github.com"},
+		},
+		{
+			name: "subdomain uses parent domain and raw counts are log scaled",
+			item: agentEvidence{SourceName: "old.reddit.com", SourceType: "community", PublishedAt: "2026-03-21T00:00:00Z", Engagement: 99},
+			want: &agentEvidenceScore{Total: 0.562, Recency: 0.5, Engagement: 0.667, Credibility: 0.55, AgeDays: intPtr(10), Credited: "domain:reddit.com"},
+		},
+		{
+			name: "undated evidence gets the unknown recency",
+			item: agentEvidence{SourceName: "example.com", SourceType: "docs"},
+			want: &agentEvidenceScore{Total: 0.535, Recency: 0.5, Engagement: 0, Credibility: 0.9, Credited: "source_type:docs"},
+		},
+		{
+			name: "unknown source uses the default and engagement saturates",
+			item: agentEvidence{SourceName: "x.io", SourceType: "podcast", PublishedAt: "not a date", Engagement: 5000},
+			want: &agentEvidenceScore{Total: 0.625, Recency: 0.5, Engagement: 1, Credibility: 0.5, Credited: "default"},
+		},
+		{
+			name: "future dates count as today",
+			item: agentEvidence{SourceName: "x.io", SourceType: "web", PublishedAt: "2026-04-02"},
+			want: &agentEvidenceScore{Total: 0.55, Recency: 1, Engagement: 0, Credibility: 0.5, AgeDays: intPtr(0), Credited: "source_type:web"},
+		},
+		{
+			name: "last day of the window is kept",
+			item: agentEvidence{SourceName: "x.io", SourceType: "web", PublishedAt: "2026-03-01"},
+			want: &agentEvidenceScore{Total: 0.244, Recency: 0.125, Engagement: 0, Credibility: 0.5, AgeDays: intPtr(30), Credited: "source_type:web"},
+		},
+		{
+			name: "older than the window is dropped",
+			item: agentEvidence{SourceName: "github.com", SourceType: "issue_tracker", PublishedAt: "2026-02-27", Engagement: 1},
+		},
+	}
+	for _, tt := range tests {
+		kept, dropped := scoreAgentEvidence(agentFeatureResearchRequest{TimeWindowDays: 30}, []agentEvidence{tt.item}, agentScoreNow)
+		if tt.want == nil {
+			if len(kept) != 0 || dropped != 1 {
+				t.Errorf("%s: kept %d, dropped %d; want it dropped", tt.name, len(kept), dropped)
+			}
+			continue
+		}
+		if len(kept) != 1 || dropped != 0 {
+			t.Errorf("%s: kept %d, dropped %d; want it kept", tt.name, len(kept), dropped)
+			continue
+		}
+		if !reflect.DeepEqual(kept[0].Score, tt.want) {
+			t.Errorf("%s: score = %+v, want %+v", tt.name, *kept[0].Score, *tt.want)
+		}
+	}
+}
+
+func TestScoreAgentEvidenceOrdersByDecay(t *testing.T) {
+	evidence := []agentEvidence{
+		{ID: "old", SourceName: "x.io", PublishedAt: "2025-12-01"},
+		{ID: "new", SourceName: "x.io", PublishedAt: "2026-03-30"},
+		{ID: "mid", SourceName: "x.io", PublishedAt: "2026-02-15"},
+		{ID: "stale", SourceName: "x.io", PublishedAt: "2025-06-01"},
+	}
+	// No window means the default, which keeps the December item.
+	kept, dropped := scoreAgentEvidence(agentFeatureResearchRequest{}, evidence, agentScoreNow)
+	if dropped != 1 {
+		t.Fatalf("dropped = %d, want 1", dropped)
+	}
+	ids := make([]string, 0, len(kept))
+	for _, item := range kept {
+		ids = append(ids, item.ID)
+	}
+	if !reflect.DeepEqual(ids, []string{"new", "mid", "old"}) {
+		t.Fatalf("order = %v, want newest first", ids)
+	}
+	// The half-life is a third of the window, so the score halves every 60 days.
+	halfLife := float64(defaultAgentTimeWindowDays) / 3
+	if got, want := kept[1].Score.Recency, roundTo(math.Exp(-math.Ln2*44/halfLife), 3); got != want {
+		t.Fatalf("recency at 44 days = %v, want %v", got, want)
+	}
+}
+
+func TestAgentCredibilityOverride(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credibility.json")
+	override := `{"default": 0.2, "source_types": {" Blog ": 0.3}, "domains": {"www.Example.com": 0.95, "reddit.com": 1.5}}`
+	if err := os.WriteFile(path, []byte(override), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	prevPath, prevTable := agentCredibilityPath, agentCredibility
+	t.Cleanup(func() { agentCredibilityPath, agentCredibility = prevPath, prevTable })
+
+	agentCredibilityPath = path
+	table, err := loadAgentCredibilityTable(agentCredibilityPath)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	agentCredibility = table
+
+	tests := []struct {
+		source, sourceType string
+		want               float64
+		credited           string
+	}{
+		{"docs.example.com", "docs", 0.95, "domain:example.com"},
+		{"reddit.com", "community", 1, "domain:reddit.com"},
+		{"github.com", "issue_tracker", 0.8, "domain:github.com"},
+		{"someblog.dev", "blog", 0.3, "source_type:blog"},
+		{"x.io", "podcast", 0.2, "default"},
+	}
+	for _, tt := range tests {
+		kept, _ := scoreAgentEvidence(agentFeatureResearchRequest{}, []agentEvidence{{SourceName: tt.source, SourceType: tt.sourceType}}, agentScoreNow)
+		if got := kept[0].Score; got.Credibility != tt.want || got.Credited != tt.credited {
+			t.Errorf("%s/%s: credibility %v by %q, want %v by %q", tt.source, tt.sourceType, got.Credibility, got.Credited, tt.want, tt.credited)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte(`{"domains": [`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadAgentCredibilityTable(path); err == nil {
+		t.Fatal("expected a parse error for a corrupt table")
+	}
+	if _, err := loadAgentCredibilityTable(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatal("expected an error for a missing table")
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const (
+	agentQueryPurposeMarket     = "market"
+	agentQueryPurposeDemand     = "demand"
+	agentQueryPurposePersona    = "persona"
+	agentQueryPurposeCompetitor = "competitor"
+
+	agentPlaceholderResultURL = "{{result.url}}"
+)
+
+var (
+	agentSearchResultSchema = map[string]string{
+		"title":   "h2",
+		"url":     "a@href",
+		"snippet": "[data-result=snippet]",
+	}
+	agentPageEvidenceSchema = map[string]string{
+		"title":        "title",
+		"snippets":     "p",
+		"published_at": "time@datetime",
+		"engagement":   "[data-engagement]",
+	}
+)
+
+type agentPlannedQuery struct {
+	ID         string         `json:"id"`
+	Query      string         `json:"query"`
+	Purpose    string         `json:"purpose"`
+	Competitor string         `json:"competitor,omitempty"`
+	Steps      []TinyFishStep `json:"steps"`
+}
+
+type agentVisitPlan struct {
+	ResultsPerQuery int               `json:"results_per_query"`
+	Schema          map[string]string `json:"schema"`
+	Steps           []TinyFishStep    `json:"steps"`
+}
+
+type agentPlanEstimate struct {
+	SearchCalls   int `json:"search_calls"`
+	MaxVisitCalls int `json:"max_visit_calls"`
+	MaxCalls      int `json:"max_tinyfish_calls"`
+}
+
+type agentResearchPlan struct {
+	Request  agentFeatureResearchRequest `json:"request"`
+	Queries  []agentPlannedQuery         `json:"queries"`
+	Visit    agentVisitPlan              `json:"visit"`
+	Estimate agentPlanEstimate           `json:"estimate"`
+}
+
+func planFeatureResearch(req agentFeatureResearchRequest) (agentResearchPlan, error) {
+	plan := agentResearchPlan{
+		Request: req,
+		Visit: agentVisitPlan{
+			ResultsPerQuery: maxAgentResultsPerQuery,
+			Schema:          agentPageEvidenceSchema,
+			Steps:           agentVisitSteps(agentPlaceholderResultURL),
+		},
+	}
+
+	plan.Queries = agentSearchQueries(req)
+	for idx := range plan.Queries {
+		plan.Queries[idx].ID = fmt.Sprintf("q%02d", idx+1)
+		plan.Queries[idx].Steps = agentSearchSteps(plan.Queries[idx].Query)
+	}
+
+	for _, query := range plan.Queries {
+		if err := validateTinyFishSteps(query.Steps); err != nil {
+			return agentResearchPlan{}, fmt.Errorf("query %s: %w", query.ID, err)
+		}
+	}
+	if err := validateTinyFishSteps(agentVisitSteps("https://example.com/")); err != nil {
+		return agentResearchPlan{}, fmt.Errorf("visit plan: %w", err)
+	}
+
+	plan.Estimate = agentPlanEstimate{
+		SearchCalls:   len(plan.Queries),
+		MaxVisitCalls: len(plan.Queries) * plan.Visit.ResultsPerQuery,
+	}
+	plan.Estimate.MaxCalls = plan.Estimate.SearchCalls + plan.Estimate.MaxVisitCalls
+	return plan, nil
+}
+
+// agentSearchQueries lists the searches the planner runs, without IDs or
+// steps.
+func agentSearchQueries(req agentFeatureResearchRequest) []agentPlannedQuery {
+	queries := []agentPlannedQuery{
+		{Purpose: agentQueryPurposeMarket, Query: fmt.Sprintf("%s %s", req.Feature, req.Category)},
+		{Purpose: agentQueryPurposeDemand, Query: fmt.Sprintf("%s feature request", req.Feature)},
+	}
+	if req.Persona != "" {
+		queries = append(queries, agentPlannedQuery{Purpose: agentQueryPurposePersona, Query: fmt.Sprintf("%s %s for %s", req.Feature, req.Category, req.Persona)})
+	}
+	for _, competitor := range req.Competitors {
+		queries = append(queries, agentPlannedQuery{Purpose: agentQueryPurposeCompetitor, Competitor: competitor, Query: fmt.Sprintf("%s %s", competitor, req.Feature)})
+	}
+	return queries
+}
+
+func agentSearchSteps(query string) []TinyFishStep {
+	return []TinyFishStep{
+		{Action: TinyFishActionGoto, URL: agentSearchEngineURL},
+		{Action: TinyFishActionType, Selector: "input[name=q]", Text: query},
+		{Action: TinyFishActionClick, Selector: "button[type=submit]"},
+		{Action: TinyFishActionWait, MS: 1500},
+		{
+			Action:   TinyFishActionExtractList,
+			Selector: "article[data-testid=result]",
+			Limit:    maxAgentResultsPerQuery,
+			Fields:   agentSearchResultSchema,
+		},
+	}
+}
+
+func agentVisitSteps(pageURL string) []TinyFishStep {
+	return []TinyFishStep{
+		{Action: TinyFishActionGoto, URL: pageURL},
+		{Action: TinyFishActionWait, MS: 1200},
+		{Action: TinyFishActionScroll, MaxScrolls: 2},
+		{Action: TinyFishActionExtract, Fields: agentPageEvidenceSchema},
+	}
+}
+
+func handleAgentFeatureResearchPlan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req agentFeatureResearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid json"})
+		return
+	}
+	req, err := normalizeFeatureResearchRequest(req)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+
+	plan, err := planFeatureResearch(req)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, plan)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func postResearchPlan(t *testing.T, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	blob, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	handleAgentFeatureResearchPlan(rec, httptest.NewRequest(http.MethodPost, "/api/agent/feature-research/plan", strings.NewReader(string(blob))))
+	return rec
+}
+
+func TestResearchPlanQueries(t *testing.T) {
+	rec := postResearchPlan(t, agentFeatureResearchRequest{
+		Feature:     "Dark mode",
+		Category:    "note taking",
+		Persona:     "developers",
+		Competitors: []string{"Notion", "notion", "Obsidian"},
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	var plan agentResearchPlan
+	if err := json.Unmarshal(rec.Body.Bytes(), &plan); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"Dark mode note taking",
+		"Dark mode feature request",
+		"Dark mode note taking for developers",
+		"Notion Dark mode",
+		"Obsidian Dark mode",
+	}
+	if len(plan.Queries) != len(want) {
+		t.Fatalf("got %d queries, want %d", len(plan.Queries), len(want))
+	}
+	for idx, query := range plan.Queries {
+		if query.Query != want[idx] {
+			t.Errorf("query %d = %q, want %q", idx, query.Query, want[idx])
+		}
+		if err := validateTinyFishSteps(query.Steps); err != nil {
+			t.Errorf("query %s steps invalid: %v", query.ID, err)
+		}
+	}
+	if plan.Queries[0].ID != "q01" || plan.Queries[4].Competitor != "Obsidian" {
+		t.Errorf("unexpected ids or competitors: %+v", plan.Queries)
+	}
+	if plan.Estimate.MaxCalls != len(want)*(1+maxAgentResultsPerQuery) {
+		t.Errorf("estimate = %+v", plan.Estimate)
+	}
+}
+
+func TestResearchPlanRejectsLongInput(t *testing.T) {
+	tests := []struct {
+		name string
+		req  agentFeatureResearchRequest
+	}{
+		{"feature", agentFeatureResearchRequest{Feature: strings.Repeat("x", maxTinyFishTextLength+1), Category: "notes"}},
+		{"feature and category", agentFeatureResearchRequest{Feature: strings.Repeat("x", 300), Category: strings.Repeat("y", 250)}},
+		{"competitor", agentFeatureResearchRequest{Feature: "Dark mode", Category: "notes", Competitors: []string{strings.Repeat("z", maxTinyFishTextLength)}}},
+		{"missing category", agentFeatureResearchRequest{Feature: "Dark mode"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := postResearchPlan(t, tt.req)
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400: %s", rec.Code, rec.Body)
+			}
+		})
+	}
+}
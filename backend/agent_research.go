@@ -0,0 +1,916 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	agentRunStatusQueued    = "queued"
+	agentRunStatusRunning   = "running"
+	agentRunStatusCompleted = "completed"
+	agentRunStatusFailed    = "failed"
+	agentRunStatusCancelled = "cancelled"
+
+	agentStepQueued     = "QUEUED"
+	agentStepSearch     = "SEARCH"
+	agentStepVisit      = "VISIT"
+	agentStepSynthesize = "SYNTHESIZE"
+	agentStepDone       = "DONE"
+
+	defaultAgentTimeWindowDays = 180
+	maxAgentTimeWindowDays     = 730
+	maxAgentCompetitors        = 5
+	maxAgentResultsPerQuery    = 5
+	maxAgentSnippetLength      = 400
+	maxAgentThemeSignals       = 100
+	agentSearchEngineURL       = "https://duckduckgo.com/"
+)
+
+type agentFeatureResearchRequest struct {
+	Feature        string   `json:"feature"`
+	Category       string   `json:"category"`
+	Persona        string   `json:"persona,omitempty"`
+	Competitors    []string `json:"competitors"`
+	TimeWindowDays int      `json:"time_window_days"`
+	BypassCache    bool     `json:"bypass_cache,omitempty"`
+	Workspace      string   `json:"workspace,omitempty"`
+}
+
+type agentRunProgress struct {
+	PagesVisited  int `json:"pagesVisited"`
+	EvidenceCount int `json:"evidenceCount"`
+	ThemesCount   int `json:"themesCount"`
+}
+
+type agentEvidence struct {
+	ID            string              `json:"id"`
+	SourceType    string              `json:"source_type"`
+	SourceName    string              `json:"source_name"`
+	URL           string              `json:"url"`
+	Title         string              `json:"title"`
+	Snippet       string              `json:"snippet"`
+	Query         string              `json:"query"`
+	CapturedAt    string              `json:"captured_at"`
+	PublishedAt   string              `json:"published_at,omitempty"`
+	Engagement    float64             `json:"engagement,omitempty"`
+	Mentions      int                 `json:"mentions"`
+	Snippets      []string            `json:"snippets,omitempty"`
+	DuplicateURLs []string            `json:"duplicate_urls,omitempty"`
+	Fingerprint   string              `json:"fingerprint,omitempty"`
+	Score         *agentEvidenceScore `json:"score,omitempty"`
+}
+
+type agentBrief struct {
+	Feature     string         `json:"feature"`
+	Category    string         `json:"category"`
+	Persona     string         `json:"persona,omitempty"`
+	Priority    string         `json:"priority"`
+	Confidence  float64        `json:"confidence"`
+	Summary     string         `json:"summary"`
+	Competitors []string       `json:"competitors"`
+	GeneratedAt string         `json:"generated_at"`
+	Sections    []briefSection `json:"sections"`
+	Template    string         `json:"template"`
+	Document    string         `json:"document"`
+}
+
+type agentMarketSignal struct {
+	Sources      int            `json:"sources"`
+	Evidence     int            `json:"evidence"`
+	Mentions     int            `json:"mentions"`
+	BySourceType map[string]int `json:"by_source_type"`
+	OutOfWindow  int            `json:"out_of_window"`
+}
+
+type agentRunArtifacts struct {
+	Themes       []themeCluster     `json:"themes"`
+	Evidence     []agentEvidence    `json:"evidence"`
+	Brief        *agentBrief        `json:"brief"`
+	URLs         []string           `json:"urls"`
+	MarketSignal *agentMarketSignal `json:"market_signal"`
+}
+
+type agentRunRecord struct {
+	ID        string                      `json:"id"`
+	Request   agentFeatureResearchRequest `json:"request"`
+	Status    string                      `json:"status"`
+	Step      string                      `json:"step"`
+	Progress  agentRunProgress            `json:"progress"`
+	Error     string                      `json:"error,omitempty"`
+	DemoMode  bool                        `json:"demoMode"`
+	Sessions  []string                    `json:"sessions,omitempty"`
+	Artifacts agentRunArtifacts           `json:"artifacts"`
+	CreatedAt time.Time                   `json:"createdAt"`
+	UpdatedAt time.Time                   `json:"updatedAt"`
+
+	QueriesCompleted int                  `json:"queriesCompleted"`
+	SearchResults    []agentSearchResult  `json:"searchResults,omitempty"`
+	VisitsCompleted  int                  `json:"visitsCompleted"`
+	VisitFailures    int                  `json:"visitFailures,omitempty"`
+	Checkpoints      []agentRunCheckpoint `json:"checkpoints,omitempty"`
+	ResumeCount      int                  `json:"resumeCount,omitempty"`
+	Events           []runEvent           `json:"events,omitempty"`
+	EventSeq         int64                `json:"eventSeq,omitempty"`
+	Cache            agentCacheStats      `json:"cache"`
+}
+
+type agentRunView struct {
+	RunID     string                      `json:"runId"`
+	Status    string                      `json:"status"`
+	Step      string                      `json:"step"`
+	Progress  agentRunProgress            `json:"progress"`
+	Error     *string                     `json:"error"`
+	DemoMode  bool                        `json:"demo_mode"`
+	Request   agentFeatureResearchRequest `json:"request"`
+	Cache     agentCacheStats             `json:"cache"`
+	CreatedAt string                      `json:"createdAt"`
+	UpdatedAt string                      `json:"updatedAt"`
+}
+
+type agentConfigResponse struct {
+	DemoMode        bool                  `json:"demo_mode"`
+	Adapter         string                `json:"adapter"`
+	TinyFishBaseURL string                `json:"tinyfish_base_url,omitempty"`
+	FixturesPath    string                `json:"fixtures_path,omitempty"`
+	Fixtures        int                   `json:"fixtures,omitempty"`
+	CircuitBreaker  *circuitBreakerView   `json:"circuit_breaker,omitempty"`
+	Concurrency     agentConcurrencyView  `json:"concurrency"`
+	Credibility     agentCredibilityTable `json:"credibility"`
+	Cache           tinyFishCacheView     `json:"cache"`
+}
+
+type featureResearchAgent struct {
+	adapter TinyFishAdapter
+	store   *agentRunStore
+	cache   *tinyFishCache
+}
+
+type agentSearchResult struct {
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Query   string `json:"query"`
+	Visited bool   `json:"visited,omitempty"`
+}
+
+func (run agentRunRecord) terminal() bool {
+	return run.Status != agentRunStatusQueued && run.Status != agentRunStatusRunning
+}
+
+func (run agentRunRecord) view() agentRunView {
+	view := agentRunView{
+		RunID:     run.ID,
+		Status:    run.Status,
+		Step:      run.Step,
+		Progress:  run.Progress,
+		DemoMode:  run.DemoMode,
+		Request:   run.Request,
+		Cache:     run.Cache,
+		CreatedAt: run.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: run.UpdatedAt.Format(time.RFC3339),
+	}
+	if strings.TrimSpace(run.Error) != "" {
+		errText := run.Error
+		view.Error = &errText
+	}
+	return view
+}
+
+func handleAgentConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	adapter := newTinyFishAdapter()
+	resp := agentConfigResponse{
+		DemoMode:    adapter.IsDemoMode(),
+		Adapter:     tinyFishAdapterName(),
+		Concurrency: agentConcurrency(),
+		Credibility: agentCredibility,
+		Cache:       agentTinyFishCache.View(),
+	}
+	if tinyFishUsesHTTP() {
+		breaker := sharedTinyFishBreaker().View()
+		resp.TinyFishBaseURL = tinyFishBaseURL
+		resp.CircuitBreaker = &breaker
+	}
+	if tinyFishMode == tinyFishModeRecord || tinyFishMode == tinyFishModeReplay {
+		resp.FixturesPath = tinyFishFixturesPath
+		resp.Fixtures = tinyFishFixtures().Count()
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func handleAgentFeatureResearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req agentFeatureResearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid json"})
+		return
+	}
+	req, err := normalizeFeatureResearchRequest(req)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	if refresh, err := strconv.ParseBool(r.URL.Query().Get("refresh")); err == nil && refresh {
+		req.BypassCache = true
+	}
+
+	if tinyFishUsesHTTP() && sharedTinyFishBreaker().IsOpen() {
+		breaker := sharedTinyFishBreaker().View()
+		writeJSON(w, http.StatusServiceUnavailable, errorResponse{Error: fmt.Sprintf("TinyFish is unavailable after repeated failures; retry after %s", breaker.RetryAt)})
+		return
+	}
+
+	adapter := newTinyFishAdapter()
+	now := time.Now().UTC()
+	run := agentRunRecord{
+		ID:       fmt.Sprintf("research_%d", now.UnixNano()),
+		Request:  req,
+		Status:   agentRunStatusQueued,
+		Step:     agentStepQueued,
+		DemoMode: adapter.IsDemoMode(),
+		Cache:    agentCacheStats{Bypassed: req.BypassCache},
+		Artifacts: agentRunArtifacts{
+			Themes:   []themeCluster{},
+			Evidence: []agentEvidence{},
+			URLs:     []string{},
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := agentRunStoreInstance.Create(run); err != nil {
+		log.Printf("agent run %s: persist failed: %v", run.ID, err)
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to store agent run"})
+		return
+	}
+
+	startAgentRun(&featureResearchAgent{adapter: adapter, store: agentRunStoreInstance, cache: agentTinyFishCache}, run.ID)
+
+	writeJSON(w, http.StatusAccepted, map[string]any{
+		"runId":     run.ID,
+		"status":    run.Status,
+		"demo_mode": run.DemoMode,
+	})
+}
+
+func handleAgentRuns(w http.ResponseWriter, r *http.Request) {
+	raw := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/agent/runs/"), "/ ")
+	if raw == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "missing run id"})
+		return
+	}
+	parts := strings.Split(raw, "/")
+	runID := strings.TrimSpace(parts[0])
+
+	if len(parts) == 2 && parts[1] == "cancel" {
+		handleAgentRunCancel(w, r, runID)
+		return
+	}
+	if len(parts) == 2 && parts[1] == "events" {
+		handleAgentRunEvents(w, r, runID)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	run, ok := agentRunStoreInstance.Get(runID)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "agent run not found"})
+		return
+	}
+
+	switch {
+	case len(parts) == 1:
+		writeJSON(w, http.StatusOK, run.view())
+	case len(parts) == 2 && parts[1] == "artifacts":
+		writeJSON(w, http.StatusOK, run.Artifacts)
+	case len(parts) == 2 && parts[1] == "brief":
+		handleAgentRunBrief(w, r, run)
+	default:
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "unknown agent run resource"})
+	}
+}
+
+func normalizeFeatureResearchRequest(req agentFeatureResearchRequest) (agentFeatureResearchRequest, error) {
+	req.Feature = strings.TrimSpace(req.Feature)
+	req.Category = strings.TrimSpace(req.Category)
+	req.Persona = strings.TrimSpace(req.Persona)
+	req.Workspace = strings.ToLower(strings.TrimSpace(req.Workspace))
+	if req.Workspace != "" && !validBriefWorkspace(req.Workspace) {
+		return req, errors.New("workspace must be lowercase letters, digits, '-' or '_'")
+	}
+	if req.Feature == "" {
+		return req, errors.New("feature is required")
+	}
+	if req.Category == "" {
+		return req, errors.New("category is required")
+	}
+
+	competitors := make([]string, 0, len(req.Competitors))
+	seen := map[string]struct{}{}
+	for _, raw := range req.Competitors {
+		name := strings.TrimSpace(raw)
+		key := strings.ToLower(name)
+		if name == "" {
+			continue
+		}
+		if _, exists := seen[key]; exists {
+			continue
+		}
+		seen[key] = struct{}{}
+		competitors = append(competitors, name)
+	}
+	if len(competitors) > maxAgentCompetitors {
+		competitors = competitors[:maxAgentCompetitors]
+	}
+	req.Competitors = competitors
+
+	if req.TimeWindowDays <= 0 {
+		req.TimeWindowDays = defaultAgentTimeWindowDays
+	}
+	if req.TimeWindowDays > maxAgentTimeWindowDays {
+		req.TimeWindowDays = maxAgentTimeWindowDays
+	}
+
+	// Every search is typed into the search box, so each query built from
+	// these fields has to fit TinyFish's text limit.
+	for _, query := range agentSearchQueries(req) {
+		if len([]rune(query.Query)) > maxTinyFishTextLength {
+			return req, fmt.Errorf("%s search query exceeds %d characters; shorten feature, category, persona or competitor names", query.Purpose, maxTinyFishTextLength)
+		}
+	}
+	return req, nil
+}
+
+func (a *featureResearchAgent) run(ctx context.Context, runID string) {
+	run, ok := a.store.Get(runID)
+	if !ok {
+		return
+	}
+
+	pool := newAgentSessionPool(a.adapter, runID, agentMaxSessions, run.Sessions, func(sessions []string) {
+		if err := a.store.Update(runID, func(rec *agentRunRecord) {
+			rec.Sessions = sessions
+			rec.emit(runEventLog, map[string]any{
+				"step":    rec.Step,
+				"message": fmt.Sprintf("Opened TinyFish session %d of %d.", len(sessions), max(agentMaxSessions, len(sessions))),
+			})
+		}); err != nil {
+			log.Printf("agent run %s: persist failed: %v", runID, err)
+		}
+	})
+	defer pool.Close()
+	if err := a.store.Update(runID, func(rec *agentRunRecord) {
+		rec.Status = agentRunStatusRunning
+		if rec.Step == agentStepQueued {
+			rec.Step = agentStepSearch
+		}
+	}); err != nil {
+		log.Printf("agent run %s: persist failed: %v", runID, err)
+	}
+
+	if err := a.search(ctx, runID, pool); err != nil {
+		a.fail(ctx, runID, err)
+		return
+	}
+
+	a.advance(runID, agentStepVisit)
+	if err := a.visit(ctx, runID, pool); err != nil {
+		a.fail(ctx, runID, err)
+		return
+	}
+
+	if ctx.Err() != nil {
+		a.fail(ctx, runID, ctx.Err())
+		return
+	}
+	a.advance(runID, agentStepSynthesize)
+	a.synthesize(runID, agentRunStatusCompleted, "")
+}
+
+func (a *featureResearchAgent) advance(runID string, step string) {
+	if err := a.store.Update(runID, func(rec *agentRunRecord) {
+		rec.Step = step
+	}); err != nil {
+		log.Printf("agent run %s: persist failed: %v", runID, err)
+	}
+}
+
+func (a *featureResearchAgent) fail(ctx context.Context, runID string, err error) {
+	if cause := context.Cause(ctx); cause != nil {
+		switch {
+		case errors.Is(cause, errAgentRunCancelled):
+			log.Printf("agent run %s cancelled", runID)
+			a.synthesize(runID, agentRunStatusCancelled, "")
+			return
+		case errors.Is(cause, errAgentRunTimedOut):
+			err = fmt.Errorf("run exceeded its %s deadline", agentRunTimeout)
+		}
+	}
+
+	log.Printf("agent run %s failed: %v", runID, err)
+	_ = a.store.Update(runID, func(rec *agentRunRecord) {
+		rec.Status = agentRunStatusFailed
+		rec.Error = err.Error()
+	})
+}
+
+func (a *featureResearchAgent) runStep(ctx context.Context, sessionID string, step string, steps []TinyFishStep) (map[string]any, error) {
+	if err := validateTinyFishSteps(steps); err != nil {
+		return nil, err
+	}
+	stepCtx, cancel := context.WithTimeout(ctx, agentStepTimeout)
+	defer cancel()
+	started := time.Now()
+	resp, err := a.adapter.RunSteps(stepCtx, sessionID, steps)
+	if err != nil && ctx.Err() == nil && errors.Is(stepCtx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("step %s timed out after %s", step, agentStepTimeout)
+	}
+	if err == nil && a.cache != nil {
+		if cacheErr := a.cache.Put(steps, resp, time.Since(started)); cacheErr != nil {
+			log.Printf("WARNING: tinyfish cache write failed: %v", cacheErr)
+		}
+	}
+	return resp, err
+}
+
+// cachedStep serves steps from the content cache unless the run asked for a
+// forced refresh. savedMS is how long the original fetch took.
+func (a *featureResearchAgent) cachedStep(req agentFeatureResearchRequest, steps []TinyFishStep) (resp map[string]any, savedMS int64, ok bool) {
+	if a.cache == nil || req.BypassCache {
+		return nil, 0, false
+	}
+	return a.cache.Get(steps)
+}
+
+func (a *featureResearchAgent) search(ctx context.Context, runID string, pool *agentSessionPool) error {
+	run, ok := a.store.Get(runID)
+	if !ok {
+		return fmt.Errorf("agent run %s not found", runID)
+	}
+
+	// Results are deduplicated by canonical URL but visited as found.
+	seen := map[string]struct{}{}
+	for _, result := range run.SearchResults {
+		seen[canonicalizeURL(result.URL)] = struct{}{}
+	}
+
+	plan, err := planFeatureResearch(run.Request)
+	if err != nil {
+		return fmt.Errorf("plan research: %w", err)
+	}
+	if run.QueriesCompleted >= len(plan.Queries) {
+		return nil
+	}
+
+	sessionID := ""
+	defer func() {
+		if sessionID != "" {
+			pool.Release(sessionID)
+		}
+	}()
+
+	for idx := run.QueriesCompleted; idx < len(plan.Queries); idx++ {
+		query := plan.Queries[idx].Query
+		resp, savedMS, hit := a.cachedStep(run.Request, plan.Queries[idx].Steps)
+		if !hit {
+			if sessionID == "" {
+				if sessionID, err = pool.Acquire(ctx); err != nil {
+					return err
+				}
+			}
+			if resp, err = a.runStep(ctx, sessionID, agentStepSearch, plan.Queries[idx].Steps); err != nil {
+				return fmt.Errorf("search %q: %w", query, err)
+			}
+		}
+
+		found := parseAgentSearchResults(resp, query)
+		pages := intFromAny(resp["pagesVisited"])
+		fresh := make([]agentSearchResult, 0, len(found))
+		for _, result := range found {
+			key := canonicalizeURL(result.URL)
+			if _, exists := seen[key]; exists {
+				continue
+			}
+			seen[key] = struct{}{}
+			fresh = append(fresh, result)
+		}
+
+		completed := idx + 1
+		if err := a.store.Update(runID, func(rec *agentRunRecord) {
+			rec.Progress.PagesVisited += pages
+			for _, result := range fresh {
+				rec.SearchResults = append(rec.SearchResults, result)
+				rec.Artifacts.URLs = append(rec.Artifacts.URLs, result.URL)
+			}
+			rec.QueriesCompleted = completed
+			if a.cache != nil {
+				rec.Cache.record(hit, savedMS)
+			}
+			rec.checkpoint(agentStepSearch, completed)
+			rec.emit(runEventLog, map[string]any{
+				"step":    agentStepSearch,
+				"message": fmt.Sprintf("Query %s %q returned %d new results.", plan.Queries[idx].ID, query, len(fresh)),
+			})
+		}); err != nil {
+			return fmt.Errorf("checkpoint search: %w", err)
+		}
+	}
+	return nil
+}
+
+func (a *featureResearchAgent) visit(ctx context.Context, runID string, pool *agentSessionPool) error {
+	run, ok := a.store.Get(runID)
+	if !ok {
+		return fmt.Errorf("agent run %s not found", runID)
+	}
+
+	visitCtx, abort := context.WithCancelCause(ctx)
+	defer abort(nil)
+	limiter := newAgentVisitLimiter(agentMaxConcurrentVisits, agentMaxVisitsPerDomain)
+
+	var wg sync.WaitGroup
+	for idx, result := range run.SearchResults {
+		if result.Visited {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := a.visitPage(visitCtx, run.Request, runID, idx, result, pool, limiter); err != nil {
+				abort(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if visitCtx.Err() != nil {
+		return context.Cause(visitCtx)
+	}
+
+	run, _ = a.store.Get(runID)
+	if len(run.SearchResults) > 0 && run.VisitFailures == len(run.SearchResults) {
+		return errors.New("all page visits failed")
+	}
+	return nil
+}
+
+func (a *featureResearchAgent) visitPage(ctx context.Context, req agentFeatureResearchRequest, runID string, idx int, result agentSearchResult, pool *agentSessionPool, limiter *agentVisitLimiter) error {
+	steps := agentVisitSteps(result.URL)
+	if resp, savedMS, hit := a.cachedStep(req, steps); hit {
+		return a.recordVisit(runID, idx, parseAgentVisitEvidence(resp, result, time.Now().UTC()), nil, true, savedMS)
+	}
+
+	release, err := limiter.Acquire(ctx, extractHost(result.URL))
+	if err != nil {
+		return nil
+	}
+	defer release()
+
+	sessionID, err := pool.Acquire(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+	resp, err := a.runStep(ctx, sessionID, agentStepVisit, steps)
+	pool.Release(sessionID)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if errors.Is(err, errTinyFishCircuitOpen) {
+			return fmt.Errorf("TinyFish unavailable, stopped visiting pages: %w", err)
+		}
+		log.Printf("agent run %s: visit %s failed: %v", runID, result.URL, err)
+		return a.recordVisit(runID, idx, nil, err, false, 0)
+	}
+
+	return a.recordVisit(runID, idx, parseAgentVisitEvidence(resp, result, time.Now().UTC()), nil, false, 0)
+}
+
+func (a *featureResearchAgent) recordVisit(runID string, idx int, evidence []agentEvidence, failure error, cached bool, savedMS int64) error {
+	if err := a.store.Update(runID, func(rec *agentRunRecord) {
+		if idx >= len(rec.SearchResults) || rec.SearchResults[idx].Visited {
+			return
+		}
+		rec.SearchResults[idx].Visited = true
+		pageURL := rec.SearchResults[idx].URL
+		if failure != nil {
+			rec.VisitFailures++
+			rec.emit(runEventLog, map[string]any{
+				"step":    agentStepVisit,
+				"level":   "warning",
+				"message": fmt.Sprintf("Visit %s failed: %v", pageURL, failure),
+			})
+		} else {
+			rec.emit(runEventLog, map[string]any{
+				"step":    agentStepVisit,
+				"message": fmt.Sprintf("Visited %s and captured %d snippets.", pageURL, len(evidence)),
+			})
+			for _, item := range evidence {
+				item.ID = fmt.Sprintf("ev_%03d", len(rec.Artifacts.Evidence)+1)
+				rec.Artifacts.Evidence = append(rec.Artifacts.Evidence, item)
+			}
+			rec.Progress.PagesVisited++
+			rec.Progress.EvidenceCount = len(rec.Artifacts.Evidence)
+		}
+		rec.VisitsCompleted++
+		if a.cache != nil {
+			rec.Cache.record(cached, savedMS)
+		}
+		rec.checkpoint(agentStepVisit, rec.VisitsCompleted)
+	}); err != nil {
+		return fmt.Errorf("checkpoint visit: %w", err)
+	}
+	return nil
+}
+
+func (a *featureResearchAgent) synthesize(runID string, status string, errText string) {
+	if err := a.store.Update(runID, func(rec *agentRunRecord) {
+		evidence, dropped := scoreAgentEvidence(rec.Request, dedupeAgentEvidence(rec.Artifacts.Evidence), time.Now().UTC())
+		rec.Artifacts.Evidence = evidence
+		rec.Progress.EvidenceCount = len(evidence)
+		themes := buildAgentThemes(rec.Request, rec.Artifacts.Evidence)
+		rec.Artifacts.Themes = themes
+		rec.Artifacts.MarketSignal = buildAgentMarketSignal(rec.Artifacts.Evidence)
+		rec.Artifacts.MarketSignal.OutOfWindow = dropped
+		rec.Artifacts.Brief = buildAgentBrief(rec.Request, rec.Artifacts.Evidence, themes)
+		rec.Progress.ThemesCount = len(themes)
+		rec.emit(runEventArtifact, map[string]any{"artifact": "evidence", "count": len(rec.Artifacts.Evidence)})
+		rec.emit(runEventArtifact, map[string]any{"artifact": "themes", "count": len(themes)})
+		rec.emit(runEventArtifact, map[string]any{"artifact": "market_signal"})
+		rec.emit(runEventArtifact, map[string]any{"artifact": "brief", "priority": rec.Artifacts.Brief.Priority})
+		if lookups := rec.Cache.Hits + rec.Cache.Misses; lookups > 0 {
+			rec.emit(runEventLog, map[string]any{
+				"step":    agentStepSynthesize,
+				"message": fmt.Sprintf("Served %d of %d TinyFish calls from cache, saving about %s.", rec.Cache.Hits, lookups, (time.Duration(rec.Cache.SavedMS) * time.Millisecond).Round(time.Millisecond)),
+			})
+		}
+		rec.Status = status
+		rec.Error = errText
+		if status == agentRunStatusCompleted {
+			rec.Step = agentStepDone
+			rec.checkpoint(agentStepSynthesize, 1)
+		}
+	}); err != nil {
+		log.Printf("agent run %s: persist failed: %v", runID, err)
+	}
+}
+
+func parseAgentSearchResults(resp map[string]any, query string) []agentSearchResult {
+	rawResults, _ := resp["results"].([]any)
+	if rawResults == nil {
+		if typed, ok := resp["results"].([]map[string]any); ok {
+			for _, item := range typed {
+				rawResults = append(rawResults, item)
+			}
+		}
+	}
+
+	results := make([]agentSearchResult, 0, len(rawResults))
+	for _, raw := range rawResults {
+		item, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		link := strings.TrimSpace(stringFromAny(item["url"]))
+		parsed, err := url.Parse(link)
+		if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			continue
+		}
+		results = append(results, agentSearchResult{
+			Title: strings.TrimSpace(stringFromAny(item["title"])),
+			URL:   link,
+			Query: query,
+		})
+		if len(results) >= maxAgentResultsPerQuery {
+			break
+		}
+	}
+	return results
+}
+
+func parseAgentVisitEvidence(resp map[string]any, result agentSearchResult, capturedAt time.Time) []agentEvidence {
+	pageURL := strings.TrimSpace(stringFromAny(resp["url"]))
+	if pageURL == "" {
+		pageURL = result.URL
+	}
+	title := strings.TrimSpace(stringFromAny(resp["title"]))
+	if title == "" {
+		title = result.Title
+	}
+	host := extractHost(pageURL)
+
+	snippets := make([]string, 0, 4)
+	switch typed := resp["snippets"].(type) {
+	case []any:
+		for _, raw := range typed {
+			snippets = append(snippets, stringFromAny(raw))
+		}
+	case []string:
+		snippets = append(snippets, typed...)
+	case string:
+		snippets = append(snippets, typed)
+	}
+
+	evidence := make([]agentEvidence, 0, len(snippets))
+	for _, snippet := range snippets {
+		snippet = strings.TrimSpace(snippet)
+		if snippet == "" {
+			continue
+		}
+		evidence = append(evidence, agentEvidence{
+			SourceType:  classifyAgentSource(host),
+			SourceName:  host,
+			URL:         pageURL,
+			Title:       title,
+			Snippet:     truncateText(snippet, maxAgentSnippetLength),
+			Query:       result.Query,
+			CapturedAt:  capturedAt.Format(time.RFC3339),
+			PublishedAt: strings.TrimSpace(stringFromAny(resp["published_at"])),
+			Engagement:  floatFromAny(resp["engagement"]),
+		})
+	}
+	return evidence
+}
+
+func classifyAgentSource(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	switch {
+	case host == "reddit.com" || strings.HasSuffix(host, ".reddit.com") || strings.Contains(host, "forum") || strings.Contains(host, "community"):
+		return "community"
+	case host == "github.com" || strings.HasSuffix(host, ".github.com"):
+		return "issue_tracker"
+	case host == "g2.com" || host == "capterra.com" || host == "trustradius.com" || strings.Contains(host, "review"):
+		return "review"
+	case strings.HasPrefix(host, "docs.") || strings.HasPrefix(host, "help.") || strings.HasPrefix(host, "support."):
+		return "docs"
+	case strings.Contains(host, "blog"):
+		return "blog"
+	default:
+		return "web"
+	}
+}
+
+func buildAgentThemes(req agentFeatureResearchRequest, evidence []agentEvidence) []themeCluster {
+	docs := make([]themeDocument, 0, len(evidence)+maxAgentThemeSignals)
+	for _, item := range evidence {
+		docs = append(docs, themeDocument{
+			ID:     item.ID,
+			Source: item.SourceName,
+			Text:   strings.Join(append([]string{item.Snippet}, item.Snippets...), " "),
+			URL:    item.URL,
+			Weight: item.Mentions,
+		})
+	}
+	if integrationStoreInstance != nil {
+		for _, signal := range integrationStoreInstance.SearchSignals(req.Feature, maxAgentThemeSignals) {
+			docs = append(docs, themeDocument{
+				ID:     signal.ID,
+				Source: signal.Source,
+				Text:   signal.Summary,
+				URL:    signal.Meta["permalink"],
+			})
+		}
+	}
+	if len(docs) == 0 {
+		return []themeCluster{}
+	}
+
+	stopWords := append([]string{req.Feature, req.Category}, req.Competitors...)
+	return clusterThemes(docs, stopWords)
+}
+
+func buildAgentMarketSignal(evidence []agentEvidence) *agentMarketSignal {
+	signal := &agentMarketSignal{
+		Evidence:     len(evidence),
+		BySourceType: map[string]int{},
+	}
+	sources := map[string]struct{}{}
+	for _, item := range evidence {
+		sources[item.URL] = struct{}{}
+		for _, link := range item.DuplicateURLs {
+			sources[link] = struct{}{}
+		}
+		signal.BySourceType[item.SourceType]++
+		signal.Mentions += max(item.Mentions, 1)
+	}
+	signal.Sources = len(sources)
+	return signal
+}
+
+func buildAgentBrief(req agentFeatureResearchRequest, evidence []agentEvidence, themes []themeCluster) *agentBrief {
+	sources := map[string]struct{}{}
+	mentions := 0
+	for _, item := range evidence {
+		sources[item.SourceName] = struct{}{}
+		for _, link := range item.DuplicateURLs {
+			sources[extractHost(link)] = struct{}{}
+		}
+		mentions += max(item.Mentions, 1)
+	}
+
+	confidence := 0.3
+	if len(evidence) > 0 {
+		confidence = 0.3 + 0.05*float64(len(sources)) + 0.01*float64(mentions)
+	}
+	if confidence > 0.95 {
+		confidence = 0.95
+	}
+
+	priority := "Low"
+	switch {
+	case mentions >= 30 && len(sources) >= 4:
+		priority = "High"
+	case mentions >= 10:
+		priority = "Medium"
+	}
+
+	topThemes := make([]string, 0, 3)
+	for _, theme := range themes {
+		topThemes = append(topThemes, theme.Label)
+		if len(topThemes) == 3 {
+			break
+		}
+	}
+	summary := fmt.Sprintf("Collected %d evidence snippets (%d mentions) for %s across %d sources.", len(evidence), mentions, req.Feature, len(sources))
+	if len(topThemes) > 0 {
+		summary += " Recurring themes: " + strings.Join(topThemes, ", ") + "."
+	}
+
+	brief := &agentBrief{
+		Feature:     req.Feature,
+		Category:    req.Category,
+		Persona:     req.Persona,
+		Priority:    priority,
+		Confidence:  roundTo(confidence, 2),
+		Summary:     summary,
+		Competitors: append([]string{}, req.Competitors...),
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	brief.Sections = buildBriefSections(req, evidence, themes, brief)
+	brief.Document, brief.Template = renderAgentBrief(req.Workspace, brief)
+	return brief
+}
+
+func stringFromAny(v any) string {
+	switch typed := v.(type) {
+	case string:
+		return typed
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(typed)
+	}
+}
+
+func floatFromAny(v any) float64 {
+	switch typed := v.(type) {
+	case float64:
+		return typed
+	case float32:
+		return float64(typed)
+	case int:
+		return float64(typed)
+	case int64:
+		return float64(typed)
+	case json.Number:
+		parsed, _ := typed.Float64()
+		return parsed
+	default:
+		return 0
+	}
+}
+
+func intFromAny(v any) int {
+	return int(floatFromAny(v))
+}
+
+func roundTo(v float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(v*scale) / scale
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultAgentRunTimeout   = 15 * time.Minute
+	defaultAgentStepTimeout  = 60 * time.Second
+	agentSessionCloseTimeout = 10 * time.Second
+	agentCancelWaitTimeout   = 5 * time.Second
+)
+
+var (
+	errAgentRunCancelled = errors.New("agent run cancelled")
+	errAgentRunTimedOut  = errors.New("agent run deadline exceeded")
+)
+
+type activeAgentRun struct {
+	cancel context.CancelCauseFunc
+	done   chan struct{}
+}
+
+type agentRunRegistry struct {
+	mu   sync.Mutex
+	runs map[string]*activeAgentRun
+}
+
+var agentActiveRuns = &agentRunRegistry{
+	runs: map[string]*activeAgentRun{},
+}
+
+func startAgentRun(agent *featureResearchAgent, runID string) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	active := &activeAgentRun{cancel: cancel, done: make(chan struct{})}
+
+	agentActiveRuns.mu.Lock()
+	agentActiveRuns.runs[runID] = active
+	agentActiveRuns.mu.Unlock()
+
+	go func() {
+		defer func() {
+			agentActiveRuns.mu.Lock()
+			delete(agentActiveRuns.runs, runID)
+			agentActiveRuns.mu.Unlock()
+			cancel(nil)
+			close(active.done)
+		}()
+
+		runCtx, stop := context.WithTimeoutCause(ctx, agentRunTimeout, errAgentRunTimedOut)
+		defer stop()
+		agent.run(runCtx, runID)
+	}()
+}
+
+func (r *agentRunRegistry) cancel(runID string) (<-chan struct{}, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	active, ok := r.runs[runID]
+	if !ok {
+		return nil, false
+	}
+	active.cancel(errAgentRunCancelled)
+	return active.done, true
+}
+
+func handleAgentRunCancel(w http.ResponseWriter, r *http.Request, runID string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	run, ok := agentRunStoreInstance.Get(runID)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "agent run not found"})
+		return
+	}
+	if run.terminal() {
+		writeJSON(w, http.StatusConflict, errorResponse{Error: fmt.Sprintf("agent run is already %s", run.Status)})
+		return
+	}
+
+	if done, active := agentActiveRuns.cancel(runID); active {
+		select {
+		case <-done:
+		case <-time.After(agentCancelWaitTimeout):
+		}
+	} else {
+		_ = agentRunStoreInstance.Update(runID, func(rec *agentRunRecord) {
+			rec.Status = agentRunStatusCancelled
+		})
+	}
+
+	run, _ = agentRunStoreInstance.Get(runID)
+	writeJSON(w, http.StatusOK, run.view())
+}
+
+func handleAgentRunEvents(w http.ResponseWriter, r *http.Request, runID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if _, ok := agentRunStoreInstance.Get(runID); !ok {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "agent run not found"})
+		return
+	}
+
+	stream, ok := startSSE(w)
+	if !ok {
+		return
+	}
+	lastID := parseLastEventID(r)
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		changed := agentRunStoreInstance.Watch(runID)
+		run, _ := agentRunStoreInstance.Get(runID)
+		for _, event := range runEventsAfter(run.Events, lastID) {
+			if err := stream.Event(event); err != nil {
+				return
+			}
+			lastID = event.Seq
+		}
+		if run.terminal() {
+			_ = stream.Event(runEvent{Type: runEventDone, At: run.UpdatedAt, Data: map[string]any{"status": run.Status}})
+			stream.Flush()
+			return
+		}
+		stream.Flush()
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-changed:
+		case <-heartbeat.C:
+			if err := stream.Comment("keep-alive"); err != nil {
+				return
+			}
+			stream.Flush()
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+type agentRunCheckpoint struct {
+	Step        string    `json:"step"`
+	Cursor      int       `json:"cursor"`
+	CompletedAt time.Time `json:"completedAt"`
+}
+
+type agentRunStore struct {
+	mu       sync.Mutex
+	dir      string
+	runs     map[string]*agentRunRecord
+	watchers map[string]chan struct{}
+}
+
+var agentRunStoreInstance *agentRunStore
+
+func initAgentRuns() error {
+	credibility, err := loadAgentCredibilityTable(agentCredibilityPath)
+	if err != nil {
+		return err
+	}
+	agentCredibility = credibility
+	initTinyFishCache()
+	if err := initTinyFishFixtures(); err != nil {
+		return err
+	}
+
+	store, err := newAgentRunStore(agentRunsDir)
+	if err != nil {
+		return err
+	}
+	agentRunStoreInstance = store
+	recoverAgentRuns(store, newTinyFishAdapter())
+	return nil
+}
+
+func newAgentRunStore(dir string) (*agentRunStore, error) {
+	if strings.TrimSpace(dir) == "" {
+		return nil, errors.New("agent runs dir is empty")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create agent runs dir: %w", err)
+	}
+
+	s := &agentRunStore{
+		dir:      dir,
+		runs:     map[string]*agentRunRecord{},
+		watchers: map[string]chan struct{}{},
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read agent runs dir: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		content, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("WARNING: skipping unreadable agent run %s: %v", path, err)
+			continue
+		}
+		var run agentRunRecord
+		if err := json.Unmarshal(content, &run); err != nil || strings.TrimSpace(run.ID) == "" {
+			log.Printf("WARNING: skipping corrupt agent run %s", path)
+			continue
+		}
+		s.runs[run.ID] = &run
+	}
+	return s, nil
+}
+
+func (s *agentRunStore) runPath(runID string) string {
+	return filepath.Join(s.dir, sanitizeSlug(runID)+".json")
+}
+
+func (s *agentRunStore) persistLocked(run *agentRunRecord) error {
+	blob, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal agent run: %w", err)
+	}
+	path := s.runPath(run.ID)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, blob, 0o600); err != nil {
+		return fmt.Errorf("write agent run: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("commit agent run: %w", err)
+	}
+	return nil
+}
+
+func (s *agentRunStore) Create(run agentRunRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run.emit(runEventStatus, map[string]any{"status": run.Status, "step": run.Step})
+	s.runs[run.ID] = &run
+	return s.persistLocked(&run)
+}
+
+func (s *agentRunStore) Get(runID string) (agentRunRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run, ok := s.runs[runID]
+	if !ok {
+		return agentRunRecord{}, false
+	}
+	return cloneAgentRun(run), true
+}
+
+func (s *agentRunStore) Update(runID string, mutate func(run *agentRunRecord)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run, ok := s.runs[runID]
+	if !ok {
+		return fmt.Errorf("agent run %s not found", runID)
+	}
+	before := agentRunRecord{Status: run.Status, Step: run.Step, Progress: run.Progress}
+	mutate(run)
+	run.UpdatedAt = time.Now().UTC()
+	if run.Step != before.Step {
+		run.emit(runEventStep, map[string]any{"step": run.Step, "previous": before.Step})
+	}
+	if run.Progress != before.Progress {
+		run.emit(runEventProgress, map[string]any{
+			"pagesVisited":  run.Progress.PagesVisited,
+			"evidenceCount": run.Progress.EvidenceCount,
+			"themesCount":   run.Progress.ThemesCount,
+		})
+	}
+	if run.Status != before.Status {
+		data := map[string]any{"status": run.Status, "step": run.Step}
+		if run.Error != "" {
+			data["error"] = run.Error
+		}
+		run.emit(runEventStatus, data)
+	}
+
+	err := s.persistLocked(run)
+	if watcher, ok := s.watchers[runID]; ok {
+		close(watcher)
+		delete(s.watchers, runID)
+	}
+	return err
+}
+
+// Watch returns a channel that is closed on the run's next update.
+func (s *agentRunStore) Watch(runID string) <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	watcher, ok := s.watchers[runID]
+	if !ok {
+		watcher = make(chan struct{})
+		s.watchers[runID] = watcher
+	}
+	return watcher
+}
+
+func (s *agentRunStore) InFlight() []agentRunRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs := make([]agentRunRecord, 0)
+	for _, run := range s.runs {
+		if run.Status == agentRunStatusQueued || run.Status == agentRunStatusRunning {
+			runs = append(runs, cloneAgentRun(run))
+		}
+	}
+	slices.SortFunc(runs, func(a, b agentRunRecord) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return runs
+}
+
+func (run *agentRunRecord) checkpoint(step string, cursor int) {
+	now := time.Now().UTC()
+	for idx := range run.Checkpoints {
+		if run.Checkpoints[idx].Step == step {
+			run.Checkpoints[idx].Cursor = cursor
+			run.Checkpoints[idx].CompletedAt = now
+			return
+		}
+	}
+	run.Checkpoints = append(run.Checkpoints, agentRunCheckpoint{
+		Step:        step,
+		Cursor:      cursor,
+		CompletedAt: now,
+	})
+}
+
+func (run *agentRunRecord) emit(kind string, data map[string]any) {
+	run.Events = appendRunEvent(run.Events, &run.EventSeq, kind, data)
+}
+
+func cloneAgentRun(run *agentRunRecord) agentRunRecord {
+	out := *run
+	out.Request.Competitors = slices.Clone(run.Request.Competitors)
+	out.Artifacts.Themes = slices.Clone(run.Artifacts.Themes)
+	out.Artifacts.Evidence = slices.Clone(run.Artifacts.Evidence)
+	out.Artifacts.URLs = slices.Clone(run.Artifacts.URLs)
+	out.Sessions = slices.Clone(run.Sessions)
+	out.SearchResults = slices.Clone(run.SearchResults)
+	out.Checkpoints = slices.Clone(run.Checkpoints)
+	out.Events = slices.Clone(run.Events)
+	return out
+}
+
+func recoverAgentRuns(store *agentRunStore, adapter TinyFishAdapter) {
+	for _, run := range store.InFlight() {
+		if reason := lostAgentSessions(adapter, run.Sessions); reason != "" {
+			log.Printf("agent run %s: %s", run.ID, reason)
+			_ = store.Update(run.ID, func(rec *agentRunRecord) {
+				rec.Status = agentRunStatusFailed
+				rec.Error = reason
+			})
+			newAgentSessionPool(adapter, run.ID, len(run.Sessions), run.Sessions, nil).Close()
+			continue
+		}
+
+		if err := store.Update(run.ID, func(rec *agentRunRecord) {
+			rec.ResumeCount++
+		}); err != nil {
+			log.Printf("agent run %s: persist failed: %v", run.ID, err)
+			continue
+		}
+		log.Printf("INFO: resuming agent run %s from step %s", run.ID, run.Step)
+		startAgentRun(&featureResearchAgent{adapter: adapter, store: store, cache: agentTinyFishCache}, run.ID)
+	}
+}
+
+func lostAgentSessions(adapter TinyFishAdapter, sessions []string) string {
+	for _, sessionID := range sessions {
+		checkCtx, cancel := context.WithTimeout(context.Background(), agentStepTimeout)
+		alive, err := adapter.SessionAlive(checkCtx, sessionID)
+		cancel()
+		if err != nil {
+			return fmt.Sprintf("tinyfish session %s was lost while the server restarted: %v", sessionID, err)
+		}
+		if !alive {
+			return fmt.Sprintf("tinyfish session %s was lost while the server restarted", sessionID)
+		}
+	}
+	return ""
+}
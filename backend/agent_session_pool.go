@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultAgentMaxSessions         = 3
+	defaultAgentMaxConcurrentVisits = 4
+	defaultAgentMaxVisitsPerDomain  = 2
+)
+
+type agentConcurrencyView struct {
+	MaxSessions         int `json:"max_sessions"`
+	MaxConcurrentVisits int `json:"max_concurrent_visits"`
+	MaxVisitsPerDomain  int `json:"max_visits_per_domain"`
+}
+
+type agentSessionPool struct {
+	adapter  TinyFishAdapter
+	runID    string
+	onChange func([]string)
+
+	mu       sync.Mutex
+	sessions []string
+	created  int
+	idle     chan string
+	slots    chan struct{}
+}
+
+func newAgentSessionPool(adapter TinyFishAdapter, runID string, limit int, existing []string, onChange func([]string)) *agentSessionPool {
+	if limit <= 0 {
+		limit = defaultAgentMaxSessions
+	}
+	if len(existing) > limit {
+		limit = len(existing)
+	}
+	pool := &agentSessionPool{
+		adapter:  adapter,
+		runID:    runID,
+		onChange: onChange,
+		idle:     make(chan string, limit),
+		slots:    make(chan struct{}, limit),
+	}
+	for _, sessionID := range existing {
+		pool.slots <- struct{}{}
+		pool.idle <- sessionID
+		pool.sessions = append(pool.sessions, sessionID)
+	}
+	pool.created = len(pool.sessions)
+	return pool
+}
+
+func (p *agentSessionPool) Acquire(ctx context.Context) (string, error) {
+	select {
+	case sessionID := <-p.idle:
+		return sessionID, nil
+	default:
+	}
+
+	select {
+	case sessionID := <-p.idle:
+		return sessionID, nil
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+
+	sessionID, err := p.create(ctx)
+	if err == nil {
+		return sessionID, nil
+	}
+	<-p.slots
+	if ctx.Err() != nil || p.Size() == 0 {
+		return "", err
+	}
+
+	log.Printf("agent run %s: extra tinyfish session unavailable, sharing existing sessions: %v", p.runID, err)
+	select {
+	case sessionID := <-p.idle:
+		return sessionID, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func (p *agentSessionPool) create(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	p.created++
+	label := fmt.Sprintf("%s-s%d", p.runID, p.created)
+	p.mu.Unlock()
+
+	stepCtx, cancel := context.WithTimeout(ctx, agentStepTimeout)
+	defer cancel()
+	sessionID, err := p.adapter.CreateSession(stepCtx, label)
+	if err != nil {
+		return "", fmt.Errorf("create tinyfish session: %w", err)
+	}
+
+	p.mu.Lock()
+	p.sessions = append(p.sessions, sessionID)
+	snapshot := slices.Clone(p.sessions)
+	p.mu.Unlock()
+	if p.onChange != nil {
+		p.onChange(snapshot)
+	}
+	return sessionID, nil
+}
+
+func (p *agentSessionPool) Release(sessionID string) {
+	p.idle <- sessionID
+}
+
+func (p *agentSessionPool) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.sessions)
+}
+
+func (p *agentSessionPool) Close() {
+	p.mu.Lock()
+	sessions := slices.Clone(p.sessions)
+	p.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, sessionID := range sessions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			closeCtx, cancel := context.WithTimeout(context.Background(), agentSessionCloseTimeout)
+			defer cancel()
+			if err := p.adapter.CloseSession(closeCtx, sessionID); err != nil {
+				log.Printf("agent run %s: close tinyfish session %s failed: %v", p.runID, sessionID, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+type agentVisitLimiter struct {
+	global    chan struct{}
+	perDomain int
+
+	mu      sync.Mutex
+	domains map[string]chan struct{}
+}
+
+func newAgentVisitLimiter(maxConcurrent int, maxPerDomain int) *agentVisitLimiter {
+	if maxConcurrent <= 0 {
+		maxConcurrent = defaultAgentMaxConcurrentVisits
+	}
+	if maxPerDomain <= 0 {
+		maxPerDomain = defaultAgentMaxVisitsPerDomain
+	}
+	return &agentVisitLimiter{
+		global:    make(chan struct{}, maxConcurrent),
+		perDomain: maxPerDomain,
+		domains:   map[string]chan struct{}{},
+	}
+}
+
+func (l *agentVisitLimiter) domain(host string) chan struct{} {
+	key := strings.ToLower(strings.TrimSpace(host))
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	slot, ok := l.domains[key]
+	if !ok {
+		slot = make(chan struct{}, l.perDomain)
+		l.domains[key] = slot
+	}
+	return slot
+}
+
+// Acquire takes the per-domain slot before the global one so a visit waiting
+// on a busy domain never holds a global slot another domain could use.
+func (l *agentVisitLimiter) Acquire(ctx context.Context, host string) (func(), error) {
+	domain := l.domain(host)
+	select {
+	case domain <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	select {
+	case l.global <- struct{}{}:
+	case <-ctx.Done():
+		<-domain
+		return nil, ctx.Err()
+	}
+	return func() {
+		<-l.global
+		<-domain
+	}, nil
+}
+
+func agentConcurrency() agentConcurrencyView {
+	return agentConcurrencyView{
+		MaxSessions:         agentMaxSessions,
+		MaxConcurrentVisits: agentMaxConcurrentVisits,
+		MaxVisitsPerDomain:  agentMaxVisitsPerDomain,
+	}
+}
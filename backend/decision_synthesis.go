@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+const (
+	decisionObjectSchemaName = "decision_object"
+	maxDecisionThemes        = 5
+	maxDecisionQuotes        = 3
+	maxDecisionChannels      = 3
+	maxDecisionRepairs       = 1
+)
+
+var errInvalidDecisionObject = errors.New("invalid decision object")
+
+type decisionChannel struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type decisionTheme struct {
+	Label string `json:"label"`
+	Count int    `json:"count"`
+}
+
+type decisionQuote struct {
+	Text   string `json:"text"`
+	Source string `json:"source"`
+	URL    string `json:"url"`
+}
+
+type decisionCompetitor struct {
+	Name     string `json:"name"`
+	Evidence string `json:"evidence"`
+	URL      string `json:"url"`
+}
+
+type decisionSynthesisInput struct {
+	Feature       string
+	TotalMentions int
+	Channels      []decisionChannel
+	Themes        []decisionTheme
+	Quotes        []decisionQuote
+	Competitors   []decisionCompetitor
+}
+
+// decisionObjectSchema closes every object and lists all of its properties as
+// required, as strict structured outputs demand. Its length, range and item
+// count limits are enforced locally; strictLLMSchema drops them for OpenAI.
+var decisionObjectSchema = map[string]any{
+	"type":                 "object",
+	"additionalProperties": false,
+	"required":             []any{"feature", "signals", "competitors", "assumptions", "recommendation"},
+	"properties": map[string]any{
+		"feature": map[string]any{"type": "string", "minLength": 1},
+		"signals": map[string]any{
+			"type":                 "object",
+			"additionalProperties": false,
+			"required":             []any{"total_mentions", "top_channels", "themes", "sample_quotes"},
+			"properties": map[string]any{
+				"total_mentions": map[string]any{"type": "integer", "minimum": 0},
+				"top_channels":   schemaArray(schemaObject("name", "string", "count", "integer")),
+				"themes":         schemaArray(schemaObject("label", "string", "count", "integer")),
+				"sample_quotes":  schemaArray(schemaObject("text", "string", "source", "string", "url", "string")),
+			},
+		},
+		"competitors": schemaArray(schemaObject("name", "string", "evidence", "string", "url", "string")),
+		"assumptions": map[string]any{
+			"type":     "array",
+			"minItems": 1,
+			"items": map[string]any{
+				"type":                 "object",
+				"additionalProperties": false,
+				"required":             []any{"statement", "risk", "validation", "metric"},
+				"properties": map[string]any{
+					"statement":  map[string]any{"type": "string", "minLength": 1},
+					"risk":       map[string]any{"type": "string", "enum": []any{"low", "medium", "high"}},
+					"validation": map[string]any{"type": "string"},
+					"metric":     map[string]any{"type": "string"},
+				},
+			},
+		},
+		"recommendation": map[string]any{
+			"type":                 "object",
+			"additionalProperties": false,
+			"required":             []any{"priority", "confidence", "next_steps"},
+			"properties": map[string]any{
+				"priority":   map[string]any{"type": "string", "enum": []any{"High", "Medium", "Low"}},
+				"confidence": map[string]any{"type": "number", "minimum": 0, "maximum": 1},
+				"next_steps": map[string]any{"type": "array", "minItems": 1, "items": map[string]any{"type": "string"}},
+			},
+		},
+	},
+}
+
+func schemaArray(items map[string]any) map[string]any {
+	return map[string]any{"type": "array", "items": items}
+}
+
+// schemaObject builds a closed object schema from name/type pairs.
+func schemaObject(fields ...string) map[string]any {
+	properties := map[string]any{}
+	required := make([]any, 0, len(fields)/2)
+	for idx := 0; idx+1 < len(fields); idx += 2 {
+		properties[fields[idx]] = map[string]any{"type": fields[idx+1]}
+		required = append(required, fields[idx])
+	}
+	return map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"required":             required,
+		"properties":           properties,
+	}
+}
+
+var llmPromptTemplates = template.Must(template.New("prompts").Parse(`
+{{define "decision_synthesis.system"}}You are a product decision analyst. Turn customer signals and competitor evidence into a decision object.
+Use only the evidence provided. Quote customers verbatim and copy URLs exactly; never invent sources.
+Assumptions must be testable: pair each with a validation method and a success metric.
+Respond with a single JSON object matching the decision_object schema and nothing else.{{end}}
+
+{{define "decision_synthesis.user"}}Feature under evaluation: {{.Feature}}
+
+Customer signals: {{.TotalMentions}} mentions.
+{{- if .Channels}}
+Top channels:
+{{- range .Channels}}
+- {{.Name}}: {{.Count}}
+{{- end}}
+{{- end}}
+{{- if .Themes}}
+Themes:
+{{- range .Themes}}
+- {{.Label}} ({{.Count}})
+{{- end}}
+{{- end}}
+{{- if .Quotes}}
+Quotes:
+{{- range .Quotes}}
+- "{{.Text}}" ({{.Source}}, {{.URL}})
+{{- end}}
+{{- end}}
+{{- if .Competitors}}
+Competitor evidence:
+{{- range .Competitors}}
+- {{.Name}}: {{.Evidence}} ({{.URL}})
+{{- end}}
+{{- else}}
+No competitor evidence was found.
+{{- end}}
+
+Recommend a priority (High, Medium or Low) with a confidence between 0 and 1 and concrete next steps.{{end}}
+`))
+
+func renderLLMPrompt(name string, data any) (string, error) {
+	var out bytes.Buffer
+	if err := llmPromptTemplates.ExecuteTemplate(&out, name, data); err != nil {
+		return "", fmt.Errorf("render prompt %s: %w", name, err)
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// synthesizeDecision asks the provider for a decision object and validates it
+// against decisionObjectSchema, giving the model one chance to repair
+// invalid output. Usage is returned even when synthesis fails.
+func synthesizeDecision(ctx context.Context, provider LLMProvider, input decisionSynthesisInput) (map[string]any, llmUsage, error) {
+	usage := llmUsage{Provider: provider.Name(), Model: provider.Model()}
+	system, err := renderLLMPrompt("decision_synthesis.system", input)
+	if err != nil {
+		return nil, usage, err
+	}
+	prompt, err := renderLLMPrompt("decision_synthesis.user", input)
+	if err != nil {
+		return nil, usage, err
+	}
+
+	req := llmRequest{
+		System:     system,
+		Prompt:     prompt,
+		SchemaName: decisionObjectSchemaName,
+		Schema:     decisionObjectSchema,
+		MaxTokens:  defaultLLMMaxTokens,
+		Reference:  referenceDecision(input),
+	}
+	for attempt := 0; ; attempt++ {
+		resp, err := provider.Complete(ctx, req)
+		if err != nil {
+			return nil, usage, fmt.Errorf("%s completion failed: %w", provider.Name(), err)
+		}
+		usage.add(provider, resp)
+
+		decision, err := decodeSchemaJSON(resp.Text, decisionObjectSchema)
+		if err == nil {
+			err = validateOperatorArtifact(artifactTypeDecisionObject, decision)
+		}
+		if err == nil {
+			return decision, usage, nil
+		}
+		if attempt >= maxDecisionRepairs {
+			return nil, usage, fmt.Errorf("%s returned an %w: %v", provider.Name(), errInvalidDecisionObject, err)
+		}
+		req.Prompt = prompt + "\n\nYour previous answer was rejected: " + err.Error() + ". Return the corrected JSON object only."
+	}
+}
+
+// referenceDecision is a rule-based decision object built from the same
+// input; the fake provider returns it as its answer.
+func referenceDecision(input decisionSynthesisInput) map[string]any {
+	channels := append([]decisionChannel{}, input.Channels...)
+	sort.SliceStable(channels, func(i, j int) bool { return channels[i].Count > channels[j].Count })
+	channels = channels[:min(len(channels), maxDecisionChannels)]
+	themes := append([]decisionTheme{}, input.Themes[:min(len(input.Themes), maxDecisionThemes)]...)
+	quotes := append([]decisionQuote{}, input.Quotes[:min(len(input.Quotes), maxDecisionQuotes)]...)
+	competitors := append([]decisionCompetitor{}, input.Competitors...)
+
+	featureLower := strings.ToLower(input.Feature)
+	assumptions := []map[string]any{{
+		"statement":  fmt.Sprintf("Demand for %s reflects a recurring workflow gap, not a one-off request", featureLower),
+		"risk":       "medium",
+		"validation": "Interview the accounts behind the loudest threads",
+		"metric":     "5 of 8 interviews confirm the gap",
+	}}
+	for _, theme := range themes[:min(len(themes), 3)] {
+		assumptions = append(assumptions, map[string]any{
+			"statement":  fmt.Sprintf("Addressing %q drives adoption of %s", theme.Label, featureLower),
+			"risk":       "medium",
+			"validation": "Prototype usability test focused on " + theme.Label,
+			"metric":     "task success rate +15%",
+		})
+	}
+	if len(input.Competitors) > 0 {
+		assumptions = append(assumptions, map[string]any{
+			"statement":  "Competitor coverage creates urgency for parity",
+			"risk":       "low",
+			"validation": "Win/loss notes",
+			"metric":     "win rate +3%",
+		})
+	}
+
+	priority := "Low"
+	switch {
+	case input.TotalMentions >= 30 && len(input.Competitors) > 0:
+		priority = "High"
+	case input.TotalMentions >= 10:
+		priority = "Medium"
+	}
+	confidence := 0.4 + 0.005*float64(input.TotalMentions) + 0.05*float64(len(input.Competitors)) + 0.02*float64(len(themes))
+	nextSteps := []string{"Create Jira epic", "Run prototype usability test"}
+	if priority == "Low" {
+		nextSteps = []string{"Keep collecting signals", "Revisit at next planning cycle"}
+	}
+
+	return map[string]any{
+		"feature": input.Feature,
+		"signals": map[string]any{
+			"total_mentions": input.TotalMentions,
+			"top_channels":   channels,
+			"themes":         themes,
+			"sample_quotes":  quotes,
+		},
+		"competitors": competitors,
+		"assumptions": assumptions,
+		"recommendation": map[string]any{
+			"priority":   priority,
+			"confidence": roundTo(min(confidence, 0.95), 2),
+			"next_steps": nextSteps,
+		},
+	}
+}
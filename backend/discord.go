@@ -0,0 +1,939 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	providerDiscord                = "discord"
+	defaultDiscordAPIURL           = "https://discord.com/api/v10"
+	discordOAuthScopes             = "bot applications.commands"
+	discordBotPermissions          = "66560" // View Channels and Read Message History
+	discordRequestTimeout          = 20 * time.Second
+	discordPageSize                = 100
+	maxDiscordImportPages          = 10
+	maxDiscordRateLimitWait        = 5 * time.Second
+	maxDiscordInteractionSkew      = 5 * time.Minute
+	discordFeedbackCommand         = "feedback"
+	discordMessageCommand          = "Send to Sentient"
+	defaultDiscordConnectionDetail = "Import community feedback from your server"
+)
+
+// Discord API enums used here.
+const (
+	discordChannelText         = 0
+	discordChannelAnnouncement = 5
+
+	discordInteractionPing    = 1
+	discordInteractionCommand = 2
+
+	discordCommandChatInput = 1
+	discordCommandMessage   = 3
+
+	discordResponsePong    = 1
+	discordResponseMessage = 4
+	discordFlagEphemeral   = 64
+)
+
+type discordSetupPersisted struct {
+	ApplicationID         string    `json:"applicationId"`
+	PublicKey             string    `json:"publicKey"`
+	RedirectURL           string    `json:"redirectUrl"`
+	AppUIBaseURL          string    `json:"appUIBaseURL"`
+	EncryptedClientSecret string    `json:"encryptedClientSecret,omitempty"`
+	EncryptedBotToken     string    `json:"encryptedBotToken,omitempty"`
+	UpdatedAt             time.Time `json:"updatedAt"`
+}
+
+type discordSetupUpsertRequest struct {
+	ApplicationID string `json:"applicationId"`
+	ClientSecret  string `json:"clientSecret"`
+	BotToken      string `json:"botToken"`
+	PublicKey     string `json:"publicKey"`
+	RedirectURL   string `json:"redirectUrl"`
+	AppUIBaseURL  string `json:"appUIBaseURL"`
+}
+
+type discordSetupConfigView struct {
+	ApplicationID        string `json:"applicationId"`
+	PublicKey            string `json:"publicKey"`
+	RedirectURL          string `json:"redirectUrl"`
+	AppUIBaseURL         string `json:"appUIBaseURL"`
+	HasClientSecret      bool   `json:"hasClientSecret"`
+	HasBotToken          bool   `json:"hasBotToken"`
+	UpdatedAt            string `json:"updatedAt,omitempty"`
+	SuggestedInteraction string `json:"suggestedInteractionsUrl,omitempty"`
+	SuggestedRedirect    string `json:"suggestedRedirectUrl,omitempty"`
+}
+
+type discordSetupStatusView struct {
+	ReadyForConnect bool     `json:"readyForConnect"`
+	MissingFields   []string `json:"missingFields"`
+	Connected       bool     `json:"connected"`
+	Guild           string   `json:"guild,omitempty"`
+}
+
+type discordSetupResponse struct {
+	Config discordSetupConfigView `json:"config"`
+	Status discordSetupStatusView `json:"status"`
+}
+
+type discordRuntimeConfig struct {
+	ApplicationID string
+	ClientSecret  string
+	BotToken      string
+	PublicKey     string
+	RedirectURL   string
+	AppUIBaseURL  string
+}
+
+// discordConnectionRecord is the guild the bot was installed in. The bot token
+// itself belongs to the application and lives in the setup.
+type discordConnectionRecord struct {
+	GuildID     string    `json:"guildId"`
+	GuildName   string    `json:"guildName"`
+	Permissions string    `json:"permissions,omitempty"`
+	ConnectedAt time.Time `json:"connectedAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+type discordGuild struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type discordChannel struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Type    int    `json:"type"`
+	GuildID string `json:"guild_id"`
+}
+
+type discordUser struct {
+	ID         string `json:"id"`
+	Username   string `json:"username"`
+	GlobalName string `json:"global_name"`
+	Bot        bool   `json:"bot"`
+}
+
+type discordMessage struct {
+	ID        string      `json:"id"`
+	ChannelID string      `json:"channel_id"`
+	Content   string      `json:"content"`
+	Timestamp time.Time   `json:"timestamp"`
+	Author    discordUser `json:"author"`
+}
+
+type discordTokenResponse struct {
+	AccessToken string        `json:"access_token"`
+	Scope       string        `json:"scope"`
+	Guild       *discordGuild `json:"guild"`
+}
+
+type discordInteraction struct {
+	ID        string          `json:"id"`
+	Type      int             `json:"type"`
+	GuildID   string          `json:"guild_id"`
+	ChannelID string          `json:"channel_id"`
+	Channel   *discordChannel `json:"channel"`
+	Member    *struct {
+		User discordUser `json:"user"`
+	} `json:"member"`
+	User *discordUser `json:"user"`
+	Data struct {
+		Name     string `json:"name"`
+		Type     int    `json:"type"`
+		TargetID string `json:"target_id"`
+		Options  []struct {
+			Name  string `json:"name"`
+			Value any    `json:"value"`
+		} `json:"options"`
+		Resolved struct {
+			Messages map[string]discordMessage `json:"messages"`
+		} `json:"resolved"`
+	} `json:"data"`
+}
+
+type discordClient struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+type discordStatusError struct {
+	Status  int
+	Message string
+}
+
+func (e *discordStatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("discord returned status %d", e.Status)
+	}
+	return fmt.Sprintf("discord returned status %d: %s", e.Status, e.Message)
+}
+
+func newDiscordClient(botToken string) (*discordClient, error) {
+	botToken = strings.TrimSpace(botToken)
+	if botToken == "" {
+		return nil, errors.New("discord bot token is not configured")
+	}
+	return &discordClient{
+		baseURL: strings.TrimRight(discordAPIURL, "/"),
+		token:   botToken,
+		client:  &http.Client{Timeout: discordRequestTimeout},
+	}, nil
+}
+
+func getDiscordClient() (*discordClient, discordConnectionRecord, error) {
+	conn, connected := getIntegrationConnection[discordConnectionRecord](integrationStoreInstance, providerDiscord)
+	if !connected {
+		return nil, conn, errors.New("discord is not connected")
+	}
+	if integrationTokenCipher == nil {
+		return nil, conn, errors.New("integration encryption is not configured")
+	}
+	cfg, err := currentDiscordRuntimeConfig()
+	if err != nil {
+		return nil, conn, err
+	}
+	client, err := newDiscordClient(cfg.BotToken)
+	return client, conn, err
+}
+
+// do sends one request as the bot. A 429 with a short retry_after is retried
+// once; longer limits are returned as errors.
+func (c *discordClient) do(ctx context.Context, method, path string, payload any, out any) error {
+	var encoded []byte
+	if payload != nil {
+		var err error
+		if encoded, err = json.Marshal(payload); err != nil {
+			return err
+		}
+	}
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(encoded))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bot "+c.token)
+		req.Header.Set("User-Agent", "DiscordBot (https://github.com/jatinS-dev/Sentient, 1.0)")
+		if payload != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("discord %s: %w", method, err)
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("discord %s: %w", method, err)
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			var parsed struct {
+				Message    string  `json:"message"`
+				RetryAfter float64 `json:"retry_after"`
+			}
+			_ = json.Unmarshal(body, &parsed)
+			wait := time.Duration(parsed.RetryAfter * float64(time.Second))
+			if resp.StatusCode == http.StatusTooManyRequests && attempt == 0 && wait <= maxDiscordRateLimitWait {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(wait):
+				}
+				continue
+			}
+			return &discordStatusError{Status: resp.StatusCode, Message: parsed.Message}
+		}
+		if out == nil || len(body) == 0 {
+			return nil
+		}
+		if err := json.Unmarshal(body, out); err != nil {
+			return fmt.Errorf("decode discord response: %w", err)
+		}
+		return nil
+	}
+}
+
+func (c *discordClient) Guild(ctx context.Context, guildID string) (discordGuild, error) {
+	var guild discordGuild
+	err := c.do(ctx, http.MethodGet, "/guilds/"+url.PathEscape(guildID), nil, &guild)
+	return guild, err
+}
+
+// GuildChannels returns the guild's text and announcement channels.
+func (c *discordClient) GuildChannels(ctx context.Context, guildID string) ([]integrationChannel, error) {
+	var raw []discordChannel
+	if err := c.do(ctx, http.MethodGet, "/guilds/"+url.PathEscape(guildID)+"/channels", nil, &raw); err != nil {
+		return nil, err
+	}
+	channels := make([]integrationChannel, 0, len(raw))
+	for _, ch := range raw {
+		var kind string
+		switch ch.Type {
+		case discordChannelText:
+			kind = "text"
+		case discordChannelAnnouncement:
+			kind = "announcement"
+		default:
+			continue
+		}
+		channels = append(channels, integrationChannel{ID: ch.ID, Name: ch.Name, Type: kind})
+	}
+	slices.SortFunc(channels, func(a, b integrationChannel) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	return channels, nil
+}
+
+// Messages pages backwards from the newest message, up to
+// maxDiscordImportPages pages.
+func (c *discordClient) Messages(ctx context.Context, channelID string) ([]discordMessage, error) {
+	var messages []discordMessage
+	before := ""
+	for page := 0; page < maxDiscordImportPages; page++ {
+		query := url.Values{}
+		query.Set("limit", strconv.Itoa(discordPageSize))
+		if before != "" {
+			query.Set("before", before)
+		}
+		var batch []discordMessage
+		if err := c.do(ctx, http.MethodGet, "/channels/"+url.PathEscape(channelID)+"/messages?"+query.Encode(), nil, &batch); err != nil {
+			return messages, err
+		}
+		messages = append(messages, batch...)
+		if len(batch) < discordPageSize {
+			break
+		}
+		before = batch[len(batch)-1].ID
+	}
+	return messages, nil
+}
+
+// RegisterCommands replaces the application's global commands with the
+// /feedback slash command and the "Send to Sentient" message command.
+func (c *discordClient) RegisterCommands(ctx context.Context, applicationID string) error {
+	commands := []map[string]any{
+		{
+			"name":        discordFeedbackCommand,
+			"type":        discordCommandChatInput,
+			"description": "Send product feedback to Sentient",
+			"options": []map[string]any{{
+				"name":        "text",
+				"description": "What should the product team know?",
+				"type":        3,
+				"required":    true,
+			}},
+		},
+		{"name": discordMessageCommand, "type": discordCommandMessage},
+	}
+	return c.do(ctx, http.MethodPut, "/applications/"+url.PathEscape(applicationID)+"/commands", commands, nil)
+}
+
+func (c *discordClient) LeaveGuild(ctx context.Context, guildID string) error {
+	return c.do(ctx, http.MethodDelete, "/users/@me/guilds/"+url.PathEscape(guildID), nil, nil)
+}
+
+func exchangeDiscordOAuthCode(ctx context.Context, code string, cfg discordRuntimeConfig) (discordTokenResponse, error) {
+	form := url.Values{}
+	form.Set("client_id", cfg.ApplicationID)
+	form.Set("client_secret", cfg.ClientSecret)
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", cfg.RedirectURL)
+
+	var parsed discordTokenResponse
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(discordAPIURL, "/")+"/oauth2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return parsed, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := &http.Client{Timeout: discordRequestTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return parsed, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return parsed, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var failure struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		_ = json.Unmarshal(body, &failure)
+		return parsed, &discordStatusError{Status: resp.StatusCode, Message: firstNonEmpty(failure.Description, failure.Error)}
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return parsed, fmt.Errorf("decode discord token response: %w", err)
+	}
+	return parsed, nil
+}
+
+func currentDiscordRuntimeConfig() (discordRuntimeConfig, error) {
+	setup, ok := getIntegrationSetup[discordSetupPersisted](integrationStoreInstance, providerDiscord)
+	if !ok {
+		return discordRuntimeConfig{}, nil
+	}
+	cfg := discordRuntimeConfig{
+		ApplicationID: strings.TrimSpace(setup.ApplicationID),
+		PublicKey:     strings.TrimSpace(setup.PublicKey),
+		RedirectURL:   strings.TrimSpace(setup.RedirectURL),
+		AppUIBaseURL:  strings.TrimSpace(setup.AppUIBaseURL),
+	}
+	if integrationTokenCipher != nil {
+		if setup.EncryptedClientSecret != "" {
+			decrypted, err := integrationTokenCipher.Decrypt(setup.EncryptedClientSecret)
+			if err != nil {
+				return discordRuntimeConfig{}, fmt.Errorf("decrypt discord client secret: %w", err)
+			}
+			cfg.ClientSecret = strings.TrimSpace(decrypted)
+		}
+		if setup.EncryptedBotToken != "" {
+			decrypted, err := integrationTokenCipher.Decrypt(setup.EncryptedBotToken)
+			if err != nil {
+				return discordRuntimeConfig{}, fmt.Errorf("decrypt discord bot token: %w", err)
+			}
+			cfg.BotToken = strings.TrimSpace(decrypted)
+		}
+	}
+	return cfg, nil
+}
+
+func discordMissingFields(cfg discordRuntimeConfig) []string {
+	missing := make([]string, 0, 4)
+	if cfg.ApplicationID == "" {
+		missing = append(missing, "applicationId")
+	}
+	if cfg.ClientSecret == "" {
+		missing = append(missing, "clientSecret")
+	}
+	if cfg.BotToken == "" {
+		missing = append(missing, "botToken")
+	}
+	if cfg.PublicKey == "" {
+		missing = append(missing, "publicKey")
+	}
+	return missing
+}
+
+func parseDiscordPublicKey(raw string) (ed25519.PublicKey, error) {
+	key, err := hex.DecodeString(strings.TrimSpace(raw))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("publicKey must be the 64-character hex key from the Discord developer portal")
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+func inferredDiscordRedirectURL(r *http.Request) string {
+	return inferRequestBaseURL(r) + "/api/integrations/discord/callback"
+}
+
+func inferredDiscordInteractionsURL(r *http.Request) string {
+	return inferRequestBaseURL(r) + "/api/integrations/discord/webhook"
+}
+
+func buildDiscordSetupResponse(r *http.Request) (discordSetupResponse, error) {
+	cfg, err := currentDiscordRuntimeConfig()
+	if err != nil {
+		return discordSetupResponse{}, err
+	}
+	if cfg.RedirectURL == "" {
+		cfg.RedirectURL = inferredDiscordRedirectURL(r)
+	}
+	if cfg.AppUIBaseURL == "" {
+		cfg.AppUIBaseURL = inferredIntegrationHubURL(r)
+	}
+	missing := discordMissingFields(cfg)
+
+	resp := discordSetupResponse{
+		Config: discordSetupConfigView{
+			ApplicationID:        cfg.ApplicationID,
+			PublicKey:            cfg.PublicKey,
+			RedirectURL:          cfg.RedirectURL,
+			AppUIBaseURL:         cfg.AppUIBaseURL,
+			HasClientSecret:      cfg.ClientSecret != "",
+			HasBotToken:          cfg.BotToken != "",
+			SuggestedInteraction: inferredDiscordInteractionsURL(r),
+			SuggestedRedirect:    cfg.RedirectURL,
+		},
+		Status: discordSetupStatusView{
+			ReadyForConnect: len(missing) == 0,
+			MissingFields:   missing,
+		},
+	}
+	if setup, ok := getIntegrationSetup[discordSetupPersisted](integrationStoreInstance, providerDiscord); ok {
+		resp.Config.UpdatedAt = setup.UpdatedAt.Format(time.RFC3339)
+	}
+	if conn, ok := getIntegrationConnection[discordConnectionRecord](integrationStoreInstance, providerDiscord); ok {
+		resp.Status.Connected = true
+		resp.Status.Guild = conn.GuildName
+	}
+	return resp, nil
+}
+
+var discordSetupWizard = integrationSetupWizard[discordSetupUpsertRequest, discordSetupResponse]{
+	label:        "Discord",
+	readyMessage: "Discord setup is ready. You can add the bot to a server from Integrations.",
+	view:         buildDiscordSetupResponse,
+	save:         saveDiscordSetup,
+	missing: func() ([]string, error) {
+		cfg, err := currentDiscordRuntimeConfig()
+		return discordMissingFields(cfg), err
+	},
+}
+
+func saveDiscordSetup(w http.ResponseWriter, r *http.Request, req discordSetupUpsertRequest) bool {
+	req.ApplicationID = strings.TrimSpace(req.ApplicationID)
+	req.ClientSecret = strings.TrimSpace(req.ClientSecret)
+	req.BotToken = strings.TrimSpace(req.BotToken)
+	req.PublicKey = strings.ToLower(strings.TrimSpace(req.PublicKey))
+	req.RedirectURL = strings.TrimSpace(req.RedirectURL)
+	req.AppUIBaseURL = strings.TrimSpace(req.AppUIBaseURL)
+
+	existing, _ := getIntegrationSetup[discordSetupPersisted](integrationStoreInstance, providerDiscord)
+	if req.RedirectURL == "" {
+		req.RedirectURL = firstNonEmpty(existing.RedirectURL, inferredDiscordRedirectURL(r))
+	}
+	if req.AppUIBaseURL == "" {
+		req.AppUIBaseURL = firstNonEmpty(existing.AppUIBaseURL, inferredIntegrationHubURL(r))
+	}
+	if _, err := url.ParseRequestURI(req.RedirectURL); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "redirectUrl must be a valid absolute URL"})
+		return false
+	}
+	if _, err := url.ParseRequestURI(req.AppUIBaseURL); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "appUIBaseURL must be a valid absolute URL"})
+		return false
+	}
+	if req.PublicKey != "" {
+		if _, err := parseDiscordPublicKey(req.PublicKey); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+			return false
+		}
+	}
+
+	setup := discordSetupPersisted{
+		ApplicationID:         req.ApplicationID,
+		PublicKey:             req.PublicKey,
+		RedirectURL:           req.RedirectURL,
+		AppUIBaseURL:          req.AppUIBaseURL,
+		EncryptedClientSecret: existing.EncryptedClientSecret,
+		EncryptedBotToken:     existing.EncryptedBotToken,
+		UpdatedAt:             time.Now().UTC(),
+	}
+	if req.ClientSecret != "" {
+		enc, err := integrationTokenCipher.Encrypt(req.ClientSecret)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to encrypt client secret"})
+			return false
+		}
+		setup.EncryptedClientSecret = enc
+	}
+	if req.BotToken != "" {
+		enc, err := integrationTokenCipher.Encrypt(req.BotToken)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to encrypt bot token"})
+			return false
+		}
+		setup.EncryptedBotToken = enc
+	}
+
+	if err := saveIntegrationSetup(integrationStoreInstance, providerDiscord, setup); err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to store discord setup"})
+		return false
+	}
+	return true
+}
+
+func discordAuthorizeRequest(r *http.Request) (integrationAuthorizeRequest, error) {
+	cfg, err := currentDiscordRuntimeConfig()
+	if err != nil {
+		return integrationAuthorizeRequest{}, err
+	}
+	params := url.Values{}
+	params.Set("client_id", cfg.ApplicationID)
+	params.Set("scope", discordOAuthScopes)
+	params.Set("permissions", discordBotPermissions)
+	params.Set("response_type", "code")
+	params.Set("redirect_uri", firstNonEmpty(cfg.RedirectURL, inferredDiscordRedirectURL(r)))
+	return integrationAuthorizeRequest{
+		URL:     strings.TrimRight(discordAPIURL, "/") + "/oauth2/authorize",
+		Params:  params,
+		Missing: discordMissingFields(cfg),
+	}, nil
+}
+
+// handleDiscordCallback finishes the bot install. The code exchange proves the
+// install happened and names the guild; the bot token then has to be able to
+// read that guild before it is stored.
+func handleDiscordCallback(w http.ResponseWriter, r *http.Request) {
+	cfg, cfgErr := currentDiscordRuntimeConfig()
+	if cfg.RedirectURL == "" {
+		cfg.RedirectURL = inferredDiscordRedirectURL(r)
+	}
+	if cfg.AppUIBaseURL == "" {
+		cfg.AppUIBaseURL = inferredIntegrationHubURL(r)
+	}
+	if !checkIntegrationCallback(w, r, providerDiscord, "Discord", cfg.AppUIBaseURL, cfgErr) {
+		return
+	}
+	query := r.URL.Query()
+	code := strings.TrimSpace(query.Get("code"))
+	if code == "" {
+		redirectIntegrationResult(w, r, cfg.AppUIBaseURL, providerDiscord, "error", "Missing OAuth code")
+		return
+	}
+
+	access, err := exchangeDiscordOAuthCode(r.Context(), code, cfg)
+	if err != nil {
+		log.Printf("discord oauth exchange failed: %v", err)
+		redirectIntegrationResult(w, r, cfg.AppUIBaseURL, providerDiscord, "error", "Failed to complete Discord install")
+		return
+	}
+	guildID := strings.TrimSpace(query.Get("guild_id"))
+	if access.Guild != nil && access.Guild.ID != "" {
+		guildID = access.Guild.ID
+	}
+	if guildID == "" {
+		redirectIntegrationResult(w, r, cfg.AppUIBaseURL, providerDiscord, "error", "Discord did not report which server the bot joined")
+		return
+	}
+
+	client, err := newDiscordClient(cfg.BotToken)
+	if err != nil {
+		redirectIntegrationResult(w, r, cfg.AppUIBaseURL, providerDiscord, "error", err.Error())
+		return
+	}
+	guild, err := client.Guild(r.Context(), guildID)
+	if err != nil {
+		log.Printf("discord guild lookup failed: %v", err)
+		redirectIntegrationResult(w, r, cfg.AppUIBaseURL, providerDiscord, "error", "The bot token cannot read the selected server")
+		return
+	}
+	if err := client.RegisterCommands(r.Context(), cfg.ApplicationID); err != nil {
+		log.Printf("discord command registration failed: %v", err)
+	}
+
+	// Channels selected in another server mean nothing in this one.
+	if existing, ok := getIntegrationConnection[discordConnectionRecord](integrationStoreInstance, providerDiscord); ok && existing.GuildID != guild.ID {
+		if err := integrationStoreInstance.SetSelectedSources(providerDiscord, nil); err != nil {
+			log.Printf("failed to clear discord channel selection: %v", err)
+		}
+	}
+	now := time.Now().UTC()
+	connection := discordConnectionRecord{
+		GuildID:     guild.ID,
+		GuildName:   guild.Name,
+		Permissions: strings.TrimSpace(query.Get("permissions")),
+		ConnectedAt: now,
+		UpdatedAt:   now,
+	}
+	if err := saveIntegrationConnection(integrationStoreInstance, providerDiscord, connection); err != nil {
+		log.Printf("failed to save discord connection: %v", err)
+		redirectIntegrationResult(w, r, cfg.AppUIBaseURL, providerDiscord, "error", "Failed to store Discord connection")
+		return
+	}
+	redirectIntegrationResult(w, r, cfg.AppUIBaseURL, providerDiscord, "connected", "Discord server connected")
+}
+
+func leaveDiscordGuild(ctx context.Context) {
+	client, conn, err := getDiscordClient()
+	if err != nil {
+		return
+	}
+	if err := client.LeaveGuild(ctx, conn.GuildID); err != nil {
+		log.Printf("discord leave guild failed: %v", err)
+	}
+}
+
+// discordSession serves the shared channel routes from the connected guild.
+type discordSession struct {
+	client *discordClient
+	conn   discordConnectionRecord
+}
+
+func openDiscordSession(context.Context) (channelSession, error) {
+	client, conn, err := getDiscordClient()
+	if err != nil {
+		return nil, err
+	}
+	return discordSession{client: client, conn: conn}, nil
+}
+
+func (s discordSession) Channels(ctx context.Context) ([]integrationChannel, error) {
+	return s.client.GuildChannels(ctx, s.conn.GuildID)
+}
+
+// History skips bot messages and messages without text. A failed page still
+// leaves earlier pages worth keeping.
+func (s discordSession) History(ctx context.Context, channelID string, channelName string) ([]signalRecord, error) {
+	messages, err := s.client.Messages(ctx, channelID)
+	signals := make([]signalRecord, 0, len(messages))
+	for _, msg := range messages {
+		if msg.Author.Bot || strings.TrimSpace(msg.Content) == "" {
+			continue
+		}
+		signals = append(signals, discordMessageSignal(s.conn.GuildID, channelID, channelName, msg))
+	}
+	return signals, err
+}
+
+func discordMessageSignal(guildID string, channelID string, channelName string, msg discordMessage) signalRecord {
+	occurredAt := msg.Timestamp.UTC()
+	if occurredAt.IsZero() {
+		occurredAt = time.Now().UTC()
+	}
+	return signalRecord{
+		ID:         fmt.Sprintf("discord:%s:%s", channelID, msg.ID),
+		Source:     "Discord",
+		Title:      fmt.Sprintf("#%s message", channelName),
+		Summary:    truncateText(strings.TrimSpace(msg.Content), 500),
+		OccurredAt: occurredAt,
+		Meta: map[string]string{
+			"eventType":   "message",
+			"guildId":     guildID,
+			"channel":     channelID,
+			"channelName": channelName,
+			"user":        firstNonEmpty(msg.Author.GlobalName, msg.Author.Username, msg.Author.ID),
+			"permalink":   fmt.Sprintf("https://discord.com/channels/%s/%s/%s", guildID, channelID, msg.ID),
+		},
+	}
+}
+
+func verifyDiscordSignature(r *http.Request, payload []byte) error {
+	cfg, err := currentDiscordRuntimeConfig()
+	if err != nil {
+		return errors.New("failed to resolve Discord public key")
+	}
+	if cfg.PublicKey == "" {
+		return errors.New("discord public key is not configured")
+	}
+	publicKey, err := parseDiscordPublicKey(cfg.PublicKey)
+	if err != nil {
+		return err
+	}
+
+	timestamp := strings.TrimSpace(r.Header.Get("X-Signature-Timestamp"))
+	signature, err := hex.DecodeString(strings.TrimSpace(r.Header.Get("X-Signature-Ed25519")))
+	if timestamp == "" || err != nil || len(signature) != ed25519.SignatureSize {
+		return errors.New("missing Discord signature headers")
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("invalid Discord timestamp header")
+	}
+	if delta := time.Since(time.Unix(ts, 0)); delta > maxDiscordInteractionSkew || delta < -maxDiscordInteractionSkew {
+		return errors.New("stale Discord request timestamp")
+	}
+	if !ed25519.Verify(publicKey, append([]byte(timestamp), payload...), signature) {
+		return errors.New("invalid Discord signature")
+	}
+	return nil
+}
+
+func discordEphemeralReply(w http.ResponseWriter, content string) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"type": discordResponseMessage,
+		"data": map[string]any{"content": content, "flags": discordFlagEphemeral},
+	})
+}
+
+// handleDiscordWebhook is the application's Interactions Endpoint URL.
+// Discord sends a signed PING when the URL is saved, then one request per
+// /feedback or "Send to Sentient" use.
+func handleDiscordWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "unable to read body"})
+		return
+	}
+	if err := verifyDiscordSignature(r, payload); err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: err.Error()})
+		return
+	}
+
+	var interaction discordInteraction
+	if err := json.Unmarshal(payload, &interaction); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid interaction payload"})
+		return
+	}
+	if interaction.Type == discordInteractionPing {
+		writeJSON(w, http.StatusOK, map[string]int{"type": discordResponsePong})
+		return
+	}
+	if interaction.Type != discordInteractionCommand {
+		discordEphemeralReply(w, "Sentient does not handle this interaction.")
+		return
+	}
+	if strings.TrimSpace(interaction.ID) == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "missing interaction id"})
+		return
+	}
+	conn, connected := getIntegrationConnection[discordConnectionRecord](integrationStoreInstance, providerDiscord)
+	if !connected || interaction.GuildID != conn.GuildID {
+		discordEphemeralReply(w, "This server is not connected to Sentient.")
+		return
+	}
+
+	isNew, err := integrationStoreInstance.RecordEvent(providerDiscord, interaction.ID, "command:"+interaction.Data.Name, payload)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to persist interaction"})
+		return
+	}
+	if !isNew {
+		discordEphemeralReply(w, "Already sent to Sentient.")
+		return
+	}
+
+	signal, err := discordInteractionSignal(interaction)
+	if err != nil {
+		integrationStoreInstance.UpdateEventStatus(providerDiscord, interaction.ID, "ignored", err.Error())
+		discordEphemeralReply(w, "Nothing was sent: "+err.Error()+".")
+		return
+	}
+	if err := integrationStoreInstance.AddSignal(signal); err != nil {
+		integrationStoreInstance.UpdateEventStatus(providerDiscord, interaction.ID, "failed", "unable to persist signal")
+		discordEphemeralReply(w, "Sentient could not save this right now. Please try again.")
+		return
+	}
+	integrationStoreInstance.UpdateEventStatus(providerDiscord, interaction.ID, "processed", "")
+	discordEphemeralReply(w, "Thanks, this was sent to Sentient.")
+}
+
+// discordInteractionSignal turns a /feedback or "Send to Sentient" use into a
+// signal. A shared message keeps its message ID so a later history import
+// updates the same signal rather than duplicating it.
+func discordInteractionSignal(interaction discordInteraction) (signalRecord, error) {
+	channelName := interaction.ChannelID
+	if interaction.Channel != nil && interaction.Channel.Name != "" {
+		channelName = interaction.Channel.Name
+	}
+	reporter := interaction.User
+	if interaction.Member != nil {
+		reporter = &interaction.Member.User
+	}
+
+	switch {
+	case interaction.Data.Type == discordCommandMessage && interaction.Data.Name == discordMessageCommand:
+		msg, ok := interaction.Data.Resolved.Messages[interaction.Data.TargetID]
+		if !ok || strings.TrimSpace(msg.Content) == "" {
+			return signalRecord{}, errors.New("the message has no text")
+		}
+		signal := discordMessageSignal(interaction.GuildID, interaction.ChannelID, channelName, msg)
+		signal.Meta["eventType"] = "message_command"
+		if reporter != nil {
+			signal.Meta["sharedBy"] = firstNonEmpty(reporter.GlobalName, reporter.Username, reporter.ID)
+		}
+		return signal, nil
+	case interaction.Data.Name == discordFeedbackCommand:
+		text := ""
+		for _, option := range interaction.Data.Options {
+			if option.Name == "text" {
+				text, _ = option.Value.(string)
+			}
+		}
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return signalRecord{}, errors.New("feedback text is empty")
+		}
+		user := ""
+		if reporter != nil {
+			user = firstNonEmpty(reporter.GlobalName, reporter.Username, reporter.ID)
+		}
+		return signalRecord{
+			ID:         fmt.Sprintf("discord:%s:%s", interaction.ChannelID, interaction.ID),
+			Source:     "Discord",
+			Title:      fmt.Sprintf("#%s feedback", channelName),
+			Summary:    truncateText(text, 500),
+			OccurredAt: time.Now().UTC(),
+			Meta: map[string]string{
+				"eventType":   "feedback_command",
+				"guildId":     interaction.GuildID,
+				"channel":     interaction.ChannelID,
+				"channelName": channelName,
+				"user":        user,
+			},
+		}, nil
+	default:
+		return signalRecord{}, fmt.Errorf("unknown command %q", interaction.Data.Name)
+	}
+}
+
+// discordIntegration mounts the Discord handlers on the integration registry.
+type discordIntegration struct{}
+
+func (discordIntegration) Name() string { return providerDiscord }
+
+func (discordIntegration) Summary() integrationSummary {
+	summary := integrationSummary{
+		Provider: providerDiscord,
+		Name:     "Discord",
+		Status:   "Disconnected",
+		Detail:   defaultDiscordConnectionDetail,
+	}
+	if conn, ok := getIntegrationConnection[discordConnectionRecord](integrationStoreInstance, providerDiscord); ok {
+		summary.Status = "Connected"
+		summary.ConnectedAt = conn.ConnectedAt.Format(time.RFC3339)
+		summary.Detail = fmt.Sprintf("%s server connected (%d selected channels, %d interactions received)",
+			conn.GuildName, len(integrationStoreInstance.GetSelectedSources(providerDiscord)), integrationStoreInstance.EventCount(providerDiscord))
+	}
+	return summary
+}
+
+func (discordIntegration) ConnectURL(w http.ResponseWriter, r *http.Request) {
+	serveIntegrationConnectURL(w, r, providerDiscord, "Discord", discordAuthorizeRequest)
+}
+
+func (discordIntegration) Callback(w http.ResponseWriter, r *http.Request) {
+	handleDiscordCallback(w, r)
+}
+
+func (discordIntegration) Disconnect(w http.ResponseWriter, r *http.Request) {
+	serveIntegrationDisconnect(w, r, providerDiscord, "Discord", leaveDiscordGuild)
+}
+
+func (discordIntegration) Sources(w http.ResponseWriter, r *http.Request) {
+	serveIntegrationChannels(w, r, providerDiscord, openDiscordSession)
+}
+
+func (discordIntegration) Import(w http.ResponseWriter, r *http.Request) {
+	serveIntegrationChannelsImport(w, r, providerDiscord, openDiscordSession)
+}
+
+func (discordIntegration) Webhook(w http.ResponseWriter, r *http.Request) {
+	handleDiscordWebhook(w, r)
+}
+
+// ExtraRoutes mirrors Slack's setup wizard and channel paths.
+func (d discordIntegration) ExtraRoutes() map[string]http.HandlerFunc {
+	return map[string]http.HandlerFunc{
+		"setup":           discordSetupWizard.Setup,
+		"setup/validate":  discordSetupWizard.Validate,
+		"channels":        d.Sources,
+		"channels/import": d.Import,
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// connectTestDiscord stores a Discord setup with a fresh signing key and a
+// connection to guild g1, and returns the key interactions are signed with.
+func connectTestDiscord(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	useTestIntegrations(t)
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setup := discordSetupPersisted{
+		ApplicationID:     "app-1",
+		PublicKey:         hex.EncodeToString(public),
+		EncryptedBotToken: encryptForTest(t, "bot-token"),
+	}
+	if err := saveIntegrationSetup(integrationStoreInstance, providerDiscord, setup); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveIntegrationConnection(integrationStoreInstance, providerDiscord, discordConnectionRecord{GuildID: "g1", GuildName: "Acme"}); err != nil {
+		t.Fatal(err)
+	}
+	return private
+}
+
+func postDiscordInteraction(t *testing.T, key ed25519.PrivateKey, signedAt time.Time, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	req := httptest.NewRequest(http.MethodPost, "/api/integrations/discord/webhook", strings.NewReader(body))
+	req.Header.Set("X-Signature-Timestamp", timestamp)
+	req.Header.Set("X-Signature-Ed25519", hex.EncodeToString(ed25519.Sign(key, []byte(timestamp+body))))
+	rec := httptest.NewRecorder()
+	discordIntegration{}.Webhook(rec, req)
+	return rec
+}
+
+func TestDiscordWebhookVerifiesSignature(t *testing.T) {
+	key := connectTestDiscord(t)
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ping := `{"id":"p1","type":1}`
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		key      ed25519.PrivateKey
+		signedAt time.Time
+		want     int
+	}{
+		{"valid", key, now, http.StatusOK},
+		{"wrong key", otherKey, now, http.StatusUnauthorized},
+		{"stale timestamp", key, now.Add(-maxDiscordInteractionSkew - time.Minute), http.StatusUnauthorized},
+		{"future timestamp", key, now.Add(maxDiscordInteractionSkew + time.Minute), http.StatusUnauthorized},
+		{"within skew", key, now.Add(-maxDiscordInteractionSkew + time.Minute), http.StatusOK},
+	}
+	for _, tt := range tests {
+		if rec := postDiscordInteraction(t, tt.key, tt.signedAt, ping); rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d: %s", tt.name, rec.Code, tt.want, rec.Body)
+		}
+	}
+
+	// The signature covers the body, so a signed body cannot be swapped.
+	req := httptest.NewRequest(http.MethodPost, "/api/integrations/discord/webhook", strings.NewReader(`{"id":"p2","type":1}`))
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set("X-Signature-Timestamp", timestamp)
+	req.Header.Set("X-Signature-Ed25519", hex.EncodeToString(ed25519.Sign(key, []byte(timestamp+ping))))
+	rec := httptest.NewRecorder()
+	discordIntegration{}.Webhook(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("tampered body: status = %d, want 401", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/integrations/discord/webhook", strings.NewReader(ping))
+	rec = httptest.NewRecorder()
+	discordIntegration{}.Webhook(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("unsigned request: status = %d, want 401", rec.Code)
+	}
+}
+
+func TestDiscordWebhookAnswersPing(t *testing.T) {
+	key := connectTestDiscord(t)
+	rec := postDiscordInteraction(t, key, time.Now(), `{"id":"p1","type":1}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	if got := decodeTestJSON(t, rec)["type"]; got != float64(discordResponsePong) {
+		t.Fatalf("response type = %v, want PONG", got)
+	}
+	if got := integrationStoreInstance.EventCount(providerDiscord); got != 0 {
+		t.Fatalf("PING was recorded as %d events", got)
+	}
+}
+
+func TestDiscordWebhookDedupesInteractions(t *testing.T) {
+	key := connectTestDiscord(t)
+	feedback := `{"id":"i1","type":2,"guild_id":"g1","channel_id":"c1","channel":{"id":"c1","name":"general"},
+		"member":{"user":{"id":"u1","username":"alex"}},
+		"data":{"name":"feedback","type":1,"options":[{"name":"text","value":"Please add dark mode"}]}}`
+
+	first := postDiscordInteraction(t, key, time.Now(), feedback)
+	if reply := discordReplyContent(t, first); !strings.Contains(reply, "Thanks") {
+		t.Fatalf("first delivery reply = %q", reply)
+	}
+	second := postDiscordInteraction(t, key, time.Now(), feedback)
+	if reply := discordReplyContent(t, second); !strings.Contains(reply, "Already sent") {
+		t.Fatalf("duplicate delivery reply = %q", reply)
+	}
+
+	if got := integrationStoreInstance.EventCount(providerDiscord); got != 1 {
+		t.Fatalf("events recorded = %d, want 1", got)
+	}
+	signals := integrationStoreInstance.ListSignals("Discord", 10)
+	if len(signals) != 1 {
+		t.Fatalf("signals = %+v, want one", signals)
+	}
+	if signals[0].Summary != "Please add dark mode" || signals[0].Meta["user"] != "alex" {
+		t.Fatalf("unexpected signal: %+v", signals[0])
+	}
+
+	// Another server's interactions are not accepted.
+	other := strings.Replace(strings.Replace(feedback, `"g1"`, `"g2"`, 1), `"i1"`, `"i2"`, 1)
+	if reply := discordReplyContent(t, postDiscordInteraction(t, key, time.Now(), other)); !strings.Contains(reply, "not connected") {
+		t.Fatalf("other guild reply = %q", reply)
+	}
+}
+
+func discordReplyContent(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	body := decodeTestJSON(t, rec)
+	data, _ := body["data"].(map[string]any)
+	content, _ := data["content"].(string)
+	return content
+}
+
+func TestDiscordChannelSelectionAndImport(t *testing.T) {
+	connectTestDiscord(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bot bot-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/guilds/g1/channels":
+			_, _ = w.Write([]byte(`[{"id":"c2","name":"Announcements","type":5},{"id":"v1","name":"voice","type":2},{"id":"c1","name":"general","type":0}]`))
+		case "/channels/c1/messages":
+			_, _ = w.Write([]byte(`[
+				{"id":"m2","content":"Export is broken","timestamp":"2026-03-02T00:00:00Z","author":{"id":"u1","username":"alex"}},
+				{"id":"m1","content":"Deployed v2","author":{"id":"b1","username":"ci","bot":true}}]`))
+		case "/channels/c2/messages":
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message":"Missing Access"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	prevURL := discordAPIURL
+	discordAPIURL = server.URL
+	t.Cleanup(func() { discordAPIURL = prevURL })
+
+	rec := httptest.NewRecorder()
+	discordIntegration{}.Sources(rec, httptest.NewRequest(http.MethodPut, "/api/integrations/discord/sources",
+		strings.NewReader(`{"channelIds":["c1"," c2","c1","v1","unknown",""]}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("select: status = %d: %s", rec.Code, rec.Body)
+	}
+	if got := integrationStoreInstance.GetSelectedSources(providerDiscord); strings.Join(got, ",") != "c1,c2" {
+		t.Fatalf("selected = %v, want [c1 c2]", got)
+	}
+
+	rec = httptest.NewRecorder()
+	discordIntegration{}.Sources(rec, httptest.NewRequest(http.MethodGet, "/api/integrations/discord/sources", nil))
+	listed := decodeTestJSON(t, rec)
+	if listed["total"] != float64(2) || listed["selectedCount"] != float64(2) {
+		t.Fatalf("unexpected channel list: %v", listed)
+	}
+
+	rec = httptest.NewRecorder()
+	discordIntegration{}.Import(rec, httptest.NewRequest(http.MethodPost, "/api/integrations/discord/import", nil))
+	imported := decodeTestJSON(t, rec)
+	if imported["importedSignals"] != float64(1) {
+		t.Fatalf("unexpected import: %v", imported)
+	}
+	if errs, _ := imported["errors"].([]any); len(errs) != 1 || !strings.Contains(errs[0].(string), "c2") {
+		t.Fatalf("expected the unreadable channel to be reported: %v", imported)
+	}
+	signals := integrationStoreInstance.ListSignals("Discord", 10)
+	if len(signals) != 1 || signals[0].ID != "discord:c1:m2" || signals[0].Meta["imported"] != "true" {
+		t.Fatalf("unexpected signals: %+v", signals)
+	}
+
+	rec = httptest.NewRecorder()
+	discordIntegration{}.Disconnect(rec, httptest.NewRequest(http.MethodPost, "/api/integrations/discord/disconnect", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("disconnect: status = %d: %s", rec.Code, rec.Body)
+	}
+	if _, ok := getIntegrationConnection[discordConnectionRecord](integrationStoreInstance, providerDiscord); ok {
+		t.Fatal("connection survived disconnect")
+	}
+	if got := integrationStoreInstance.GetSelectedSources(providerDiscord); len(got) != 0 {
+		t.Fatalf("selection survived disconnect: %v", got)
+	}
+}
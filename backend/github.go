@@ -0,0 +1,762 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	providerGitHub         = "github"
+	defaultGitHubAPIURL    = "https://api.github.com"
+	githubRequestTimeout   = 20 * time.Second
+	githubPageSize         = 100
+	maxGitHubImportPages   = 10
+	maxGitHubRepos         = 20
+	githubIssueTitleMaxLen = 250
+)
+
+var (
+	githubRepoPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
+	githubNextLink    = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+)
+
+// githubConnectionRecord is the stored GitHub connection. ImportedThrough
+// holds, per repo, the updated_at imports have read through so they only
+// fetch what changed since.
+type githubConnectionRecord struct {
+	Login           string               `json:"login"`
+	EncryptedToken  string               `json:"encryptedToken"`
+	Repos           []string             `json:"repos"`
+	ImportedThrough map[string]time.Time `json:"importedThrough,omitempty"`
+	ConnectedAt     time.Time            `json:"connectedAt"`
+	UpdatedAt       time.Time            `json:"updatedAt"`
+}
+
+type githubConnectRequest struct {
+	Token string   `json:"token"`
+	Repos []string `json:"repos"`
+}
+
+type githubReposRequest struct {
+	Repos []string `json:"repos"`
+}
+
+type githubImportRequest struct {
+	Repos []string `json:"repos"`
+	Full  bool     `json:"full"`
+}
+
+type githubImportResponse struct {
+	Status           string   `json:"status"`
+	TotalRepos       int      `json:"totalRepos"`
+	ImportedIssues   int      `json:"importedIssues"`
+	ImportedComments int      `json:"importedComments"`
+	Errors           []string `json:"errors"`
+}
+
+type githubTrackingIssueRequest struct {
+	Repo string `json:"repo"`
+}
+
+type githubClient struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+type githubRepo struct {
+	FullName string `json:"full_name"`
+	Private  bool   `json:"private"`
+	HTMLURL  string `json:"html_url"`
+	Selected bool   `json:"selected"`
+}
+
+type githubReactions struct {
+	TotalCount int `json:"total_count"`
+	PlusOne    int `json:"+1"`
+	MinusOne   int `json:"-1"`
+	Laugh      int `json:"laugh"`
+	Hooray     int `json:"hooray"`
+	Confused   int `json:"confused"`
+	Heart      int `json:"heart"`
+	Rocket     int `json:"rocket"`
+	Eyes       int `json:"eyes"`
+}
+
+type githubIssue struct {
+	Number      int                     `json:"number"`
+	Title       string                  `json:"title"`
+	Body        string                  `json:"body"`
+	State       string                  `json:"state"`
+	HTMLURL     string                  `json:"html_url"`
+	Comments    int                     `json:"comments"`
+	User        githubUser              `json:"user"`
+	Labels      []struct{ Name string } `json:"labels"`
+	Reactions   githubReactions         `json:"reactions"`
+	CreatedAt   time.Time               `json:"created_at"`
+	UpdatedAt   time.Time               `json:"updated_at"`
+	PullRequest *struct{}               `json:"pull_request"`
+}
+
+type githubComment struct {
+	ID        int64           `json:"id"`
+	Body      string          `json:"body"`
+	HTMLURL   string          `json:"html_url"`
+	IssueURL  string          `json:"issue_url"`
+	User      githubUser      `json:"user"`
+	Reactions githubReactions `json:"reactions"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+type githubStatusError struct {
+	Status  int
+	Message string
+}
+
+func (e *githubStatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("github returned status %d", e.Status)
+	}
+	return fmt.Sprintf("github returned status %d: %s", e.Status, e.Message)
+}
+
+func newGitHubClient(token string) (*githubClient, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, errors.New("token is required")
+	}
+	return &githubClient{
+		baseURL: strings.TrimRight(githubAPIURL, "/"),
+		token:   token,
+		client:  &http.Client{Timeout: githubRequestTimeout},
+	}, nil
+}
+
+func getGitHubClient() (*githubClient, githubConnectionRecord, error) {
+	conn, connected := integrationStoreInstance.GetGitHubConnection()
+	if !connected {
+		return nil, conn, errors.New("github is not connected")
+	}
+	if integrationTokenCipher == nil {
+		return nil, conn, errors.New("integration encryption is not configured")
+	}
+	token, err := integrationTokenCipher.Decrypt(conn.EncryptedToken)
+	if err != nil {
+		return nil, conn, fmt.Errorf("failed to decrypt GitHub token: %w", err)
+	}
+	client, err := newGitHubClient(token)
+	return client, conn, err
+}
+
+// do sends one request. target is a path on the API or, when following
+// pagination, an absolute URL. It returns the next page URL, if any.
+func (c *githubClient) do(ctx context.Context, method, target string, payload any, out any) (string, error) {
+	if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+		target = c.baseURL + target
+	}
+	var body io.Reader
+	if payload != nil {
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return "", err
+		}
+		body = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("github %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if err != nil {
+		return "", fmt.Errorf("github %s: %w", method, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var parsed struct {
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(respBody, &parsed)
+		return "", &githubStatusError{Status: resp.StatusCode, Message: parsed.Message}
+	}
+	if out != nil {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return "", fmt.Errorf("decode github response: %w", err)
+		}
+	}
+	next := ""
+	if match := githubNextLink.FindStringSubmatch(resp.Header.Get("Link")); match != nil {
+		next = match[1]
+	}
+	return next, nil
+}
+
+func (c *githubClient) Viewer(ctx context.Context) (githubUser, error) {
+	var user githubUser
+	_, err := c.do(ctx, http.MethodGet, "/user", nil, &user)
+	return user, err
+}
+
+func (c *githubClient) Repo(ctx context.Context, fullName string) (githubRepo, error) {
+	var repo githubRepo
+	_, err := c.do(ctx, http.MethodGet, "/repos/"+fullName, nil, &repo)
+	return repo, err
+}
+
+// Repos lists repositories the token can read, up to maxGitHubImportPages
+// pages.
+func (c *githubClient) Repos(ctx context.Context) ([]githubRepo, error) {
+	repos := make([]githubRepo, 0, githubPageSize)
+	next := fmt.Sprintf("/user/repos?per_page=%d&sort=pushed", githubPageSize)
+	for page := 0; next != "" && page < maxGitHubImportPages; page++ {
+		var batch []githubRepo
+		var err error
+		if next, err = c.do(ctx, http.MethodGet, next, nil, &batch); err != nil {
+			return nil, err
+		}
+		repos = append(repos, batch...)
+	}
+	return repos, nil
+}
+
+// Issues returns issues (not pull requests) updated at or after since, in
+// updated order. When the page limit cuts the list short, until is the
+// updated_at of the last item fetched; otherwise it is zero.
+func (c *githubClient) Issues(ctx context.Context, repo string, since time.Time) ([]githubIssue, time.Time, error) {
+	query := url.Values{}
+	query.Set("state", "all")
+	query.Set("sort", "updated")
+	query.Set("direction", "asc")
+	query.Set("per_page", strconv.Itoa(githubPageSize))
+	if !since.IsZero() {
+		query.Set("since", since.UTC().Format(time.RFC3339))
+	}
+	issues := make([]githubIssue, 0, githubPageSize)
+	var last time.Time
+	next := "/repos/" + repo + "/issues?" + query.Encode()
+	for page := 0; next != "" && page < maxGitHubImportPages; page++ {
+		var batch []githubIssue
+		var err error
+		if next, err = c.do(ctx, http.MethodGet, next, nil, &batch); err != nil {
+			return nil, time.Time{}, err
+		}
+		for _, issue := range batch {
+			last = issue.UpdatedAt
+			if issue.PullRequest == nil {
+				issues = append(issues, issue)
+			}
+		}
+	}
+	if next == "" {
+		return issues, time.Time{}, nil
+	}
+	return issues, last, nil
+}
+
+// Comments returns issue comments across the repo updated at or after since,
+// in updated order. until is as for Issues.
+func (c *githubClient) Comments(ctx context.Context, repo string, since time.Time) ([]githubComment, time.Time, error) {
+	query := url.Values{}
+	query.Set("sort", "updated")
+	query.Set("direction", "asc")
+	query.Set("per_page", strconv.Itoa(githubPageSize))
+	if !since.IsZero() {
+		query.Set("since", since.UTC().Format(time.RFC3339))
+	}
+	comments := make([]githubComment, 0, githubPageSize)
+	next := "/repos/" + repo + "/issues/comments?" + query.Encode()
+	for page := 0; next != "" && page < maxGitHubImportPages; page++ {
+		var batch []githubComment
+		var err error
+		if next, err = c.do(ctx, http.MethodGet, next, nil, &batch); err != nil {
+			return nil, time.Time{}, err
+		}
+		comments = append(comments, batch...)
+	}
+	if next == "" || len(comments) == 0 {
+		return comments, time.Time{}, nil
+	}
+	return comments, comments[len(comments)-1].UpdatedAt, nil
+}
+
+func (c *githubClient) CreateIssue(ctx context.Context, repo, title, body string, labels []string) (githubIssue, error) {
+	var issue githubIssue
+	_, err := c.do(ctx, http.MethodPost, "/repos/"+repo+"/issues", map[string]any{
+		"title":  title,
+		"body":   body,
+		"labels": labels,
+	}, &issue)
+	return issue, err
+}
+
+func normalizeGitHubRepos(raw []string) ([]string, error) {
+	repos := make([]string, 0, len(raw))
+	for _, entry := range raw {
+		repo := strings.Trim(strings.TrimSpace(entry), "/")
+		if repo == "" {
+			continue
+		}
+		if !githubRepoPattern.MatchString(repo) {
+			return nil, fmt.Errorf("%q is not an owner/name repository", entry)
+		}
+		if !slices.ContainsFunc(repos, func(existing string) bool { return strings.EqualFold(existing, repo) }) {
+			repos = append(repos, repo)
+		}
+	}
+	if len(repos) > maxGitHubRepos {
+		return nil, fmt.Errorf("at most %d repositories can be configured", maxGitHubRepos)
+	}
+	return repos, nil
+}
+
+// verifyGitHubRepos checks every repo is readable and returns canonical names.
+func verifyGitHubRepos(ctx context.Context, client *githubClient, repos []string) ([]string, error) {
+	verified := make([]string, 0, len(repos))
+	for _, repo := range repos {
+		details, err := client.Repo(ctx, repo)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", repo, err)
+		}
+		verified = append(verified, firstNonEmpty(details.FullName, repo))
+	}
+	return verified, nil
+}
+
+func githubReactionMeta(meta map[string]string, reactions githubReactions) {
+	meta["reactions"] = strconv.Itoa(reactions.TotalCount)
+	for key, count := range map[string]int{
+		"reactionsThumbsUp":   reactions.PlusOne,
+		"reactionsThumbsDown": reactions.MinusOne,
+		"reactionsLaugh":      reactions.Laugh,
+		"reactionsHooray":     reactions.Hooray,
+		"reactionsConfused":   reactions.Confused,
+		"reactionsHeart":      reactions.Heart,
+		"reactionsRocket":     reactions.Rocket,
+		"reactionsEyes":       reactions.Eyes,
+	} {
+		if count > 0 {
+			meta[key] = strconv.Itoa(count)
+		}
+	}
+}
+
+func githubIssueSignal(repo string, issue githubIssue) signalRecord {
+	labels := make([]string, 0, len(issue.Labels))
+	for _, label := range issue.Labels {
+		labels = append(labels, label.Name)
+	}
+	meta := map[string]string{
+		"eventType": "issue",
+		"repo":      repo,
+		"number":    strconv.Itoa(issue.Number),
+		"state":     issue.State,
+		"user":      issue.User.Login,
+		"comments":  strconv.Itoa(issue.Comments),
+		"permalink": issue.HTMLURL,
+		"imported":  "true",
+	}
+	if len(labels) > 0 {
+		meta["labels"] = strings.Join(labels, ",")
+	}
+	githubReactionMeta(meta, issue.Reactions)
+	return signalRecord{
+		ID:         fmt.Sprintf("github:%s:issue:%d", repo, issue.Number),
+		Source:     "GitHub",
+		Title:      fmt.Sprintf("%s#%d: %s", repo, issue.Number, strings.TrimSpace(issue.Title)),
+		Summary:    truncateText(strings.TrimSpace(issue.Title+"\n\n"+issue.Body), 500),
+		OccurredAt: issue.CreatedAt.UTC(),
+		Meta:       meta,
+	}
+}
+
+func githubCommentSignal(repo string, comment githubComment) signalRecord {
+	number := comment.IssueURL[strings.LastIndex(comment.IssueURL, "/")+1:]
+	meta := map[string]string{
+		"eventType": "comment",
+		"repo":      repo,
+		"number":    number,
+		"user":      comment.User.Login,
+		"permalink": comment.HTMLURL,
+		"imported":  "true",
+	}
+	githubReactionMeta(meta, comment.Reactions)
+	return signalRecord{
+		ID:         fmt.Sprintf("github:%s:comment:%d", repo, comment.ID),
+		Source:     "GitHub",
+		Title:      fmt.Sprintf("%s#%s comment", repo, number),
+		Summary:    truncateText(strings.TrimSpace(comment.Body), 500),
+		OccurredAt: comment.CreatedAt.UTC(),
+		Meta:       meta,
+	}
+}
+
+// importGitHubRepo stores a repo's issues and comments as signals and
+// returns the cursor for the next import: the newest updated_at seen, held
+// back to where a list stopped if the page limit cut it short, so the rest
+// of that list is fetched next time. A failed import keeps since.
+func importGitHubRepo(ctx context.Context, client *githubClient, repo string, since time.Time) (int, int, time.Time, error) {
+	issues, issuesUntil, err := client.Issues(ctx, repo, since)
+	if err != nil {
+		return 0, 0, since, fmt.Errorf("list issues: %w", err)
+	}
+	comments, commentsUntil, err := client.Comments(ctx, repo, since)
+	if err != nil {
+		return 0, 0, since, fmt.Errorf("list comments: %w", err)
+	}
+
+	through := since
+	importedIssues, importedComments := 0, 0
+	for _, issue := range issues {
+		if err := integrationStoreInstance.AddSignal(githubIssueSignal(repo, issue)); err != nil {
+			return importedIssues, importedComments, since, fmt.Errorf("store issue #%d: %w", issue.Number, err)
+		}
+		importedIssues++
+		if issue.UpdatedAt.After(through) {
+			through = issue.UpdatedAt
+		}
+	}
+	for _, comment := range comments {
+		if comment.UpdatedAt.After(through) {
+			through = comment.UpdatedAt
+		}
+		if strings.TrimSpace(comment.Body) == "" {
+			continue
+		}
+		if err := integrationStoreInstance.AddSignal(githubCommentSignal(repo, comment)); err != nil {
+			return importedIssues, importedComments, since, fmt.Errorf("store comment %d: %w", comment.ID, err)
+		}
+		importedComments++
+	}
+	for _, until := range []time.Time{issuesUntil, commentsUntil} {
+		if !until.IsZero() && until.Before(through) {
+			through = until
+		}
+	}
+	return importedIssues, importedComments, through, nil
+}
+
+// githubConnectErrorStatus maps a connect failure to a response status.
+// GitHub rejecting the token or a repo is for the caller to fix; GitHub
+// failing, rate limiting or being unreachable is a bad gateway.
+func githubConnectErrorStatus(err error) int {
+	var githubErr *githubStatusError
+	if errors.As(err, &githubErr) {
+		if githubErr.Status >= http.StatusInternalServerError || githubErr.Status == http.StatusTooManyRequests {
+			return http.StatusBadGateway
+		}
+		return http.StatusBadRequest
+	}
+	var netErr *url.Error
+	if errors.As(err, &netErr) {
+		return http.StatusBadGateway
+	}
+	return http.StatusBadRequest
+}
+
+func handleGitHubConnect(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if integrationTokenCipher == nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "integration encryption is not configured"})
+		return
+	}
+	var req githubConnectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid json"})
+		return
+	}
+	repos, err := normalizeGitHubRepos(req.Repos)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	client, err := newGitHubClient(req.Token)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+
+	user, err := client.Viewer(r.Context())
+	if err != nil {
+		writeJSON(w, githubConnectErrorStatus(err), errorResponse{Error: "verify github token: " + err.Error()})
+		return
+	}
+	if repos, err = verifyGitHubRepos(r.Context(), client, repos); err != nil {
+		writeJSON(w, githubConnectErrorStatus(err), errorResponse{Error: err.Error()})
+		return
+	}
+	encrypted, err := integrationTokenCipher.Encrypt(client.token)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to encrypt GitHub token"})
+		return
+	}
+
+	now := time.Now().UTC()
+	record := githubConnectionRecord{
+		Login:          user.Login,
+		EncryptedToken: encrypted,
+		Repos:          repos,
+		ConnectedAt:    now,
+		UpdatedAt:      now,
+	}
+	if existing, ok := integrationStoreInstance.GetGitHubConnection(); ok && existing.Login == record.Login {
+		record.ConnectedAt = existing.ConnectedAt
+		record.ImportedThrough = existing.ImportedThrough
+	}
+	if err := integrationStoreInstance.UpsertGitHubConnection(record); err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to save GitHub connection"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status": "ok",
+		"login":  record.Login,
+		"repos":  record.Repos,
+	})
+}
+
+func handleGitHubRepos(w http.ResponseWriter, r *http.Request) {
+	client, conn, err := getGitHubClient()
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		repos, err := client.Repos(r.Context())
+		if err != nil {
+			writeJSON(w, http.StatusBadGateway, errorResponse{Error: err.Error()})
+			return
+		}
+		for idx := range repos {
+			repos[idx].Selected = slices.ContainsFunc(conn.Repos, func(selected string) bool {
+				return strings.EqualFold(selected, repos[idx].FullName)
+			})
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"repos":         repos,
+			"total":         len(repos),
+			"selectedRepos": conn.Repos,
+		})
+	case http.MethodPut:
+		var req githubReposRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid json"})
+			return
+		}
+		repos, err := normalizeGitHubRepos(req.Repos)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+			return
+		}
+		if repos, err = verifyGitHubRepos(r.Context(), client, repos); err != nil {
+			writeJSON(w, http.StatusBadGateway, errorResponse{Error: err.Error()})
+			return
+		}
+		if err := integrationStoreInstance.SetGitHubRepos(repos); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to save repositories"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"status": "ok",
+			"repos":  repos,
+		})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func handleGitHubImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	client, conn, err := getGitHubClient()
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	var req githubImportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid json"})
+		return
+	}
+	repos, err := normalizeGitHubRepos(req.Repos)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	if len(repos) == 0 {
+		repos = conn.Repos
+	}
+	if len(repos) == 0 {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "no repositories configured"})
+		return
+	}
+
+	response := githubImportResponse{Status: "ok", TotalRepos: len(repos), Errors: []string{}}
+	for _, repo := range repos {
+		since := conn.ImportedThrough[repo]
+		if req.Full {
+			since = time.Time{}
+		}
+		issues, comments, through, err := importGitHubRepo(r.Context(), client, repo, since)
+		response.ImportedIssues += issues
+		response.ImportedComments += comments
+		if err != nil {
+			response.Errors = append(response.Errors, fmt.Sprintf("%s: %v", repo, err))
+		}
+		if through.After(since) {
+			if err := integrationStoreInstance.SetGitHubImportedThrough(repo, through); err != nil {
+				response.Errors = append(response.Errors, fmt.Sprintf("%s: failed to save import cursor", repo))
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func handleGitHubDisconnect(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := integrationStoreInstance.DisconnectGitHub(); err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to disconnect GitHub"})
+		return
+	}
+	writeJSON(w, http.StatusOK, okResponse{Status: "ok"})
+}
+
+// githubIntegration mounts the GitHub handlers on the integration registry.
+// GitHub connects with a token rather than OAuth and is polled, so it has no
+// connect URL, callback or webhook.
+type githubIntegration struct{}
+
+func (githubIntegration) Name() string { return providerGitHub }
+
+func (githubIntegration) Summary() integrationSummary {
+	summary := integrationSummary{
+		Provider: providerGitHub,
+		Name:     "GitHub",
+		Status:   "Disconnected",
+		Detail:   "Import issues and reactions from your repositories",
+	}
+	if conn, ok := integrationStoreInstance.GetGitHubConnection(); ok {
+		summary.Status = "Connected"
+		summary.ConnectedAt = conn.ConnectedAt.Format(time.RFC3339)
+		summary.Detail = fmt.Sprintf("%s connected (%d repositories)", conn.Login, len(conn.Repos))
+	}
+	return summary
+}
+
+func (githubIntegration) ConnectURL(w http.ResponseWriter, r *http.Request) {
+	writeIntegrationUnsupported(w, providerGitHub, "connect-url")
+}
+
+func (githubIntegration) Callback(w http.ResponseWriter, r *http.Request) {
+	writeIntegrationUnsupported(w, providerGitHub, "callback")
+}
+
+func (githubIntegration) Webhook(w http.ResponseWriter, r *http.Request) {
+	writeIntegrationUnsupported(w, providerGitHub, "webhook")
+}
+
+func (githubIntegration) Disconnect(w http.ResponseWriter, r *http.Request) {
+	handleGitHubDisconnect(w, r)
+}
+
+func (githubIntegration) Sources(w http.ResponseWriter, r *http.Request) {
+	handleGitHubRepos(w, r)
+}
+
+func (githubIntegration) Import(w http.ResponseWriter, r *http.Request) {
+	handleGitHubImport(w, r)
+}
+
+func (githubIntegration) ExtraRoutes() map[string]http.HandlerFunc {
+	return map[string]http.HandlerFunc{
+		"connect": handleGitHubConnect,
+		"repos":   handleGitHubRepos,
+	}
+}
+
+// openGitHubTrackingIssue files a completed decision run as a GitHub issue in
+// repo, or the first configured repo.
+func openGitHubTrackingIssue(ctx context.Context, runID string, repo string) (githubIssueArtifact, error) {
+	client, conn, err := getGitHubClient()
+	if err != nil {
+		return githubIssueArtifact{}, err
+	}
+	repos, err := normalizeGitHubRepos([]string{repo})
+	if err != nil {
+		return githubIssueArtifact{}, err
+	}
+	if len(repos) == 0 {
+		if len(conn.Repos) == 0 {
+			return githubIssueArtifact{}, errors.New("repo is required when no repositories are configured")
+		}
+		repos = conn.Repos[:1]
+	}
+
+	detail, err := fetchDecisionRunDetail(ctx, runID)
+	if err != nil {
+		return githubIssueArtifact{}, err
+	}
+	if status := stringFromAny(detail["status"]); status != "completed" {
+		if reason := stringFromAny(detail["error"]); reason != "" {
+			return githubIssueArtifact{}, fmt.Errorf("%w: status is %s: %s", errDecisionRunNotReady, status, reason)
+		}
+		return githubIssueArtifact{}, fmt.Errorf("%w: status is %s", errDecisionRunNotReady, status)
+	}
+	var decision decisionObject
+	found := false
+	if artifacts, ok := detail["artifacts"].([]any); ok {
+		for _, raw := range artifacts {
+			artifact, _ := raw.(map[string]any)
+			if stringFromAny(artifact["type"]) == artifactTypeDecisionObject {
+				if err := decodeArtifact(artifact["json"], &decision, false); err != nil {
+					return githubIssueArtifact{}, fmt.Errorf("decode decision object: %w", err)
+				}
+				found = true
+			}
+		}
+	}
+	if !found {
+		return githubIssueArtifact{}, fmt.Errorf("%w: no decision object", errDecisionRunNotReady)
+	}
+
+	title := truncateText(fmt.Sprintf("[%s] %s", decision.Recommendation.Priority, decision.Feature), githubIssueTitleMaxLen)
+	issue, err := client.CreateIssue(ctx, repos[0], title, decisionMarkdown(decision, runID), []string{"sentient"})
+	if err != nil {
+		return githubIssueArtifact{}, fmt.Errorf("create github issue in %s: %w", repos[0], err)
+	}
+	return githubIssueArtifact{Repo: repos[0], Number: issue.Number, URL: issue.HTMLURL}, nil
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeGitHub serves the REST endpoints the GitHub client uses. Issue creation
+// waits on release when it is set, to hold a request in flight.
+type fakeGitHub struct {
+	mu      sync.Mutex
+	created []map[string]any
+	release chan struct{}
+	entered chan struct{}
+}
+
+func useFakeGitHub(t *testing.T) *fakeGitHub {
+	t.Helper()
+	fake := &fakeGitHub{}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer gh-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message":"Bad credentials"}`))
+			return
+		}
+		switch {
+		case r.URL.Path == "/user":
+			_, _ = w.Write([]byte(`{"login":"octo"}`))
+		case r.URL.Path == "/repos/acme/app":
+			_, _ = w.Write([]byte(`{"full_name":"acme/app","html_url":"https://github.com/acme/app"}`))
+		case r.URL.Path == "/repos/acme/missing":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"Not Found"}`))
+		case r.URL.Path == "/repos/acme/down":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case r.URL.Path == "/repos/acme/app/issues" && r.Method == http.MethodGet:
+			if r.URL.Query().Get("page") == "2" {
+				_, _ = w.Write([]byte(`[{"number":2,"title":"Export to CSV","state":"closed","html_url":"https://github.com/acme/app/issues/2",
+					"user":{"login":"sam"},"created_at":"2026-03-02T00:00:00Z","updated_at":"2026-03-05T00:00:00Z"}]`))
+				return
+			}
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/acme/app/issues?page=2>; rel="next"`, server.URL))
+			_, _ = w.Write([]byte(`[
+				{"number":1,"title":"Dark mode please","body":"My eyes hurt","state":"open","html_url":"https://github.com/acme/app/issues/1",
+				 "comments":1,"user":{"login":"alex"},"labels":[{"name":"enhancement"}],
+				 "reactions":{"total_count":7,"+1":5,"heart":2},"created_at":"2026-03-01T00:00:00Z","updated_at":"2026-03-04T00:00:00Z"},
+				{"number":3,"title":"Fix build","state":"open","html_url":"https://github.com/acme/app/pull/3","pull_request":{},
+				 "created_at":"2026-03-01T00:00:00Z","updated_at":"2026-03-06T00:00:00Z"}]`))
+		case r.URL.Path == "/repos/acme/app/issues/comments":
+			_, _ = w.Write([]byte(`[
+				{"id":11,"body":"+1, dark mode would help at night","html_url":"https://github.com/acme/app/issues/1#issuecomment-11",
+				 "issue_url":"https://api.github.com/repos/acme/app/issues/1","user":{"login":"kim"},
+				 "reactions":{"total_count":1,"rocket":1},"created_at":"2026-03-03T00:00:00Z","updated_at":"2026-03-07T00:00:00Z"},
+				{"id":12,"body":"  ","issue_url":"https://api.github.com/repos/acme/app/issues/1","created_at":"2026-03-03T00:00:00Z","updated_at":"2026-03-03T00:00:00Z"}]`))
+		case r.URL.Path == "/repos/acme/busy/issues":
+			// Every page links to another, so imports stop at the page limit.
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			page = max(page, 1)
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/acme/busy/issues?page=%d>; rel="next"`, server.URL, page+1))
+			_, _ = fmt.Fprintf(w, `[{"number":%d,"title":"Issue %d","updated_at":%q}]`, page, page, time.Date(2026, 3, 1, page, 0, 0, 0, time.UTC).Format(time.RFC3339))
+		case r.URL.Path == "/repos/acme/busy/issues/comments":
+			_, _ = w.Write([]byte(`[{"id":21,"body":"Still slow","issue_url":"https://api.github.com/repos/acme/busy/issues/1",
+				"created_at":"2026-04-01T00:00:00Z","updated_at":"2026-04-01T00:00:00Z"}]`))
+		case r.URL.Path == "/repos/acme/app/issues" && r.Method == http.MethodPost:
+			var body map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			fake.mu.Lock()
+			release, entered := fake.release, fake.entered
+			fake.mu.Unlock()
+			if entered != nil {
+				close(entered)
+			}
+			if release != nil {
+				<-release
+			}
+			fake.mu.Lock()
+			fake.created = append(fake.created, body)
+			number := 40 + len(fake.created)
+			fake.mu.Unlock()
+			w.WriteHeader(http.StatusCreated)
+			_, _ = fmt.Fprintf(w, `{"number":%d,"html_url":"https://github.com/acme/app/issues/%d"}`, number, number)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	prev := githubAPIURL
+	githubAPIURL = server.URL
+	t.Cleanup(func() { githubAPIURL = prev })
+	return fake
+}
+
+func (f *fakeGitHub) createdCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.created)
+}
+
+func connectTestGitHub(t *testing.T) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handleGitHubConnect(rec, httptest.NewRequest(http.MethodPost, "/api/integrations/github/connect", strings.NewReader(`{"token":"gh-token","repos":["acme/app"]}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("connect: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+// upstreamRunDetail serves one upstream run with the given status and
+// decision object.
+func upstreamRunDetail(runID string, status string, decision map[string]any) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/decision-runs/"+runID {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id":     runID,
+			"status": status,
+			"artifacts": []any{
+				map[string]any{"id": "a1", "type": artifactTypeDecisionObject, "json": decision},
+			},
+		})
+	}
+}
+
+func postTrackingIssue(runID string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handleOperatorDecisionRunByID(rec, httptest.NewRequest(http.MethodPost, "/api/operator/decision-runs/"+runID+"/github-issue", strings.NewReader(`{}`)))
+	return rec
+}
+
+func TestGitHubConnectAndImport(t *testing.T) {
+	useTestIntegrations(t)
+	useFakeGitHub(t)
+
+	connectTestGitHub(t)
+	conn, _ := integrationStoreInstance.GetGitHubConnection()
+	if conn.Login != "octo" || strings.Contains(conn.EncryptedToken, "gh-token") {
+		t.Fatalf("connection = %+v", conn)
+	}
+
+	rec := httptest.NewRecorder()
+	handleGitHubImport(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	var resp githubImportResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if rec.Code != http.StatusOK || resp.ImportedIssues != 2 || resp.ImportedComments != 1 || len(resp.Errors) != 0 {
+		t.Fatalf("import: %d %+v", rec.Code, resp)
+	}
+
+	signals := map[string]signalRecord{}
+	for _, signal := range integrationStoreInstance.ListSignals("", 100) {
+		signals[signal.ID] = signal
+	}
+	issue := signals["github:acme/app:issue:1"]
+	if issue.Meta["reactions"] != "7" || issue.Meta["reactionsThumbsUp"] != "5" || issue.Meta["reactionsHeart"] != "2" || issue.Meta["labels"] != "enhancement" {
+		t.Fatalf("issue signal meta = %v", issue.Meta)
+	}
+	if comment := signals["github:acme/app:comment:11"]; comment.Meta["number"] != "1" || comment.Meta["reactionsRocket"] != "1" {
+		t.Fatalf("comment signal meta = %v", comment.Meta)
+	}
+	if _, ok := signals["github:acme/app:issue:3"]; ok {
+		t.Fatal("pull requests must not be imported")
+	}
+	conn, _ = integrationStoreInstance.GetGitHubConnection()
+	if want := time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC); !conn.ImportedThrough["acme/app"].Equal(want) {
+		t.Fatalf("imported through %v, want %v", conn.ImportedThrough["acme/app"], want)
+	}
+}
+
+func TestGitHubConnectErrorStatus(t *testing.T) {
+	useTestIntegrations(t)
+	useFakeGitHub(t)
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"rejected token", `{"token":"wrong","repos":["acme/app"]}`, http.StatusBadRequest},
+		{"missing repo", `{"token":"gh-token","repos":["acme/missing"]}`, http.StatusBadRequest},
+		{"github unavailable", `{"token":"gh-token","repos":["acme/down"]}`, http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handleGitHubConnect(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body)))
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d: %s", tt.name, rec.Code, tt.want, rec.Body)
+		}
+		if tt.name == "rejected token" && !strings.Contains(rec.Body.String(), "Bad credentials") {
+			t.Errorf("rejected token: body = %s, want GitHub's message", rec.Body)
+		}
+	}
+	if _, ok := integrationStoreInstance.GetGitHubConnection(); ok {
+		t.Fatal("a failed connect stored a connection")
+	}
+
+	// An unreachable API is a bad gateway too.
+	githubAPIURL = "http://127.0.0.1:1"
+	rec := httptest.NewRecorder()
+	handleGitHubConnect(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"token":"gh-token","repos":["acme/app"]}`)))
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("unreachable: status = %d, want 502: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestGitHubImportHoldsCursorAtPageLimit(t *testing.T) {
+	useTestIntegrations(t)
+	useFakeGitHub(t)
+	connectTestGitHub(t)
+
+	rec := httptest.NewRecorder()
+	handleGitHubImport(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"repos":["acme/busy"]}`)))
+	var resp githubImportResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if rec.Code != http.StatusOK || resp.ImportedIssues != maxGitHubImportPages || resp.ImportedComments != 1 {
+		t.Fatalf("import: %d %+v", rec.Code, resp)
+	}
+	// The comment is newer, but issues past the page limit are still unread,
+	// so the cursor stays at the last issue fetched.
+	conn, _ := integrationStoreInstance.GetGitHubConnection()
+	if want := time.Date(2026, 3, 1, maxGitHubImportPages, 0, 0, 0, time.UTC); !conn.ImportedThrough["acme/busy"].Equal(want) {
+		t.Fatalf("imported through %v, want %v", conn.ImportedThrough["acme/busy"], want)
+	}
+}
+
+func TestGitHubTrackingIssueForUpstreamRunIsRecorded(t *testing.T) {
+	useTestIntegrations(t)
+	github := useFakeGitHub(t)
+	connectTestGitHub(t)
+	useFakeOperator(t, upstreamRunDetail("up_run", "completed", referenceDecision(testDecisionInput())))
+
+	rec := postTrackingIssue("up_run")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("first request: %d %s", rec.Code, rec.Body.String())
+	}
+	var issue githubIssueArtifact
+	_ = json.Unmarshal(rec.Body.Bytes(), &issue)
+	if issue.Repo != "acme/app" || issue.Number != 41 {
+		t.Fatalf("issue = %+v", issue)
+	}
+	if title := github.created[0]["title"]; title != "[High] Dark mode" {
+		t.Errorf("title = %v", title)
+	}
+
+	rec = postTrackingIssue("up_run")
+	if rec.Code != http.StatusOK || github.createdCount() != 1 {
+		t.Fatalf("repeat request: %d, %d issues created", rec.Code, github.createdCount())
+	}
+	if recorded, ok := integrationStoreInstance.GetGitHubTrackingIssue("up_run"); !ok || recorded != issue {
+		t.Fatalf("issue not recorded for the upstream run: %+v", recorded)
+	}
+}
+
+func TestGitHubTrackingIssueConcurrentRequests(t *testing.T) {
+	useTestIntegrations(t)
+	github := useFakeGitHub(t)
+	connectTestGitHub(t)
+	useFakeOperator(t, upstreamRunDetail("up_run", "completed", referenceDecision(testDecisionInput())))
+
+	github.mu.Lock()
+	github.release, github.entered = make(chan struct{}), make(chan struct{})
+	entered := github.entered
+	github.mu.Unlock()
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() { first <- postTrackingIssue("up_run") }()
+	<-entered
+
+	if rec := postTrackingIssue("up_run"); rec.Code != http.StatusConflict {
+		t.Fatalf("concurrent request: %d %s", rec.Code, rec.Body.String())
+	}
+	close(github.release)
+	if rec := <-first; rec.Code != http.StatusCreated {
+		t.Fatalf("first request: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := postTrackingIssue("up_run"); rec.Code != http.StatusOK {
+		t.Fatalf("later request: %d", rec.Code)
+	}
+	if got := github.createdCount(); got != 1 {
+		t.Fatalf("created %d issues, want 1", got)
+	}
+}
+
+func TestGitHubTrackingIssueValidatesUpstreamDetail(t *testing.T) {
+	useTestIntegrations(t)
+	github := useFakeGitHub(t)
+	connectTestGitHub(t)
+
+	extended := referenceDecision(testDecisionInput())
+	extended["owner"] = "added upstream"
+	invalid := referenceDecision(testDecisionInput())
+	invalid["recommendation"].(map[string]any)["priority"] = "Urgent"
+
+	tests := []struct {
+		name       string
+		status     string
+		decision   map[string]any
+		wantStatus int
+		wantError  string
+	}{
+		{"extra fields are tolerated", "completed", extended, http.StatusCreated, ""},
+		{"invalid decision", "completed", invalid, http.StatusConflict, "Artifact validation failed"},
+		{"still running", "running", extended, http.StatusConflict, "status is running"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runID := strings.ReplaceAll(tt.name, " ", "_")
+			useFakeOperator(t, upstreamRunDetail(runID, tt.status, tt.decision))
+			before := github.createdCount()
+			rec := postTrackingIssue(runID)
+			if rec.Code != tt.wantStatus || !strings.Contains(rec.Body.String(), tt.wantError) {
+				t.Fatalf("got %d %s, want %d containing %q", rec.Code, rec.Body.String(), tt.wantStatus, tt.wantError)
+			}
+			if tt.wantStatus != http.StatusCreated && github.createdCount() != before {
+				t.Fatal("an issue was created for a rejected run")
+			}
+		})
+	}
+
+	useFakeOperator(t, upstreamRunDetail("other", "completed", extended))
+	if rec := postTrackingIssue("unknown"); rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown run: %d", rec.Code)
+	}
+}
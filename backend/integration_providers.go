@@ -0,0 +1,412 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// IntegrationProvider is a source or sink that can be connected from the
+// Integrations page. Each handler is mounted under
+// /api/integrations/{name}/; providers without a use for a route answer it
+// with writeIntegrationUnsupported.
+type IntegrationProvider interface {
+	Name() string
+	Summary() integrationSummary
+	// ConnectURL returns the URL that starts an OAuth install.
+	ConnectURL(w http.ResponseWriter, r *http.Request)
+	Callback(w http.ResponseWriter, r *http.Request)
+	Disconnect(w http.ResponseWriter, r *http.Request)
+	// Sources lists (GET) or selects (PUT) the channels, repos or similar
+	// that signals are read from.
+	Sources(w http.ResponseWriter, r *http.Request)
+	Import(w http.ResponseWriter, r *http.Request)
+	Webhook(w http.ResponseWriter, r *http.Request)
+}
+
+// integrationExtraRoutes is implemented by providers that serve routes beyond
+// the standard set, such as Slack's setup wizard. Keys are paths relative to
+// the provider prefix.
+type integrationExtraRoutes interface {
+	ExtraRoutes() map[string]http.HandlerFunc
+}
+
+type integrationRegistry struct {
+	providers []IntegrationProvider
+}
+
+var integrationProviders = newIntegrationRegistry(
+	slackIntegration{},
+	githubIntegration{},
+	discordIntegration{},
+	teamsIntegration{},
+)
+
+func newIntegrationRegistry(providers ...IntegrationProvider) *integrationRegistry {
+	return &integrationRegistry{providers: providers}
+}
+
+func (reg *integrationRegistry) Summaries() []integrationSummary {
+	summaries := make([]integrationSummary, 0, len(reg.providers))
+	for _, provider := range reg.providers {
+		summaries = append(summaries, provider.Summary())
+	}
+	return summaries
+}
+
+// Mount registers every provider's routes on mux.
+func (reg *integrationRegistry) Mount(mux *http.ServeMux) {
+	for _, provider := range reg.providers {
+		prefix := "/api/integrations/" + provider.Name() + "/"
+		mux.HandleFunc(prefix+"connect-url", provider.ConnectURL)
+		mux.HandleFunc(prefix+"callback", provider.Callback)
+		mux.HandleFunc(prefix+"disconnect", provider.Disconnect)
+		mux.HandleFunc(prefix+"sources", provider.Sources)
+		mux.HandleFunc(prefix+"import", provider.Import)
+		mux.HandleFunc(prefix+"webhook", provider.Webhook)
+		if extra, ok := provider.(integrationExtraRoutes); ok {
+			for path, handler := range extra.ExtraRoutes() {
+				mux.HandleFunc(prefix+path, handler)
+			}
+		}
+	}
+}
+
+func writeIntegrationUnsupported(w http.ResponseWriter, provider string, route string) {
+	writeJSON(w, http.StatusNotFound, errorResponse{Error: fmt.Sprintf("%s does not support %s", provider, route)})
+}
+
+type integrationSetupValidateResponse struct {
+	ReadyForConnect bool     `json:"readyForConnect"`
+	MissingFields   []string `json:"missingFields"`
+	Message         string   `json:"message"`
+}
+
+// integrationSetupWizard serves the setup and setup/validate routes of a
+// provider configured like Slack: GET shows the stored setup, POST saves
+// it, and validate reports the fields still missing.
+type integrationSetupWizard[Req any, View any] struct {
+	label        string
+	readyMessage string
+	// view builds the setup shown by GET and returned after a save.
+	view func(r *http.Request) (View, error)
+	// save stores req. It writes its own error response and returns false
+	// when the setup is rejected.
+	save    func(w http.ResponseWriter, r *http.Request, req Req) bool
+	missing func() ([]string, error)
+}
+
+func (wiz integrationSetupWizard[Req, View]) Setup(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		wiz.writeView(w, r)
+	case http.MethodPost:
+		if integrationTokenCipher == nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: "integration encryption is not configured"})
+			return
+		}
+		var req Req
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid json"})
+			return
+		}
+		if !wiz.save(w, r, req) {
+			return
+		}
+		wiz.writeView(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (wiz integrationSetupWizard[Req, View]) writeView(w http.ResponseWriter, r *http.Request) {
+	resp, err := wiz.view(r)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to read " + wiz.label + " setup"})
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (wiz integrationSetupWizard[Req, View]) Validate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	missing, err := wiz.missing()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to resolve " + wiz.label + " setup"})
+		return
+	}
+	message := wiz.readyMessage
+	if len(missing) > 0 {
+		message = wiz.label + " setup is incomplete. Fill missing fields in the setup form."
+	}
+	writeJSON(w, http.StatusOK, integrationSetupValidateResponse{
+		ReadyForConnect: len(missing) == 0,
+		MissingFields:   missing,
+		Message:         message,
+	})
+}
+
+// integrationAuthorizeRequest is where a provider's install or consent flow
+// starts. serveIntegrationConnectURL adds the OAuth state to Params.
+type integrationAuthorizeRequest struct {
+	URL     string
+	Params  url.Values
+	Missing []string
+}
+
+func serveIntegrationConnectURL(w http.ResponseWriter, r *http.Request, provider string, label string, authorize func(r *http.Request) (integrationAuthorizeRequest, error)) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	req, err := authorize(r)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to resolve " + label + " setup"})
+		return
+	}
+	missing := req.Missing
+	if integrationTokenCipher == nil {
+		missing = append(missing, "integrationEncryption")
+	}
+	if len(missing) > 0 {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "missing required " + label + " setup fields: " + strings.Join(missing, ", ")})
+		return
+	}
+
+	state, err := integrationStoreInstance.CreateOAuthState(provider, oauthStateTTL)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "unable to create oauth state"})
+		return
+	}
+	req.Params.Set("state", state)
+	writeJSON(w, http.StatusOK, connectURLResponse{URL: req.URL + "?" + req.Params.Encode()})
+}
+
+// checkIntegrationCallback does the checks every OAuth callback shares: the
+// setup resolved, the user did not deny access, and the state is one this
+// server issued. On failure it redirects back to the app and returns false.
+func checkIntegrationCallback(w http.ResponseWriter, r *http.Request, provider string, label string, appBaseURL string, cfgErr error) bool {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	if cfgErr != nil {
+		redirectIntegrationResult(w, r, appBaseURL, provider, "error", label+" setup is invalid")
+		return false
+	}
+	query := r.URL.Query()
+	if denied := strings.TrimSpace(query.Get("error")); denied != "" {
+		redirectIntegrationResult(w, r, appBaseURL, provider, "error", label+" access was denied: "+denied)
+		return false
+	}
+	state := strings.TrimSpace(query.Get("state"))
+	if state == "" {
+		redirectIntegrationResult(w, r, appBaseURL, provider, "error", "Missing OAuth state")
+		return false
+	}
+	if !integrationStoreInstance.ConsumeOAuthState(state, provider) {
+		redirectIntegrationResult(w, r, appBaseURL, provider, "error", "Invalid or expired OAuth state")
+		return false
+	}
+	return true
+}
+
+// serveIntegrationDisconnect forgets provider's connection. release undoes
+// the install on the provider's side first; its failures are only logged.
+func serveIntegrationDisconnect(w http.ResponseWriter, r *http.Request, provider string, label string, release func(ctx context.Context)) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	release(r.Context())
+	if err := integrationStoreInstance.DisconnectIntegration(provider); err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to disconnect " + label})
+		return
+	}
+	writeJSON(w, http.StatusOK, okResponse{Status: "ok"})
+}
+
+// integrationChannel is a chat channel that can be selected as a source.
+// Type is the provider's channel kind; Subscribed is set by providers that
+// also receive the channel's new messages.
+type integrationChannel struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Type       string `json:"type,omitempty"`
+	Selected   bool   `json:"selected"`
+	Subscribed bool   `json:"subscribed,omitempty"`
+}
+
+type integrationChannelsRequest struct {
+	ChannelIDs []string `json:"channelIds"`
+}
+
+type integrationChannelsResponse struct {
+	Channels      []integrationChannel `json:"channels"`
+	Total         int                  `json:"total"`
+	SelectedCount int                  `json:"selectedCount"`
+}
+
+type integrationChannelImportResponse struct {
+	Status          string   `json:"status"`
+	TotalChannels   int      `json:"totalChannels"`
+	ImportedSignals int      `json:"importedSignals"`
+	Errors          []string `json:"errors,omitempty"`
+}
+
+// channelSession is a connected chat workspace as the shared channels and
+// channels/import routes see it.
+type channelSession interface {
+	Channels(ctx context.Context) ([]integrationChannel, error)
+	// History reads a channel's recent messages as signals. Signals read
+	// before an error are returned with it.
+	History(ctx context.Context, channelID string, channelName string) ([]signalRecord, error)
+}
+
+// channelSelectionSyncer is implemented by sessions that act on a new
+// selection, such as Teams subscribing to the selected channels. The
+// returned strings are reported to the caller as errors.
+type channelSelectionSyncer interface {
+	SyncSelection(ctx context.Context, channels []integrationChannel) []string
+}
+
+// serveIntegrationChannels lists a provider's channels (GET) or replaces its
+// selection (PUT). IDs that are blank, repeated or not in the workspace are
+// dropped from the selection.
+func serveIntegrationChannels(w http.ResponseWriter, r *http.Request, provider string, open func(ctx context.Context) (channelSession, error)) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	session, err := open(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	var req integrationChannelsRequest
+	if r.Method == http.MethodPut {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid json"})
+			return
+		}
+	}
+	channels, err := session.Channels(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusBadGateway, errorResponse{Error: err.Error()})
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		selected := make([]string, 0, len(req.ChannelIDs))
+		for _, rawID := range req.ChannelIDs {
+			id := strings.TrimSpace(rawID)
+			known := slices.ContainsFunc(channels, func(ch integrationChannel) bool { return ch.ID == id })
+			if id == "" || !known || slices.Contains(selected, id) {
+				continue
+			}
+			selected = append(selected, id)
+		}
+		if err := integrationStoreInstance.SetSelectedSources(provider, selected); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to save selected channels"})
+			return
+		}
+		resp := map[string]any{
+			"status":        "ok",
+			"selectedCount": len(selected),
+			"channelIds":    selected,
+		}
+		if syncer, ok := session.(channelSelectionSyncer); ok {
+			resp["errors"] = syncer.SyncSelection(r.Context(), channels)
+		}
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	selectedIDs := integrationStoreInstance.GetSelectedSources(provider)
+	selectedCount := 0
+	for idx := range channels {
+		if slices.Contains(selectedIDs, channels[idx].ID) {
+			channels[idx].Selected = true
+			selectedCount++
+		}
+	}
+	writeJSON(w, http.StatusOK, integrationChannelsResponse{
+		Channels:      channels,
+		Total:         len(channels),
+		SelectedCount: selectedCount,
+	})
+}
+
+// serveIntegrationChannelsImport stores the history of the requested
+// channels, or of the selected ones when the body names none. A channel that
+// fails is reported in errors and does not stop the others.
+func serveIntegrationChannelsImport(w http.ResponseWriter, r *http.Request, provider string, open func(ctx context.Context) (channelSession, error)) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	session, err := open(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	var req integrationChannelsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid json"})
+		return
+	}
+	selected := req.ChannelIDs
+	if len(selected) == 0 {
+		selected = integrationStoreInstance.GetSelectedSources(provider)
+	}
+	if len(selected) == 0 {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "no channels selected"})
+		return
+	}
+
+	errorsOut := make([]string, 0)
+	channelNameByID := map[string]string{}
+	if channels, err := session.Channels(r.Context()); err != nil {
+		errorsOut = append(errorsOut, "channel metadata refresh failed: "+err.Error())
+	} else {
+		for _, ch := range channels {
+			channelNameByID[ch.ID] = ch.Name
+		}
+	}
+
+	importedSignals := 0
+	for _, channelID := range selected {
+		channelID = strings.TrimSpace(channelID)
+		if channelID == "" {
+			continue
+		}
+		signals, historyErr := session.History(r.Context(), channelID, firstNonEmpty(channelNameByID[channelID], channelID))
+		if historyErr != nil {
+			errorsOut = append(errorsOut, fmt.Sprintf("%s: %v", channelID, historyErr))
+		}
+		for _, signal := range signals {
+			signal.Meta["imported"] = "true"
+			if err := integrationStoreInstance.AddSignal(signal); err != nil {
+				errorsOut = append(errorsOut, fmt.Sprintf("%s: failed to store signal %s", channelID, signal.ID))
+				continue
+			}
+			importedSignals++
+		}
+	}
+
+	writeJSON(w, http.StatusOK, integrationChannelImportResponse{
+		Status:          "ok",
+		TotalChannels:   len(selected),
+		ImportedSignals: importedSignals,
+		Errors:          errorsOut,
+	})
+}
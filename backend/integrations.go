@@ -14,6 +14,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"maps"
 	"net/http"
 	"net/url"
 	"os"
@@ -32,6 +33,8 @@ const (
 	maxRawSlackEventsInStore       = 1000
 	processedSlackEventRetention   = 72 * time.Hour
 	maxSlackWebhookTimestampSkew   = 5 * time.Minute
+	maxRawEventsInStore            = 1000
+	processedEventRetention        = 72 * time.Hour
 	defaultSlackConnectionDetail   = "Connect for real-time alerts"
 	defaultSlackDisconnectedStatus = "Disconnected"
 	defaultSlackBotScopes          = "app_mentions:read,channels:history,channels:read,chat:write,groups:history,groups:read,im:history,im:read,mpim:history,mpim:read,reactions:read,team:read,users:read,users:read.email,files:read"
@@ -163,6 +166,18 @@ type rawSlackEventRecord struct {
 	Error      string          `json:"error,omitempty"`
 }
 
+// rawIntegrationEventRecord is a webhook delivery from a provider other than
+// Slack, kept with its dedupe key so redeliveries are dropped.
+type rawIntegrationEventRecord struct {
+	Provider   string          `json:"provider"`
+	EventID    string          `json:"eventId"`
+	EventType  string          `json:"eventType"`
+	Payload    json.RawMessage `json:"payload"`
+	ReceivedAt time.Time       `json:"receivedAt"`
+	Status     string          `json:"status"`
+	Error      string          `json:"error,omitempty"`
+}
+
 type signalRecord struct {
 	ID         string            `json:"id"`
 	Source     string            `json:"source"`
@@ -182,13 +197,24 @@ type supabaseSignalRow struct {
 }
 
 type integrationStoreData struct {
-	SlackConnection       *slackConnectionRecord      `json:"slackConnection,omitempty"`
-	SlackSetup            *slackSetupPersisted        `json:"slackSetup,omitempty"`
-	SelectedSlackChannels []string                    `json:"selectedSlackChannels,omitempty"`
-	OAuthStates           map[string]oauthStateRecord `json:"oauthStates"`
-	ProcessedSlackEvent   map[string]time.Time        `json:"processedSlackEvent"`
-	RawSlackEvents        []rawSlackEventRecord       `json:"rawSlackEvents"`
-	Signals               []signalRecord              `json:"signals"`
+	SlackConnection        *slackConnectionRecord         `json:"slackConnection,omitempty"`
+	SlackSetup             *slackSetupPersisted           `json:"slackSetup,omitempty"`
+	SelectedSlackChannels  []string                       `json:"selectedSlackChannels,omitempty"`
+	JiraConnection         *jiraConnectionRecord          `json:"jiraConnection,omitempty"`
+	LinearConnection       *linearConnectionRecord        `json:"linearConnection,omitempty"`
+	GitHubConnection       *githubConnectionRecord        `json:"githubConnection,omitempty"`
+	GitHubTrackingIssues   map[string]githubIssueArtifact `json:"githubTrackingIssues,omitempty"`
+	IntegrationSetups      map[string]json.RawMessage     `json:"integrationSetups,omitempty"`
+	IntegrationConnections map[string]json.RawMessage     `json:"integrationConnections,omitempty"`
+	SelectedSources        map[string][]string            `json:"selectedSources,omitempty"`
+	TeamsSetup             *teamsSetupPersisted           `json:"teamsSetup,omitempty"`
+	TeamsConnection        *teamsConnectionRecord         `json:"teamsConnection,omitempty"`
+	OAuthStates            map[string]oauthStateRecord    `json:"oauthStates"`
+	ProcessedSlackEvent    map[string]time.Time           `json:"processedSlackEvent"`
+	RawSlackEvents         []rawSlackEventRecord          `json:"rawSlackEvents"`
+	ProcessedEvents        map[string]time.Time           `json:"processedEvents"`
+	RawEvents              []rawIntegrationEventRecord    `json:"rawEvents"`
+	Signals                []signalRecord                 `json:"signals"`
 }
 
 type slackRuntimeConfig struct {
@@ -317,6 +343,8 @@ func newIntegrationStore(path string) (*integrationStore, error) {
 			OAuthStates:         map[string]oauthStateRecord{},
 			ProcessedSlackEvent: map[string]time.Time{},
 			RawSlackEvents:      []rawSlackEventRecord{},
+			ProcessedEvents:     map[string]time.Time{},
+			RawEvents:           []rawIntegrationEventRecord{},
 			Signals:             []signalRecord{},
 		},
 	}
@@ -358,6 +386,12 @@ func newIntegrationStore(path string) (*integrationStore, error) {
 	if s.data.RawSlackEvents == nil {
 		s.data.RawSlackEvents = []rawSlackEventRecord{}
 	}
+	if s.data.ProcessedEvents == nil {
+		s.data.ProcessedEvents = map[string]time.Time{}
+	}
+	if s.data.RawEvents == nil {
+		s.data.RawEvents = []rawIntegrationEventRecord{}
+	}
 	if s.data.Signals == nil {
 		s.data.Signals = []signalRecord{}
 	}
@@ -396,6 +430,14 @@ func (s *integrationStore) cleanupLocked(now time.Time) {
 	if len(s.data.RawSlackEvents) > maxRawSlackEventsInStore {
 		s.data.RawSlackEvents = append([]rawSlackEventRecord{}, s.data.RawSlackEvents[len(s.data.RawSlackEvents)-maxRawSlackEventsInStore:]...)
 	}
+	for key, processedAt := range s.data.ProcessedEvents {
+		if now.Sub(processedAt) > processedEventRetention {
+			delete(s.data.ProcessedEvents, key)
+		}
+	}
+	if len(s.data.RawEvents) > maxRawEventsInStore {
+		s.data.RawEvents = append([]rawIntegrationEventRecord{}, s.data.RawEvents[len(s.data.RawEvents)-maxRawEventsInStore:]...)
+	}
 	if len(s.data.Signals) > maxSignalsInStore {
 		s.data.Signals = append([]signalRecord{}, s.data.Signals[len(s.data.Signals)-maxSignalsInStore:]...)
 	}
@@ -487,6 +529,240 @@ func (s *integrationStore) DisconnectSlack() error {
 	return s.persistLocked()
 }
 
+func (s *integrationStore) UpsertJiraConnection(connection jiraConnectionRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.JiraConnection = &connection
+	s.cleanupLocked(time.Now().UTC())
+	return s.persistLocked()
+}
+
+func (s *integrationStore) GetJiraConnection() (jiraConnectionRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data.JiraConnection == nil {
+		return jiraConnectionRecord{}, false
+	}
+	return *s.data.JiraConnection, true
+}
+
+func (s *integrationStore) DisconnectJira() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.JiraConnection = nil
+	s.cleanupLocked(time.Now().UTC())
+	return s.persistLocked()
+}
+
+func (s *integrationStore) UpsertLinearConnection(connection linearConnectionRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.LinearConnection = &connection
+	s.cleanupLocked(time.Now().UTC())
+	return s.persistLocked()
+}
+
+func (s *integrationStore) GetLinearConnection() (linearConnectionRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data.LinearConnection == nil {
+		return linearConnectionRecord{}, false
+	}
+	return *s.data.LinearConnection, true
+}
+
+func (s *integrationStore) DisconnectLinear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.LinearConnection = nil
+	s.cleanupLocked(time.Now().UTC())
+	return s.persistLocked()
+}
+
+func (s *integrationStore) UpsertGitHubConnection(connection githubConnectionRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.GitHubConnection = &connection
+	s.cleanupLocked(time.Now().UTC())
+	return s.persistLocked()
+}
+
+func (s *integrationStore) GetGitHubConnection() (githubConnectionRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data.GitHubConnection == nil {
+		return githubConnectionRecord{}, false
+	}
+	conn := *s.data.GitHubConnection
+	conn.Repos = append([]string(nil), conn.Repos...)
+	conn.ImportedThrough = maps.Clone(conn.ImportedThrough)
+	return conn, true
+}
+
+func (s *integrationStore) SetGitHubRepos(repos []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data.GitHubConnection == nil {
+		return errors.New("github is not connected")
+	}
+	s.data.GitHubConnection.Repos = append([]string(nil), repos...)
+	s.data.GitHubConnection.UpdatedAt = time.Now().UTC()
+	s.cleanupLocked(time.Now().UTC())
+	return s.persistLocked()
+}
+
+func (s *integrationStore) SetGitHubImportedThrough(repo string, through time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data.GitHubConnection == nil {
+		return errors.New("github is not connected")
+	}
+	if s.data.GitHubConnection.ImportedThrough == nil {
+		s.data.GitHubConnection.ImportedThrough = map[string]time.Time{}
+	}
+	s.data.GitHubConnection.ImportedThrough[repo] = through.UTC()
+	s.cleanupLocked(time.Now().UTC())
+	return s.persistLocked()
+}
+
+func (s *integrationStore) DisconnectGitHub() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.GitHubConnection = nil
+	s.cleanupLocked(time.Now().UTC())
+	return s.persistLocked()
+}
+
+// GetGitHubTrackingIssue returns the tracking issue opened for a decision
+// run. Records outlive the GitHub connection, since the issues do too.
+func (s *integrationStore) GetGitHubTrackingIssue(runID string) (githubIssueArtifact, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	issue, ok := s.data.GitHubTrackingIssues[runID]
+	return issue, ok
+}
+
+func (s *integrationStore) SaveGitHubTrackingIssue(runID string, issue githubIssueArtifact) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data.GitHubTrackingIssues == nil {
+		s.data.GitHubTrackingIssues = map[string]githubIssueArtifact{}
+	}
+	s.data.GitHubTrackingIssues[runID] = issue
+	s.cleanupLocked(time.Now().UTC())
+	return s.persistLocked()
+}
+
+// The setup and connection records of providers added after Slack, Jira and
+// Linear are stored as JSON keyed by provider name. Methods cannot take type
+// parameters, so their typed accessors are functions.
+
+func getIntegrationSetup[T any](s *integrationStore, provider string) (T, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return decodeIntegrationRecord[T](s.data.IntegrationSetups, provider)
+}
+
+func saveIntegrationSetup[T any](s *integrationStore, provider string, setup T) error {
+	encoded, err := json.Marshal(setup)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data.IntegrationSetups == nil {
+		s.data.IntegrationSetups = map[string]json.RawMessage{}
+	}
+	s.data.IntegrationSetups[provider] = encoded
+	s.cleanupLocked(time.Now().UTC())
+	return s.persistLocked()
+}
+
+func getIntegrationConnection[T any](s *integrationStore, provider string) (T, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return decodeIntegrationRecord[T](s.data.IntegrationConnections, provider)
+}
+
+func saveIntegrationConnection[T any](s *integrationStore, provider string, connection T) error {
+	encoded, err := json.Marshal(connection)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data.IntegrationConnections == nil {
+		s.data.IntegrationConnections = map[string]json.RawMessage{}
+	}
+	s.data.IntegrationConnections[provider] = encoded
+	s.cleanupLocked(time.Now().UTC())
+	return s.persistLocked()
+}
+
+// updateIntegrationConnection applies update to the stored connection under
+// the store lock. It fails after a disconnect, so a late write cannot bring
+// the connection back.
+func updateIntegrationConnection[T any](s *integrationStore, provider string, update func(*T)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	connection, ok := decodeIntegrationRecord[T](s.data.IntegrationConnections, provider)
+	if !ok {
+		return fmt.Errorf("%s is not connected", provider)
+	}
+	update(&connection)
+	encoded, err := json.Marshal(connection)
+	if err != nil {
+		return err
+	}
+	s.data.IntegrationConnections[provider] = encoded
+	s.cleanupLocked(time.Now().UTC())
+	return s.persistLocked()
+}
+
+func decodeIntegrationRecord[T any](records map[string]json.RawMessage, provider string) (T, bool) {
+	var record T
+	raw, ok := records[provider]
+	if !ok {
+		return record, false
+	}
+	if err := json.Unmarshal(raw, &record); err != nil {
+		log.Printf("ignoring unreadable %s integration record: %v", provider, err)
+		return record, false
+	}
+	return record, true
+}
+
+// DisconnectIntegration forgets provider's connection and selected sources.
+// The setup is kept so the provider can be connected again.
+func (s *integrationStore) DisconnectIntegration(provider string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data.IntegrationConnections, provider)
+	delete(s.data.SelectedSources, provider)
+	s.cleanupLocked(time.Now().UTC())
+	return s.persistLocked()
+}
+
+func (s *integrationStore) GetSelectedSources(provider string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.data.SelectedSources[provider]...)
+}
+
+func (s *integrationStore) SetSelectedSources(provider string, sourceIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data.SelectedSources == nil {
+		s.data.SelectedSources = map[string][]string{}
+	}
+	s.data.SelectedSources[provider] = append([]string{}, sourceIDs...)
+	s.cleanupLocked(time.Now().UTC())
+	return s.persistLocked()
+}
+
 func (s *integrationStore) GetSelectedSlackChannels() []string {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -541,6 +817,60 @@ func (s *integrationStore) UpdateSlackEventStatus(eventID string, status string,
 	_ = s.persistLocked()
 }
 
+// RecordEvent is RecordSlackEvent for other providers. It returns false when
+// provider already delivered eventID.
+func (s *integrationStore) RecordEvent(provider string, eventID string, eventType string, payload []byte) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	s.cleanupLocked(now)
+	key := provider + ":" + eventID
+	if _, exists := s.data.ProcessedEvents[key]; exists {
+		return false, nil
+	}
+	s.data.ProcessedEvents[key] = now
+	s.data.RawEvents = append(s.data.RawEvents, rawIntegrationEventRecord{
+		Provider:   provider,
+		EventID:    eventID,
+		EventType:  eventType,
+		Payload:    append([]byte(nil), payload...),
+		ReceivedAt: now,
+		Status:     "pending",
+	})
+	s.cleanupLocked(now)
+	if err := s.persistLocked(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *integrationStore) UpdateEventStatus(provider string, eventID string, status string, eventErr string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := len(s.data.RawEvents) - 1; i >= 0; i-- {
+		if s.data.RawEvents[i].Provider == provider && s.data.RawEvents[i].EventID == eventID {
+			s.data.RawEvents[i].Status = status
+			s.data.RawEvents[i].Error = eventErr
+			break
+		}
+	}
+	_ = s.persistLocked()
+}
+
+func (s *integrationStore) EventCount(provider string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := 0
+	for _, event := range s.data.RawEvents {
+		if event.Provider == provider {
+			count++
+		}
+	}
+	return count
+}
+
 func (s *integrationStore) SlackEventCount() int {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -600,6 +930,33 @@ func (s *integrationStore) ListSignals(source string, limit int) []signalRecord
 	return items
 }
 
+func (s *integrationStore) SearchSignals(query string, limit int) []signalRecord {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return []signalRecord{}
+	}
+
+	candidates := s.ListSignals("", maxSignalsInStore)
+	matches := make([]signalRecord, 0, len(candidates))
+	for _, candidate := range candidates {
+		text := strings.ToLower(candidate.Title + " " + candidate.Summary)
+		matched := true
+		for _, term := range terms {
+			if !strings.Contains(text, term) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			matches = append(matches, candidate)
+		}
+	}
+	if limit > 0 && len(matches) > limit {
+		matches = matches[len(matches)-limit:]
+	}
+	return matches
+}
+
 func newSupabaseSignalStore(projectURL string, serviceRoleKey string, table string) (*supabaseSignalStore, error) {
 	projectURL = strings.TrimSpace(projectURL)
 	serviceRoleKey = strings.TrimSpace(serviceRoleKey)
@@ -823,6 +1180,17 @@ func inferredAppUIBaseURL(r *http.Request) string {
 	return "http://localhost:4200/app/integration/slack"
 }
 
+// inferredIntegrationHubURL is where providers without their own settings
+// page send the browser after an OAuth callback.
+func inferredIntegrationHubURL(r *http.Request) string {
+	parsed, err := url.Parse(inferredAppUIBaseURL(r))
+	if err != nil {
+		return "http://localhost:4200/app/integration"
+	}
+	parsed.Path = "/app/integration"
+	return parsed.String()
+}
+
 func ensureIntegrationEncryptionKey(path string) (string, error) {
 	if strings.TrimSpace(path) == "" {
 		return "", errors.New("encryption key path is empty")
@@ -1277,25 +1645,66 @@ func handleIntegrations(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	writeJSON(w, http.StatusOK, integrationsResponse{
+		Integrations: integrationProviders.Summaries(),
+	})
+}
+
+// slackIntegration mounts the Slack handlers on the integration registry.
+type slackIntegration struct{}
+
+func (slackIntegration) Name() string { return providerSlack }
 
-	slackSummary := integrationSummary{
+func (slackIntegration) Summary() integrationSummary {
+	summary := integrationSummary{
 		Provider: providerSlack,
 		Name:     "Slack",
 		Status:   defaultSlackDisconnectedStatus,
 		Detail:   defaultSlackConnectionDetail,
 	}
-
 	if conn, ok := integrationStoreInstance.GetSlackConnection(); ok {
-		slackSummary.Status = "Connected"
-		slackSummary.ConnectedAt = conn.ConnectedAt.Format(time.RFC3339)
+		summary.Status = "Connected"
+		summary.ConnectedAt = conn.ConnectedAt.Format(time.RFC3339)
 		eventCount := integrationStoreInstance.SlackEventCount()
 		selectedCount := len(integrationStoreInstance.GetSelectedSlackChannels())
-		slackSummary.Detail = fmt.Sprintf("%s workspace connected (%d selected channels, %d events received)", conn.TeamName, selectedCount, eventCount)
+		summary.Detail = fmt.Sprintf("%s workspace connected (%d selected channels, %d events received)", conn.TeamName, selectedCount, eventCount)
 	}
+	return summary
+}
 
-	writeJSON(w, http.StatusOK, integrationsResponse{
-		Integrations: []integrationSummary{slackSummary},
-	})
+func (slackIntegration) ConnectURL(w http.ResponseWriter, r *http.Request) {
+	handleSlackConnectURL(w, r)
+}
+
+func (slackIntegration) Callback(w http.ResponseWriter, r *http.Request) {
+	handleSlackCallback(w, r)
+}
+
+func (slackIntegration) Disconnect(w http.ResponseWriter, r *http.Request) {
+	handleSlackDisconnect(w, r)
+}
+
+func (slackIntegration) Sources(w http.ResponseWriter, r *http.Request) {
+	handleSlackChannels(w, r)
+}
+
+func (slackIntegration) Import(w http.ResponseWriter, r *http.Request) {
+	handleSlackChannelsImport(w, r)
+}
+
+func (slackIntegration) Webhook(w http.ResponseWriter, r *http.Request) {
+	handleSlackWebhook(w, r)
+}
+
+// ExtraRoutes keeps the setup wizard and the channel paths the UI already
+// calls.
+func (slackIntegration) ExtraRoutes() map[string]http.HandlerFunc {
+	return map[string]http.HandlerFunc{
+		"setup":           handleSlackSetup,
+		"setup/validate":  handleSlackSetupValidate,
+		"channels":        handleSlackChannels,
+		"channels/import": handleSlackChannelsImport,
+	}
 }
 
 func handleSlackConnectURL(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,533 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	jiraAuthAPIToken      = "api_token"
+	jiraAuthCookieSession = "cookie_session"
+	jiraRequestTimeout    = 30 * time.Second
+	jiraProjectPageSize   = 50
+	maxJiraProjects       = 500
+	maxJiraSummaryLength  = 255
+)
+
+// jiraConnectionRecord is the stored Jira Cloud connection. The secret is an
+// API token (basic auth with Email) or a session cookie, encrypted with
+// integrationTokenCipher.
+type jiraConnectionRecord struct {
+	BaseURL          string    `json:"baseUrl"`
+	AuthType         string    `json:"authType"`
+	Email            string    `json:"email,omitempty"`
+	EncryptedSecret  string    `json:"encryptedSecret"`
+	AccountName      string    `json:"accountName,omitempty"`
+	ProjectKey       string    `json:"projectKey"`
+	EpicIssueTypeID  string    `json:"epicIssueTypeId"`
+	StoryIssueTypeID string    `json:"storyIssueTypeId"`
+	ConnectedAt      time.Time `json:"connectedAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+type jiraConnectRequest struct {
+	AuthType       string `json:"auth_type"`
+	ProjectKey     string `json:"project_key"`
+	EpicIssueType  string `json:"epic_issue_type"`
+	StoryIssueType string `json:"story_issue_type"`
+	CredentialBlob struct {
+		BaseURL       string `json:"base_url"`
+		Email         string `json:"email"`
+		APIToken      string `json:"api_token"`
+		SessionCookie string `json:"session_cookie"`
+		ProjectKey    string `json:"project_key"`
+	} `json:"credential_blob"`
+}
+
+type jiraClient struct {
+	baseURL  string
+	authType string
+	email    string
+	secret   string
+	client   *http.Client
+}
+
+type jiraStatusError struct {
+	Status   int
+	Messages []string
+}
+
+func (e *jiraStatusError) Error() string {
+	if len(e.Messages) == 0 {
+		return fmt.Sprintf("jira returned status %d", e.Status)
+	}
+	return fmt.Sprintf("jira returned status %d: %s", e.Status, strings.Join(e.Messages, "; "))
+}
+
+type jiraUser struct {
+	AccountID    string `json:"accountId"`
+	DisplayName  string `json:"displayName"`
+	EmailAddress string `json:"emailAddress"`
+}
+
+type jiraIssueType struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	Subtask        bool   `json:"subtask"`
+	HierarchyLevel int    `json:"hierarchyLevel"`
+}
+
+type jiraProject struct {
+	ID         string          `json:"id"`
+	Key        string          `json:"key"`
+	Name       string          `json:"name"`
+	IssueTypes []jiraIssueType `json:"issueTypes"`
+}
+
+type jiraCreatedIssue struct {
+	ID  string `json:"id"`
+	Key string `json:"key"`
+}
+
+// jiraIssueFields is the create payload; description is Atlassian Document
+// Format, which the v3 API requires.
+type jiraIssueFields struct {
+	Project     map[string]string `json:"project"`
+	IssueType   map[string]string `json:"issuetype"`
+	Summary     string            `json:"summary"`
+	Description map[string]any    `json:"description,omitempty"`
+	Labels      []string          `json:"labels,omitempty"`
+	Parent      map[string]string `json:"parent,omitempty"`
+}
+
+func newJiraClient(baseURL, authType, email, secret string) (*jiraClient, error) {
+	baseURL, err := normalizeJiraBaseURL(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	switch authType {
+	case jiraAuthAPIToken:
+		if strings.TrimSpace(email) == "" || strings.TrimSpace(secret) == "" {
+			return nil, errors.New("email and api_token are required for api_token auth")
+		}
+	case jiraAuthCookieSession:
+		if strings.TrimSpace(secret) == "" {
+			return nil, errors.New("session_cookie is required for cookie_session auth")
+		}
+	default:
+		return nil, fmt.Errorf("auth_type must be %s or %s", jiraAuthAPIToken, jiraAuthCookieSession)
+	}
+	return &jiraClient{
+		baseURL:  baseURL,
+		authType: authType,
+		email:    strings.TrimSpace(email),
+		secret:   strings.TrimSpace(secret),
+		client:   &http.Client{Timeout: jiraRequestTimeout},
+	}, nil
+}
+
+func normalizeJiraBaseURL(raw string) (string, error) {
+	base := strings.TrimRight(strings.TrimSpace(raw), "/")
+	parsed, err := url.Parse(base)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", errors.New("base_url must be an absolute http(s) URL")
+	}
+	return base, nil
+}
+
+// storedJiraClient builds a client from the encrypted connection record.
+func storedJiraClient() (*jiraClient, jiraConnectionRecord, error) {
+	conn, connected := integrationStoreInstance.GetJiraConnection()
+	if !connected {
+		return nil, conn, errors.New("jira is not connected")
+	}
+	if integrationTokenCipher == nil {
+		return nil, conn, errors.New("integration encryption is not configured")
+	}
+	secret, err := integrationTokenCipher.Decrypt(conn.EncryptedSecret)
+	if err != nil {
+		return nil, conn, fmt.Errorf("failed to decrypt Jira credentials: %w", err)
+	}
+	client, err := newJiraClient(conn.BaseURL, conn.AuthType, conn.Email, secret)
+	return client, conn, err
+}
+
+func (c *jiraClient) do(ctx context.Context, method, path string, payload any, out any) error {
+	var body io.Reader
+	if payload != nil {
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.authType == jiraAuthAPIToken {
+		req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(c.email+":"+c.secret)))
+	} else {
+		req.Header.Set("Cookie", c.secret)
+		req.Header.Set("X-Atlassian-Token", "no-check")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("jira %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return fmt.Errorf("jira %s %s: %w", method, path, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &jiraStatusError{Status: resp.StatusCode, Messages: jiraErrorMessages(respBody)}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("decode jira %s response: %w", path, err)
+	}
+	return nil
+}
+
+func jiraErrorMessages(body []byte) []string {
+	var parsed struct {
+		ErrorMessages []string          `json:"errorMessages"`
+		Errors        map[string]string `json:"errors"`
+	}
+	if json.Unmarshal(body, &parsed) != nil {
+		return nil
+	}
+	messages := append([]string{}, parsed.ErrorMessages...)
+	for field, message := range parsed.Errors {
+		messages = append(messages, field+": "+message)
+	}
+	return messages
+}
+
+func (c *jiraClient) Myself(ctx context.Context) (jiraUser, error) {
+	var user jiraUser
+	err := c.do(ctx, http.MethodGet, "/rest/api/3/myself", nil, &user)
+	return user, err
+}
+
+// Projects lists the projects the credentials can see, with their issue types.
+func (c *jiraClient) Projects(ctx context.Context) ([]jiraProject, error) {
+	projects := make([]jiraProject, 0, jiraProjectPageSize)
+	for startAt := 0; len(projects) < maxJiraProjects; {
+		query := url.Values{}
+		query.Set("expand", "issueTypes")
+		query.Set("startAt", strconv.Itoa(startAt))
+		query.Set("maxResults", strconv.Itoa(jiraProjectPageSize))
+		var page struct {
+			Values []jiraProject `json:"values"`
+			IsLast bool          `json:"isLast"`
+		}
+		if err := c.do(ctx, http.MethodGet, "/rest/api/3/project/search?"+query.Encode(), nil, &page); err != nil {
+			return nil, err
+		}
+		projects = append(projects, page.Values...)
+		if page.IsLast || len(page.Values) == 0 {
+			break
+		}
+		startAt += len(page.Values)
+	}
+	return projects, nil
+}
+
+func (c *jiraClient) CreateIssue(ctx context.Context, fields jiraIssueFields) (jiraCreatedIssue, error) {
+	var created jiraCreatedIssue
+	err := c.do(ctx, http.MethodPost, "/rest/api/3/issue", map[string]any{"fields": fields}, &created)
+	return created, err
+}
+
+func (c *jiraClient) BrowseURL(key string) string {
+	return c.baseURL + "/browse/" + key
+}
+
+// pickJiraIssueTypes resolves the epic and story issue types for a project.
+// Explicit names win; otherwise Epic is the hierarchy level 1 type and stories
+// fall back to Task.
+func pickJiraIssueTypes(project jiraProject, epicName, storyName string) (jiraIssueType, jiraIssueType, error) {
+	var epic, story, task jiraIssueType
+	for _, issueType := range project.IssueTypes {
+		switch {
+		case issueType.Subtask:
+		case epicName != "" && strings.EqualFold(issueType.Name, epicName):
+			epic = issueType
+		case epicName == "" && epic.ID == "" && (strings.EqualFold(issueType.Name, "Epic") || issueType.HierarchyLevel == 1):
+			epic = issueType
+		case storyName != "" && strings.EqualFold(issueType.Name, storyName):
+			story = issueType
+		case storyName == "" && strings.EqualFold(issueType.Name, "Story"):
+			story = issueType
+		case strings.EqualFold(issueType.Name, "Task"):
+			task = issueType
+		}
+	}
+	if epic.ID == "" {
+		return epic, story, fmt.Errorf("project %s has no %s issue type", project.Key, firstNonEmpty(epicName, "Epic"))
+	}
+	if story.ID == "" && storyName == "" {
+		story = task
+	}
+	if story.ID == "" {
+		return epic, story, fmt.Errorf("project %s has no %s issue type", project.Key, firstNonEmpty(storyName, "Story or Task"))
+	}
+	return epic, story, nil
+}
+
+// connectJira verifies the credentials, discovers the target project and its
+// issue types, and stores the connection with the secret encrypted.
+func connectJira(ctx context.Context, req jiraConnectRequest) (jiraConnectionRecord, []jiraProject, error) {
+	if integrationTokenCipher == nil {
+		return jiraConnectionRecord{}, nil, errors.New("integration encryption is not configured")
+	}
+	authType := strings.TrimSpace(req.AuthType)
+	secret := req.CredentialBlob.APIToken
+	if authType == "" {
+		authType = jiraAuthAPIToken
+		if strings.TrimSpace(secret) == "" {
+			authType = jiraAuthCookieSession
+		}
+	}
+	if authType == jiraAuthCookieSession {
+		secret = req.CredentialBlob.SessionCookie
+	}
+	client, err := newJiraClient(req.CredentialBlob.BaseURL, authType, req.CredentialBlob.Email, secret)
+	if err != nil {
+		return jiraConnectionRecord{}, nil, err
+	}
+
+	user, err := client.Myself(ctx)
+	if err != nil {
+		return jiraConnectionRecord{}, nil, fmt.Errorf("verify jira credentials: %w", err)
+	}
+	projects, err := client.Projects(ctx)
+	if err != nil {
+		return jiraConnectionRecord{}, nil, fmt.Errorf("list jira projects: %w", err)
+	}
+	projectKey := strings.ToUpper(strings.TrimSpace(firstNonEmpty(req.ProjectKey, req.CredentialBlob.ProjectKey)))
+	var project *jiraProject
+	for idx := range projects {
+		if projectKey == "" || projects[idx].Key == projectKey {
+			project = &projects[idx]
+			break
+		}
+	}
+	if project == nil {
+		if projectKey == "" {
+			return jiraConnectionRecord{}, projects, errors.New("no jira projects are visible to these credentials")
+		}
+		return jiraConnectionRecord{}, projects, fmt.Errorf("jira project %s was not found", projectKey)
+	}
+	epicType, storyType, err := pickJiraIssueTypes(*project, strings.TrimSpace(req.EpicIssueType), strings.TrimSpace(req.StoryIssueType))
+	if err != nil {
+		return jiraConnectionRecord{}, projects, err
+	}
+
+	encrypted, err := integrationTokenCipher.Encrypt(client.secret)
+	if err != nil {
+		return jiraConnectionRecord{}, projects, fmt.Errorf("encrypt jira credentials: %w", err)
+	}
+	now := time.Now().UTC()
+	record := jiraConnectionRecord{
+		BaseURL:          client.baseURL,
+		AuthType:         client.authType,
+		Email:            client.email,
+		EncryptedSecret:  encrypted,
+		AccountName:      firstNonEmpty(user.DisplayName, user.EmailAddress, user.AccountID),
+		ProjectKey:       project.Key,
+		EpicIssueTypeID:  epicType.ID,
+		StoryIssueTypeID: storyType.ID,
+		ConnectedAt:      now,
+		UpdatedAt:        now,
+	}
+	if existing, ok := integrationStoreInstance.GetJiraConnection(); ok && existing.BaseURL == record.BaseURL {
+		record.ConnectedAt = existing.ConnectedAt
+	}
+	if err := integrationStoreInstance.UpsertJiraConnection(record); err != nil {
+		return record, projects, fmt.Errorf("save jira connection: %w", err)
+	}
+	return record, projects, nil
+}
+
+// jiraConnectErrorStatus maps a connect failure to a response status. Jira
+// rejecting the request (bad credentials, unknown project) is for the caller
+// to fix; Jira failing, throttling or being unreachable is a bad gateway.
+func jiraConnectErrorStatus(err error) int {
+	var jiraErr *jiraStatusError
+	if errors.As(err, &jiraErr) {
+		if jiraErr.Status >= http.StatusInternalServerError || jiraErr.Status == http.StatusTooManyRequests {
+			return http.StatusBadGateway
+		}
+		return http.StatusBadRequest
+	}
+	var netErr *url.Error
+	if errors.As(err, &netErr) {
+		return http.StatusBadGateway
+	}
+	return http.StatusBadRequest
+}
+
+func jiraConnectionSummary(conn jiraConnectionRecord, connected bool) map[string]any {
+	if !connected {
+		return map[string]any{"tool": "jira", "status": "disconnected"}
+	}
+	return map[string]any{
+		"tool":                "jira",
+		"status":              "connected",
+		"base_url":            conn.BaseURL,
+		"auth_type":           conn.AuthType,
+		"account":             conn.AccountName,
+		"project_key":         conn.ProjectKey,
+		"epic_issue_type_id":  conn.EpicIssueTypeID,
+		"story_issue_type_id": conn.StoryIssueTypeID,
+		"connected_at":        conn.ConnectedAt.Format(time.RFC3339),
+	}
+}
+
+// jiraEpicFromDecision renders the epic and one story per next step from a
+// decision object.
+func jiraEpicFromDecision(conn jiraConnectionRecord, runID string, decision map[string]any) (jiraIssueFields, []jiraIssueFields, error) {
+	var parsed decisionObject
+	if err := decodeArtifactStrict(decision, &parsed); err != nil {
+		return jiraIssueFields{}, nil, fmt.Errorf("decode decision object: %w", err)
+	}
+
+	content := []map[string]any{
+		adfParagraph(adfText(fmt.Sprintf("Recommendation: %s priority, %.0f%% confidence.",
+			parsed.Recommendation.Priority, parsed.Recommendation.Confidence*100))),
+		adfHeading("Customer signals"),
+		adfParagraph(adfText(fmt.Sprintf("%d mentions.", parsed.Signals.TotalMentions))),
+	}
+	if items := jiraChannelItems(parsed.Signals.TopChannels); len(items) > 0 {
+		content = append(content, adfBulletList(items))
+	}
+	if len(parsed.Signals.Themes) > 0 {
+		items := make([][]map[string]any, 0, len(parsed.Signals.Themes))
+		for _, theme := range parsed.Signals.Themes {
+			items = append(items, []map[string]any{adfText(fmt.Sprintf("%s (%d)", theme.Label, theme.Count))})
+		}
+		content = append(content, adfHeading("Themes"), adfBulletList(items))
+	}
+	if len(parsed.Signals.SampleQuotes) > 0 {
+		items := make([][]map[string]any, 0, len(parsed.Signals.SampleQuotes))
+		for _, quote := range parsed.Signals.SampleQuotes {
+			items = append(items, []map[string]any{adfText("“" + quote.Text + "” — "), adfLink(firstNonEmpty(quote.Source, "source"), quote.URL)})
+		}
+		content = append(content, adfHeading("Sample quotes"), adfBulletList(items))
+	}
+	if len(parsed.Competitors) > 0 {
+		items := make([][]map[string]any, 0, len(parsed.Competitors))
+		for _, competitor := range parsed.Competitors {
+			items = append(items, []map[string]any{adfLink(competitor.Name, competitor.URL), adfText(": " + competitor.Evidence)})
+		}
+		content = append(content, adfHeading("Competitors"), adfBulletList(items))
+	}
+	if len(parsed.Assumptions) > 0 {
+		items := make([][]map[string]any, 0, len(parsed.Assumptions))
+		for _, assumption := range parsed.Assumptions {
+			items = append(items, []map[string]any{adfText(fmt.Sprintf("%s (%s risk). Validate: %s. Metric: %s.",
+				assumption.Statement, assumption.Risk, assumption.Validation, assumption.Metric))})
+		}
+		content = append(content, adfHeading("Assumptions"), adfBulletList(items))
+	}
+	if steps := parsed.Recommendation.NextSteps; len(steps) > 0 {
+		items := make([][]map[string]any, 0, len(steps))
+		for _, step := range steps {
+			items = append(items, []map[string]any{adfText(step)})
+		}
+		content = append(content, adfHeading("Next steps"), adfBulletList(items))
+	}
+	content = append(content, adfParagraph(adfText("Generated by Sentient decision run "+runID+".")))
+
+	epic := jiraIssueFields{
+		Project:     map[string]string{"key": conn.ProjectKey},
+		IssueType:   map[string]string{"id": conn.EpicIssueTypeID},
+		Summary:     truncateJiraSummary(parsed.Feature),
+		Description: adfDocument(content),
+		Labels:      []string{"sentient"},
+	}
+	stories := make([]jiraIssueFields, 0, len(parsed.Recommendation.NextSteps))
+	for _, step := range parsed.Recommendation.NextSteps {
+		if strings.TrimSpace(step) == "" {
+			continue
+		}
+		stories = append(stories, jiraIssueFields{
+			Project:   map[string]string{"key": conn.ProjectKey},
+			IssueType: map[string]string{"id": conn.StoryIssueTypeID},
+			Summary:   truncateJiraSummary(step),
+			Description: adfDocument([]map[string]any{
+				adfParagraph(adfText(fmt.Sprintf("Next step for %s from Sentient decision run %s.", parsed.Feature, runID))),
+			}),
+			Labels: []string{"sentient"},
+		})
+	}
+	return epic, stories, nil
+}
+
+func jiraChannelItems(channels []decisionChannel) [][]map[string]any {
+	items := make([][]map[string]any, 0, len(channels))
+	for _, channel := range channels {
+		items = append(items, []map[string]any{adfText(fmt.Sprintf("%s: %d", channel.Name, channel.Count))})
+	}
+	return items
+}
+
+func truncateJiraSummary(summary string) string {
+	summary = strings.Join(strings.Fields(summary), " ")
+	if runes := []rune(summary); len(runes) > maxJiraSummaryLength {
+		return string(runes[:maxJiraSummaryLength-1]) + "…"
+	}
+	return summary
+}
+
+func adfDocument(content []map[string]any) map[string]any {
+	return map[string]any{"type": "doc", "version": 1, "content": content}
+}
+
+func adfHeading(text string) map[string]any {
+	return map[string]any{"type": "heading", "attrs": map[string]any{"level": 3}, "content": []map[string]any{adfText(text)}}
+}
+
+func adfParagraph(inline ...map[string]any) map[string]any {
+	return map[string]any{"type": "paragraph", "content": inline}
+}
+
+func adfBulletList(items [][]map[string]any) map[string]any {
+	listItems := make([]map[string]any, 0, len(items))
+	for _, inline := range items {
+		listItems = append(listItems, map[string]any{"type": "listItem", "content": []map[string]any{adfParagraph(inline...)}})
+	}
+	return map[string]any{"type": "bulletList", "content": listItems}
+}
+
+func adfText(text string) map[string]any {
+	return map[string]any{"type": "text", "text": text}
+}
+
+func adfLink(text, href string) map[string]any {
+	node := adfText(text)
+	if strings.TrimSpace(href) != "" {
+		node["marks"] = []map[string]any{{"type": "link", "attrs": map[string]any{"href": href}}}
+	}
+	return node
+}
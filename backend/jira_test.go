@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeJira serves the Jira Cloud endpoints the client uses and records the
+// issues it is asked to create.
+type fakeJira struct {
+	mu       sync.Mutex
+	server   *httptest.Server
+	auth     string
+	failWith int
+	issues   []jiraIssueFields
+}
+
+func newFakeJira(t *testing.T) *fakeJira {
+	t.Helper()
+	fake := &fakeJira{auth: "Basic " + base64.StdEncoding.EncodeToString([]byte("pm@example.com:good-token"))}
+	fake.server = httptest.NewServer(http.HandlerFunc(fake.serve))
+	t.Cleanup(fake.server.Close)
+	return fake
+}
+
+func (f *fakeJira) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failWith != 0 {
+		w.WriteHeader(f.failWith)
+		_, _ = w.Write([]byte(`{"errorMessages":["Jira is having a bad day"]}`))
+		return
+	}
+	if r.Header.Get("Authorization") != f.auth {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"errorMessages":["Client must be authenticated to access this resource."]}`))
+		return
+	}
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/rest/api/3/myself":
+		_, _ = w.Write([]byte(`{"accountId":"acc-1","displayName":"Pat PM"}`))
+	case r.Method == http.MethodGet && r.URL.Path == "/rest/api/3/project/search":
+		if r.URL.Query().Get("expand") != "issueTypes" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"isLast":true,"values":[
+			{"id":"1","key":"OPS","name":"Ops","issueTypes":[{"id":"20","name":"Task"}]},
+			{"id":"2","key":"PROD","name":"Product","issueTypes":[
+				{"id":"10000","name":"Epic","hierarchyLevel":1},
+				{"id":"10001","name":"Story"},
+				{"id":"10002","name":"Task"},
+				{"id":"10003","name":"Sub-task","subtask":true}]}]}`))
+	case r.Method == http.MethodPost && r.URL.Path == "/rest/api/3/issue":
+		var body struct {
+			Fields jiraIssueFields `json:"fields"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Fields.Summary == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errors":{"summary":"You must specify a summary of the issue."}}`))
+			return
+		}
+		f.issues = append(f.issues, body.Fields)
+		_ = json.NewEncoder(w).Encode(jiraCreatedIssue{ID: "1", Key: fmt.Sprintf("PROD-%d", len(f.issues))})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func testJiraConnectRequest(baseURL string, token string, project string) jiraConnectRequest {
+	var req jiraConnectRequest
+	req.ProjectKey = project
+	req.CredentialBlob.BaseURL = baseURL + "/"
+	req.CredentialBlob.Email = "pm@example.com"
+	req.CredentialBlob.APIToken = token
+	return req
+}
+
+func TestConnectJiraDiscoversProjectAndStoresSecretEncrypted(t *testing.T) {
+	useTestIntegrations(t)
+	fake := newFakeJira(t)
+
+	conn, projects, err := connectJira(context.Background(), testJiraConnectRequest(fake.server.URL, "good-token", "prod"))
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if len(projects) != 2 || conn.ProjectKey != "PROD" || conn.EpicIssueTypeID != "10000" || conn.StoryIssueTypeID != "10001" {
+		t.Fatalf("connection = %+v", conn)
+	}
+	if conn.BaseURL != fake.server.URL || conn.AuthType != jiraAuthAPIToken || conn.AccountName != "Pat PM" {
+		t.Fatalf("connection = %+v", conn)
+	}
+	if strings.Contains(conn.EncryptedSecret, "good-token") {
+		t.Fatal("secret stored in plain text")
+	}
+	stored, connected := integrationStoreInstance.GetJiraConnection()
+	if !connected || stored.EncryptedSecret != conn.EncryptedSecret {
+		t.Fatal("connection was not stored")
+	}
+	client, _, err := storedJiraClient()
+	if err != nil || client.secret != "good-token" {
+		t.Fatalf("stored client: %v", err)
+	}
+}
+
+func TestJiraEpicCreation(t *testing.T) {
+	useTestIntegrations(t)
+	fake := newFakeJira(t)
+	if _, _, err := connectJira(context.Background(), testJiraConnectRequest(fake.server.URL, "good-token", "PROD")); err != nil {
+		t.Fatal(err)
+	}
+
+	run := addTestLocalRun(t, "run_jira_epic", decisionSinkJira)
+	if err := createLocalJiraEpic(context.Background(), run); err != nil {
+		t.Fatalf("create epic: %v", err)
+	}
+
+	nextSteps := run.Decision["recommendation"].(map[string]any)["next_steps"].([]string)
+	if len(fake.issues) != 1+len(nextSteps) {
+		t.Fatalf("created %d issues, want an epic and %d stories", len(fake.issues), len(nextSteps))
+	}
+	epic := fake.issues[0]
+	if epic.IssueType["id"] != "10000" || epic.Project["key"] != "PROD" || epic.Summary != "Dark mode" || epic.Parent != nil {
+		t.Fatalf("epic fields = %+v", epic)
+	}
+	description, _ := json.Marshal(epic.Description)
+	for _, want := range []string{`"type":"doc"`, "Customer signals", "Next steps", "run_jira_epic", nextSteps[0]} {
+		if !strings.Contains(string(description), want) {
+			t.Errorf("epic description is missing %q", want)
+		}
+	}
+	for idx, story := range fake.issues[1:] {
+		if story.IssueType["id"] != "10001" || story.Parent["key"] != "PROD-1" || story.Summary != nextSteps[idx] {
+			t.Errorf("story %d fields = %+v", idx, story)
+		}
+	}
+
+	stored, _ := readLocalRun(run.ID)
+	if stored.JiraEpic == nil || stored.JiraEpic.EpicKey != "PROD-1" || stored.JiraEpic.URL != fake.server.URL+"/browse/PROD-1" {
+		t.Fatalf("jira epic artifact = %+v", stored.JiraEpic)
+	}
+	if len(stored.JiraEpic.Stories) != len(nextSteps) {
+		t.Fatalf("artifact has %d stories, want %d", len(stored.JiraEpic.Stories), len(nextSteps))
+	}
+}
+
+func TestJiraCookieSessionAuth(t *testing.T) {
+	var cookie, token string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, token = r.Header.Get("Cookie"), r.Header.Get("X-Atlassian-Token")
+		_, _ = w.Write([]byte(`{"accountId":"acc-1"}`))
+	}))
+	defer server.Close()
+
+	client, err := newJiraClient(server.URL, jiraAuthCookieSession, "", "tenant.session.token=abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Myself(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if cookie != "tenant.session.token=abc" || token != "no-check" {
+		t.Fatalf("cookie %q, X-Atlassian-Token %q", cookie, token)
+	}
+}
+
+func TestJiraConnectionHandlerErrorMapping(t *testing.T) {
+	useTestIntegrations(t)
+	fake := newFakeJira(t)
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name       string
+		baseURL    string
+		token      string
+		project    string
+		failWith   int
+		wantStatus int
+		wantError  string
+	}{
+		{"bad credentials", fake.server.URL, "wrong-token", "PROD", 0, http.StatusBadRequest, "Client must be authenticated"},
+		{"forbidden", fake.server.URL, "good-token", "PROD", http.StatusForbidden, http.StatusBadRequest, "status 403"},
+		{"unknown project", fake.server.URL, "good-token", "NOPE", 0, http.StatusBadRequest, "jira project NOPE was not found"},
+		{"project without epics", fake.server.URL, "good-token", "OPS", 0, http.StatusBadRequest, "has no Epic issue type"},
+		{"invalid base url", "ftp://jira.example", "good-token", "PROD", 0, http.StatusBadRequest, "absolute http(s) URL"},
+		{"rate limited", fake.server.URL, "good-token", "PROD", http.StatusTooManyRequests, http.StatusBadGateway, "status 429"},
+		{"server error", fake.server.URL, "good-token", "PROD", http.StatusServiceUnavailable, http.StatusBadGateway, "Jira is having a bad day"},
+		{"unreachable", closed.URL, "good-token", "PROD", 0, http.StatusBadGateway, "verify jira credentials"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake.mu.Lock()
+			fake.failWith = tt.failWith
+			fake.mu.Unlock()
+
+			body, _ := json.Marshal(testJiraConnectRequest(tt.baseURL, tt.token, tt.project))
+			rec := httptest.NewRecorder()
+			handleOperatorJiraConnection(rec, httptest.NewRequest(http.MethodPost, "/api/operator/connections/jira", strings.NewReader(string(body))))
+
+			var resp errorResponse
+			_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+			if rec.Code != tt.wantStatus || !strings.Contains(resp.Error, tt.wantError) {
+				t.Fatalf("got %d %q, want %d containing %q", rec.Code, resp.Error, tt.wantStatus, tt.wantError)
+			}
+			if _, connected := integrationStoreInstance.GetJiraConnection(); connected {
+				t.Fatal("a failed connect must not store a connection")
+			}
+		})
+	}
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultLinearAPIURL  = "https://api.linear.app/graphql"
+	linearTargetIssue    = "issue"
+	linearTargetProject  = "project"
+	linearRequestTimeout = 30 * time.Second
+)
+
+// linearConnectionRecord is the stored Linear connection. Decisions are filed
+// in TeamID as a single issue or as a project, depending on Target.
+type linearConnectionRecord struct {
+	EncryptedAPIKey  string    `json:"encryptedApiKey"`
+	OrganizationName string    `json:"organizationName,omitempty"`
+	ViewerName       string    `json:"viewerName,omitempty"`
+	TeamID           string    `json:"teamId"`
+	TeamKey          string    `json:"teamKey"`
+	TeamName         string    `json:"teamName,omitempty"`
+	Target           string    `json:"target"`
+	ConnectedAt      time.Time `json:"connectedAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+type linearConnectRequest struct {
+	APIKey  string `json:"api_key"`
+	TeamKey string `json:"team_key"`
+	Target  string `json:"target"`
+}
+
+type linearClient struct {
+	endpoint string
+	apiKey   string
+	client   *http.Client
+}
+
+type linearStatusError struct {
+	Status   int
+	Messages []string
+}
+
+func (e *linearStatusError) Error() string {
+	if len(e.Messages) == 0 {
+		return fmt.Sprintf("linear returned status %d", e.Status)
+	}
+	return fmt.Sprintf("linear returned status %d: %s", e.Status, strings.Join(e.Messages, "; "))
+}
+
+type linearTeam struct {
+	ID   string `json:"id"`
+	Key  string `json:"key"`
+	Name string `json:"name"`
+}
+
+type linearIssue struct {
+	ID         string `json:"id"`
+	Identifier string `json:"identifier"`
+	Title      string `json:"title"`
+	URL        string `json:"url"`
+}
+
+type linearProject struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	SlugID string `json:"slugId"`
+	URL    string `json:"url"`
+}
+
+type linearViewer struct {
+	Viewer struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	} `json:"viewer"`
+	Organization struct {
+		Name string `json:"name"`
+	} `json:"organization"`
+	Teams struct {
+		Nodes []linearTeam `json:"nodes"`
+	} `json:"teams"`
+}
+
+func newLinearClient(apiKey string) (*linearClient, error) {
+	apiKey = strings.TrimSpace(apiKey)
+	if apiKey == "" {
+		return nil, errors.New("api_key is required")
+	}
+	return &linearClient{
+		endpoint: linearAPIURL,
+		apiKey:   apiKey,
+		client:   &http.Client{Timeout: linearRequestTimeout},
+	}, nil
+}
+
+func storedLinearClient() (*linearClient, linearConnectionRecord, error) {
+	conn, connected := integrationStoreInstance.GetLinearConnection()
+	if !connected {
+		return nil, conn, errors.New("linear is not connected")
+	}
+	if integrationTokenCipher == nil {
+		return nil, conn, errors.New("integration encryption is not configured")
+	}
+	apiKey, err := integrationTokenCipher.Decrypt(conn.EncryptedAPIKey)
+	if err != nil {
+		return nil, conn, fmt.Errorf("failed to decrypt Linear API key: %w", err)
+	}
+	client, err := newLinearClient(apiKey)
+	return client, conn, err
+}
+
+// do runs one GraphQL operation. Linear reports most failures as a 200 with
+// an errors array, so both are surfaced as errors.
+func (c *linearClient) do(ctx context.Context, query string, variables map[string]any, out any) error {
+	payload, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", c.apiKey)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("linear request: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return fmt.Errorf("linear request: %w", err)
+	}
+
+	var parsed struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	decodeErr := json.Unmarshal(body, &parsed)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 || len(parsed.Errors) > 0 {
+		statusErr := &linearStatusError{Status: resp.StatusCode}
+		for _, graphErr := range parsed.Errors {
+			statusErr.Messages = append(statusErr.Messages, graphErr.Message)
+		}
+		return statusErr
+	}
+	if decodeErr != nil {
+		return fmt.Errorf("decode linear response: %w", decodeErr)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(parsed.Data, out); err != nil {
+		return fmt.Errorf("decode linear response: %w", err)
+	}
+	return nil
+}
+
+func (c *linearClient) Viewer(ctx context.Context) (linearViewer, error) {
+	var viewer linearViewer
+	err := c.do(ctx, `query { viewer { name email } organization { name } teams(first: 100) { nodes { id key name } } }`, nil, &viewer)
+	return viewer, err
+}
+
+func (c *linearClient) CreateIssue(ctx context.Context, input map[string]any) (linearIssue, error) {
+	var out struct {
+		IssueCreate struct {
+			Success bool        `json:"success"`
+			Issue   linearIssue `json:"issue"`
+		} `json:"issueCreate"`
+	}
+	err := c.do(ctx, `mutation($input: IssueCreateInput!) { issueCreate(input: $input) { success issue { id identifier title url } } }`,
+		map[string]any{"input": input}, &out)
+	if err == nil && (!out.IssueCreate.Success || out.IssueCreate.Issue.Identifier == "") {
+		err = errors.New("linear did not create the issue")
+	}
+	return out.IssueCreate.Issue, err
+}
+
+func (c *linearClient) CreateProject(ctx context.Context, input map[string]any) (linearProject, error) {
+	var out struct {
+		ProjectCreate struct {
+			Success bool          `json:"success"`
+			Project linearProject `json:"project"`
+		} `json:"projectCreate"`
+	}
+	err := c.do(ctx, `mutation($input: ProjectCreateInput!) { projectCreate(input: $input) { success project { id name slugId url } } }`,
+		map[string]any{"input": input}, &out)
+	if err == nil && (!out.ProjectCreate.Success || out.ProjectCreate.Project.ID == "") {
+		err = errors.New("linear did not create the project")
+	}
+	return out.ProjectCreate.Project, err
+}
+
+// connectLinear verifies the API key, resolves the team and stores the
+// connection with the key encrypted.
+func connectLinear(ctx context.Context, req linearConnectRequest) (linearConnectionRecord, []linearTeam, error) {
+	if integrationTokenCipher == nil {
+		return linearConnectionRecord{}, nil, errors.New("integration encryption is not configured")
+	}
+	target := strings.ToLower(strings.TrimSpace(req.Target))
+	switch target {
+	case "":
+		target = linearTargetIssue
+	case linearTargetIssue, linearTargetProject:
+	default:
+		return linearConnectionRecord{}, nil, fmt.Errorf("target must be %s or %s", linearTargetIssue, linearTargetProject)
+	}
+	client, err := newLinearClient(req.APIKey)
+	if err != nil {
+		return linearConnectionRecord{}, nil, err
+	}
+
+	viewer, err := client.Viewer(ctx)
+	if err != nil {
+		return linearConnectionRecord{}, nil, fmt.Errorf("verify linear api key: %w", err)
+	}
+	teams := viewer.Teams.Nodes
+	teamKey := strings.ToUpper(strings.TrimSpace(req.TeamKey))
+	var team *linearTeam
+	for idx := range teams {
+		if teamKey == "" || strings.EqualFold(teams[idx].Key, teamKey) {
+			team = &teams[idx]
+			break
+		}
+	}
+	if team == nil {
+		if teamKey == "" {
+			return linearConnectionRecord{}, teams, errors.New("no linear teams are visible to this api key")
+		}
+		return linearConnectionRecord{}, teams, fmt.Errorf("linear team %s was not found", teamKey)
+	}
+
+	encrypted, err := integrationTokenCipher.Encrypt(client.apiKey)
+	if err != nil {
+		return linearConnectionRecord{}, teams, fmt.Errorf("encrypt linear api key: %w", err)
+	}
+	now := time.Now().UTC()
+	record := linearConnectionRecord{
+		EncryptedAPIKey:  encrypted,
+		OrganizationName: viewer.Organization.Name,
+		ViewerName:       firstNonEmpty(viewer.Viewer.Name, viewer.Viewer.Email),
+		TeamID:           team.ID,
+		TeamKey:          team.Key,
+		TeamName:         team.Name,
+		Target:           target,
+		ConnectedAt:      now,
+		UpdatedAt:        now,
+	}
+	if existing, ok := integrationStoreInstance.GetLinearConnection(); ok {
+		record.ConnectedAt = existing.ConnectedAt
+	}
+	if err := integrationStoreInstance.UpsertLinearConnection(record); err != nil {
+		return record, teams, fmt.Errorf("save linear connection: %w", err)
+	}
+	return record, teams, nil
+}
+
+func linearConnectionSummary(conn linearConnectionRecord, connected bool) map[string]any {
+	if !connected {
+		return map[string]any{"tool": "linear", "status": "disconnected"}
+	}
+	return map[string]any{
+		"tool":         "linear",
+		"status":       "connected",
+		"organization": conn.OrganizationName,
+		"account":      conn.ViewerName,
+		"team_key":     conn.TeamKey,
+		"team_name":    conn.TeamName,
+		"target":       conn.Target,
+		"connected_at": conn.ConnectedAt.Format(time.RFC3339),
+	}
+}
+
+// decisionMarkdown renders a decision object as a Markdown body for trackers
+// that accept Markdown descriptions.
+func decisionMarkdown(decision decisionObject, runID string) string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "**Recommendation:** %s priority, %.0f%% confidence.\n\n",
+		decision.Recommendation.Priority, decision.Recommendation.Confidence*100)
+
+	fmt.Fprintf(&out, "### Customer signals\n\n%d mentions.\n\n", decision.Signals.TotalMentions)
+	for _, channel := range decision.Signals.TopChannels {
+		fmt.Fprintf(&out, "- %s: %d\n", channel.Name, channel.Count)
+	}
+	if len(decision.Signals.Themes) > 0 {
+		out.WriteString("\n### Themes\n\n")
+		for _, theme := range decision.Signals.Themes {
+			fmt.Fprintf(&out, "- %s (%d)\n", theme.Label, theme.Count)
+		}
+	}
+	if len(decision.Signals.SampleQuotes) > 0 {
+		out.WriteString("\n### Sample quotes\n\n")
+		for _, quote := range decision.Signals.SampleQuotes {
+			fmt.Fprintf(&out, "> %s\n>\n> — %s\n\n", quote.Text, markdownLink(firstNonEmpty(quote.Source, "source"), quote.URL))
+		}
+	}
+	if len(decision.Competitors) > 0 {
+		out.WriteString("\n### Competitors\n\n")
+		for _, competitor := range decision.Competitors {
+			fmt.Fprintf(&out, "- %s: %s\n", markdownLink(competitor.Name, competitor.URL), competitor.Evidence)
+		}
+	}
+	if len(decision.Assumptions) > 0 {
+		out.WriteString("\n### Assumptions\n\n")
+		for _, assumption := range decision.Assumptions {
+			fmt.Fprintf(&out, "- %s (%s risk). Validate: %s. Metric: %s.\n",
+				assumption.Statement, assumption.Risk, assumption.Validation, assumption.Metric)
+		}
+	}
+	if len(decision.Recommendation.NextSteps) > 0 {
+		out.WriteString("\n### Next steps\n\n")
+		for _, step := range decision.Recommendation.NextSteps {
+			fmt.Fprintf(&out, "- [ ] %s\n", step)
+		}
+	}
+	fmt.Fprintf(&out, "\n_Generated by Sentient decision run %s._\n", runID)
+	return out.String()
+}
+
+func markdownLink(text, href string) string {
+	if strings.TrimSpace(href) == "" {
+		return text
+	}
+	return "[" + text + "](" + href + ")"
+}
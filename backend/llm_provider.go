@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	llmProviderFake      = "fake"
+	llmProviderOpenAI    = "openai"
+	llmProviderAnthropic = "anthropic"
+
+	defaultLLMTimeout         = 60 * time.Second
+	defaultLLMMaxTokens       = 2048
+	defaultOpenAIBaseURL      = "https://api.openai.com"
+	defaultOpenAIModel        = "gpt-4o-mini"
+	defaultAnthropicBaseURL   = "https://api.anthropic.com"
+	defaultAnthropicModel     = "claude-sonnet-4-5"
+	anthropicAPIVersion       = "2023-06-01"
+	fakeLLMModel              = "fake-deterministic"
+	approxLLMCharsPerToken    = 4
+	maxLLMErrorBodyCharacters = 500
+)
+
+// LLMProvider completes a prompt into JSON that conforms to req.Schema.
+// Providers enforce the schema natively where the API supports it; callers
+// still validate the result.
+type LLMProvider interface {
+	Name() string
+	Model() string
+	Complete(ctx context.Context, req llmRequest) (llmResponse, error)
+}
+
+type llmRequest struct {
+	System     string
+	Prompt     string
+	SchemaName string
+	Schema     map[string]any
+	MaxTokens  int
+	// Reference is a known-good answer. Only the fake provider reads it, which
+	// keeps demo and test runs deterministic without a model.
+	Reference any
+}
+
+type llmResponse struct {
+	Text         string
+	InputTokens  int
+	OutputTokens int
+}
+
+type llmUsage struct {
+	Provider     string  `json:"provider"`
+	Model        string  `json:"model"`
+	Calls        int     `json:"calls"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+}
+
+type llmStatusError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+}
+
+func (e *llmStatusError) Error() string {
+	return fmt.Sprintf("%s returned status %d: %s", e.Provider, e.StatusCode, truncateText(e.Body, maxLLMErrorBodyCharacters))
+}
+
+func (u *llmUsage) add(provider LLMProvider, resp llmResponse) {
+	u.Provider = provider.Name()
+	u.Model = provider.Model()
+	u.Calls++
+	u.InputTokens += resp.InputTokens
+	u.OutputTokens += resp.OutputTokens
+	cost := float64(u.InputTokens)*llmInputCostPerMTok/1e6 + float64(u.OutputTokens)*llmOutputCostPerMTok/1e6
+	u.CostUSD = roundTo(cost, 6)
+}
+
+func newLLMProvider() LLMProvider {
+	client := &http.Client{Timeout: llmTimeout}
+	switch llmProviderName {
+	case llmProviderOpenAI:
+		if llmAPIKey == "" && llmBaseURL == "" {
+			log.Println("WARNING: LLM_PROVIDER=openai without LLM_API_KEY; using fake provider")
+			return fakeLLMProvider{}
+		}
+		return &openAILLMProvider{
+			baseURL: strings.TrimRight(firstNonEmpty(llmBaseURL, defaultOpenAIBaseURL), "/"),
+			apiKey:  llmAPIKey,
+			model:   firstNonEmpty(llmModel, defaultOpenAIModel),
+			client:  client,
+		}
+	case llmProviderAnthropic:
+		if llmAPIKey == "" && llmBaseURL == "" {
+			log.Println("WARNING: LLM_PROVIDER=anthropic without LLM_API_KEY; using fake provider")
+			return fakeLLMProvider{}
+		}
+		return &anthropicLLMProvider{
+			baseURL: strings.TrimRight(firstNonEmpty(llmBaseURL, defaultAnthropicBaseURL), "/"),
+			apiKey:  llmAPIKey,
+			model:   firstNonEmpty(llmModel, defaultAnthropicModel),
+			client:  client,
+		}
+	default:
+		return fakeLLMProvider{}
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+type fakeLLMProvider struct{}
+
+func (fakeLLMProvider) Name() string  { return llmProviderFake }
+func (fakeLLMProvider) Model() string { return fakeLLMModel }
+
+// Complete echoes the caller's reference answer and estimates tokens from
+// text length, so accounting paths are exercised with stable numbers.
+func (fakeLLMProvider) Complete(ctx context.Context, req llmRequest) (llmResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return llmResponse{}, err
+	}
+	if req.Reference == nil {
+		return llmResponse{}, errors.New("fake llm provider needs a reference answer")
+	}
+	blob, err := json.Marshal(req.Reference)
+	if err != nil {
+		return llmResponse{}, err
+	}
+	return llmResponse{
+		Text:         string(blob),
+		InputTokens:  estimateLLMTokens(req.System) + estimateLLMTokens(req.Prompt),
+		OutputTokens: estimateLLMTokens(string(blob)),
+	}, nil
+}
+
+func estimateLLMTokens(text string) int {
+	return (len(text) + approxLLMCharsPerToken - 1) / approxLLMCharsPerToken
+}
+
+type openAILLMProvider struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+func (p *openAILLMProvider) Name() string  { return llmProviderOpenAI }
+func (p *openAILLMProvider) Model() string { return p.model }
+
+func (p *openAILLMProvider) Complete(ctx context.Context, req llmRequest) (llmResponse, error) {
+	payload := map[string]any{
+		"model":      p.model,
+		"max_tokens": max(req.MaxTokens, 1),
+		"messages": []map[string]string{
+			{"role": "system", "content": req.System},
+			{"role": "user", "content": req.Prompt},
+		},
+		"response_format": map[string]any{
+			"type": "json_schema",
+			"json_schema": map[string]any{
+				"name":   req.SchemaName,
+				"schema": strictLLMSchema(req.Schema),
+				"strict": true,
+			},
+		},
+	}
+	headers := map[string]string{}
+	if p.apiKey != "" {
+		headers["Authorization"] = "Bearer " + p.apiKey
+	}
+
+	var decoded struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+				Refusal string `json:"refusal"`
+			} `json:"message"`
+		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+	}
+	if err := postLLMJSON(ctx, p.client, p.Name(), p.baseURL+"/v1/chat/completions", headers, payload, &decoded); err != nil {
+		return llmResponse{}, err
+	}
+	if len(decoded.Choices) == 0 {
+		return llmResponse{}, errors.New("openai response has no choices")
+	}
+	if refusal := strings.TrimSpace(decoded.Choices[0].Message.Refusal); refusal != "" {
+		return llmResponse{}, fmt.Errorf("openai refused: %s", refusal)
+	}
+	return llmResponse{
+		Text:         decoded.Choices[0].Message.Content,
+		InputTokens:  decoded.Usage.PromptTokens,
+		OutputTokens: decoded.Usage.CompletionTokens,
+	}, nil
+}
+
+type anthropicLLMProvider struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+func (p *anthropicLLMProvider) Name() string  { return llmProviderAnthropic }
+func (p *anthropicLLMProvider) Model() string { return p.model }
+
+// Complete forces a single tool call whose input schema is the output schema;
+// the tool input is the structured answer.
+func (p *anthropicLLMProvider) Complete(ctx context.Context, req llmRequest) (llmResponse, error) {
+	payload := map[string]any{
+		"model":      p.model,
+		"max_tokens": max(req.MaxTokens, 1),
+		"system":     req.System,
+		"messages": []map[string]string{
+			{"role": "user", "content": req.Prompt},
+		},
+		"tools": []map[string]any{{
+			"name":         req.SchemaName,
+			"description":  "Record the " + req.SchemaName + " answer.",
+			"input_schema": req.Schema,
+		}},
+		"tool_choice": map[string]any{"type": "tool", "name": req.SchemaName},
+	}
+	headers := map[string]string{"anthropic-version": anthropicAPIVersion}
+	if p.apiKey != "" {
+		headers["x-api-key"] = p.apiKey
+	}
+
+	var decoded struct {
+		Content []struct {
+			Type  string          `json:"type"`
+			Name  string          `json:"name"`
+			Input json.RawMessage `json:"input"`
+			Text  string          `json:"text"`
+		} `json:"content"`
+		Usage struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+	}
+	if err := postLLMJSON(ctx, p.client, p.Name(), p.baseURL+"/v1/messages", headers, payload, &decoded); err != nil {
+		return llmResponse{}, err
+	}
+	resp := llmResponse{InputTokens: decoded.Usage.InputTokens, OutputTokens: decoded.Usage.OutputTokens}
+	for _, block := range decoded.Content {
+		if block.Type == "tool_use" && block.Name == req.SchemaName {
+			resp.Text = string(block.Input)
+			return resp, nil
+		}
+	}
+	return resp, errors.New("anthropic response has no tool_use block")
+}
+
+func postLLMJSON(ctx context.Context, client *http.Client, provider string, endpoint string, headers map[string]string, payload any, out any) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &llmStatusError{Provider: provider, StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("%s decode error: %w", provider, err)
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func testDecisionInput() decisionSynthesisInput {
+	return decisionSynthesisInput{
+		Feature:       "Dark mode",
+		TotalMentions: 42,
+		Channels:      []decisionChannel{{Name: "feedback", Count: 30}, {Name: "support", Count: 12}},
+		Themes:        []decisionTheme{{Label: "eye strain", Count: 9}},
+		Quotes:        []decisionQuote{{Text: "My eyes hurt at night", Source: "Slack", URL: "https://slack.example/p1"}},
+		Competitors:   []decisionCompetitor{{Name: "Notion", Evidence: "Ships dark mode", URL: "https://notion.example"}},
+	}
+}
+
+func testDecisionJSON(t *testing.T) string {
+	t.Helper()
+	blob, err := json.Marshal(referenceDecision(testDecisionInput()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(blob)
+}
+
+// openAIStandIn answers chat completions with the given contents in turn and
+// records each request body.
+func openAIStandIn(t *testing.T, contents ...string) (*openAILLMProvider, *[]map[string]any) {
+	t.Helper()
+	var calls atomic.Int32
+	requests := &[]map[string]any{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer sk-test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":{"message":"bad key"}}`))
+			return
+		}
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		*requests = append(*requests, body)
+		idx := int(calls.Add(1)) - 1
+		content := contents[min(idx, len(contents)-1)]
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []any{map[string]any{"message": map[string]any{"content": content}}},
+			"usage":   map[string]any{"prompt_tokens": 100, "completion_tokens": 40},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return &openAILLMProvider{baseURL: server.URL, apiKey: "sk-test", model: "gpt-test", client: server.Client()}, requests
+}
+
+func TestOpenAIProviderSendsStrictSchema(t *testing.T) {
+	provider, requests := openAIStandIn(t, testDecisionJSON(t))
+	decision, usage, err := synthesizeDecision(context.Background(), provider, testDecisionInput())
+	if err != nil {
+		t.Fatalf("synthesize: %v", err)
+	}
+	if decision["feature"] != "Dark mode" {
+		t.Errorf("feature = %v", decision["feature"])
+	}
+	if usage.Calls != 1 || usage.InputTokens != 100 || usage.OutputTokens != 40 || usage.Model != "gpt-test" {
+		t.Errorf("usage = %+v", usage)
+	}
+
+	format := (*requests)[0]["response_format"].(map[string]any)["json_schema"].(map[string]any)
+	if format["strict"] != true || format["name"] != decisionObjectSchemaName {
+		t.Errorf("response_format = %v", format)
+	}
+	schema, _ := json.Marshal(format["schema"])
+	for _, keyword := range llmStrictUnsupportedKeywords {
+		if strings.Contains(string(schema), `"`+keyword+`"`) {
+			t.Errorf("schema sent to OpenAI contains %s", keyword)
+		}
+	}
+}
+
+func TestSynthesizeDecisionRepairsOnce(t *testing.T) {
+	invalid := `{"feature": "Dark mode"}`
+	provider, requests := openAIStandIn(t, invalid, testDecisionJSON(t))
+	decision, usage, err := synthesizeDecision(context.Background(), provider, testDecisionInput())
+	if err != nil {
+		t.Fatalf("repair failed: %v", err)
+	}
+	if decision == nil || usage.Calls != 2 || usage.InputTokens != 200 {
+		t.Fatalf("decision %v, usage %+v", decision, usage)
+	}
+	messages := (*requests)[1]["messages"].([]any)
+	repairPrompt := messages[1].(map[string]any)["content"].(string)
+	if !strings.Contains(repairPrompt, "Your previous answer was rejected") || !strings.Contains(repairPrompt, "missing required field") {
+		t.Errorf("repair prompt does not explain the rejection: %q", repairPrompt)
+	}
+
+	provider, _ = openAIStandIn(t, invalid, "```json\n"+invalid+"\n```")
+	_, usage, err = synthesizeDecision(context.Background(), provider, testDecisionInput())
+	if !errors.Is(err, errInvalidDecisionObject) {
+		t.Fatalf("error = %v, want errInvalidDecisionObject", err)
+	}
+	if usage.Calls != 1+maxDecisionRepairs {
+		t.Errorf("calls = %d, want %d", usage.Calls, 1+maxDecisionRepairs)
+	}
+}
+
+func TestOpenAIProviderErrors(t *testing.T) {
+	provider, _ := openAIStandIn(t, "{}")
+	provider.apiKey = "wrong"
+	_, err := provider.Complete(context.Background(), llmRequest{Schema: map[string]any{"type": "object"}})
+	var statusErr *llmStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized || statusErr.Provider != llmProviderOpenAI {
+		t.Fatalf("error = %v, want a 401 llmStatusError", err)
+	}
+
+	refusing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"","refusal":"I can't help with that"}}]}`))
+	}))
+	defer refusing.Close()
+	provider = &openAILLMProvider{baseURL: refusing.URL, model: "gpt-test", client: refusing.Client()}
+	if _, err := provider.Complete(context.Background(), llmRequest{}); err == nil || !strings.Contains(err.Error(), "refused") {
+		t.Fatalf("error = %v, want a refusal", err)
+	}
+}
+
+func TestAnthropicProviderUsesForcedTool(t *testing.T) {
+	var calls atomic.Int32
+	var first map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" || r.Header.Get("x-api-key") != "ak-test" || r.Header.Get("anthropic-version") != anthropicAPIVersion {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		input := json.RawMessage(`{"feature": ""}`)
+		if calls.Add(1) == 1 {
+			first = body
+		} else {
+			input = json.RawMessage(testDecisionJSON(t))
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"content": []any{
+				map[string]any{"type": "text", "text": "Recording the decision."},
+				map[string]any{"type": "tool_use", "name": decisionObjectSchemaName, "input": input},
+			},
+			"usage": map[string]any{"input_tokens": 80, "output_tokens": 30},
+		})
+	}))
+	defer server.Close()
+
+	provider := &anthropicLLMProvider{baseURL: server.URL, apiKey: "ak-test", model: "claude-test", client: server.Client()}
+	decision, usage, err := synthesizeDecision(context.Background(), provider, testDecisionInput())
+	if err != nil {
+		t.Fatalf("synthesize: %v", err)
+	}
+	if decision["feature"] != "Dark mode" || usage.Calls != 2 || usage.OutputTokens != 60 {
+		t.Fatalf("decision %v, usage %+v", decision["feature"], usage)
+	}
+
+	choice := first["tool_choice"].(map[string]any)
+	if choice["type"] != "tool" || choice["name"] != decisionObjectSchemaName {
+		t.Errorf("tool_choice = %v", choice)
+	}
+	tool := first["tools"].([]any)[0].(map[string]any)
+	if _, ok := tool["input_schema"].(map[string]any)["properties"]; !ok {
+		t.Errorf("tool has no input schema: %v", tool)
+	}
+	if first["system"] == "" {
+		t.Error("system prompt not sent")
+	}
+
+	noTool := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"hi"}]}`))
+	}))
+	defer noTool.Close()
+	provider = &anthropicLLMProvider{baseURL: noTool.URL, model: "claude-test", client: noTool.Client()}
+	if _, err := provider.Complete(context.Background(), llmRequest{SchemaName: decisionObjectSchemaName}); err == nil {
+		t.Fatal("expected an error without a tool_use block")
+	}
+}
+
+func TestFakeProviderIsDeterministic(t *testing.T) {
+	first, usage, err := synthesizeDecision(context.Background(), fakeLLMProvider{}, testDecisionInput())
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _, err := synthesizeDecision(context.Background(), fakeLLMProvider{}, testDecisionInput())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, _ := json.Marshal(first)
+	b, _ := json.Marshal(second)
+	if string(a) != string(b) || usage.Calls != 1 || usage.InputTokens == 0 {
+		t.Fatalf("fake provider output differs or usage missing: %+v", usage)
+	}
+	if first["recommendation"].(map[string]any)["priority"] != "High" {
+		t.Errorf("priority = %v, want High for 42 mentions with a competitor", first["recommendation"])
+	}
+}
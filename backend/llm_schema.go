@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// llmStrictUnsupportedKeywords are validation keywords that OpenAI strict
+// structured outputs rejects. They stay in the schema used for local
+// validation and are stripped from the copy sent to the API.
+var llmStrictUnsupportedKeywords = []string{"minLength", "maxLength", "minimum", "maximum", "minItems", "maxItems"}
+
+// strictLLMSchema returns a copy of schema without llmStrictUnsupportedKeywords.
+func strictLLMSchema(schema map[string]any) map[string]any {
+	out := make(map[string]any, len(schema))
+	for key, value := range schema {
+		if slices.Contains(llmStrictUnsupportedKeywords, key) {
+			continue
+		}
+		switch typed := value.(type) {
+		case map[string]any:
+			if key == "properties" {
+				properties := make(map[string]any, len(typed))
+				for name, child := range typed {
+					if childSchema, ok := child.(map[string]any); ok {
+						properties[name] = strictLLMSchema(childSchema)
+					} else {
+						properties[name] = child
+					}
+				}
+				out[key] = properties
+				continue
+			}
+			out[key] = strictLLMSchema(typed)
+		default:
+			out[key] = value
+		}
+	}
+	return out
+}
+
+// schemaNumber reads a numeric keyword. Schemas written as Go literals hold
+// ints, while schemas decoded from JSON hold float64.
+func schemaNumber(schema map[string]any, key string) (float64, bool) {
+	switch value := schema[key].(type) {
+	case int:
+		return float64(value), true
+	case int64:
+		return float64(value), true
+	case float64:
+		return value, true
+	case json.Number:
+		parsed, err := value.Float64()
+		return parsed, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// validateJSONSchema checks value against the subset of JSON Schema that
+// structured-output APIs accept: type, properties, required,
+// additionalProperties, items, enum, minLength/maxLength, minimum/maximum
+// and minItems/maxItems.
+func validateJSONSchema(schema map[string]any, value any, path string) error {
+	if path == "" {
+		path = "$"
+	}
+	if enum, ok := schema["enum"].([]any); ok && !slices.Contains(enum, value) {
+		return fmt.Errorf("%s: %v is not one of %v", path, value, enum)
+	}
+
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: expected object", path)
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		required, _ := schema["required"].([]any)
+		for _, key := range required {
+			name, _ := key.(string)
+			if _, exists := object[name]; !exists {
+				return fmt.Errorf("%s: missing required field %q", path, name)
+			}
+		}
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child, known := properties[key].(map[string]any)
+			if !known {
+				if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+					return fmt.Errorf("%s: unexpected field %q", path, key)
+				}
+				continue
+			}
+			if err := validateJSONSchema(child, object[key], path+"."+key); err != nil {
+				return err
+			}
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s: expected array", path)
+		}
+		if minItems, ok := schemaNumber(schema, "minItems"); ok && float64(len(items)) < minItems {
+			return fmt.Errorf("%s: expected at least %v items", path, minItems)
+		}
+		if maxItems, ok := schemaNumber(schema, "maxItems"); ok && float64(len(items)) > maxItems {
+			return fmt.Errorf("%s: expected at most %v items", path, maxItems)
+		}
+		if itemSchema, ok := schema["items"].(map[string]any); ok {
+			for idx, item := range items {
+				if err := validateJSONSchema(itemSchema, item, fmt.Sprintf("%s[%d]", path, idx)); err != nil {
+					return err
+				}
+			}
+		}
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s: expected string", path)
+		}
+		if minLength, ok := schemaNumber(schema, "minLength"); ok && float64(len(strings.TrimSpace(text))) < minLength {
+			return fmt.Errorf("%s: must be at least %v characters", path, minLength)
+		}
+		if maxLength, ok := schemaNumber(schema, "maxLength"); ok && float64(len([]rune(text))) > maxLength {
+			return fmt.Errorf("%s: must be at most %v characters", path, maxLength)
+		}
+	case "number", "integer":
+		number, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("%s: expected %s", path, schema["type"])
+		}
+		if schema["type"] == "integer" && number != math.Trunc(number) {
+			return fmt.Errorf("%s: expected integer", path)
+		}
+		if minimum, ok := schemaNumber(schema, "minimum"); ok && number < minimum {
+			return fmt.Errorf("%s: %v is below %v", path, number, minimum)
+		}
+		if maximum, ok := schemaNumber(schema, "maximum"); ok && number > maximum {
+			return fmt.Errorf("%s: %v is above %v", path, number, maximum)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected boolean", path)
+		}
+	}
+	return nil
+}
+
+// decodeSchemaJSON parses model output and validates it. Models occasionally
+// wrap JSON in a markdown fence, which is stripped first.
+func decodeSchemaJSON(text string, schema map[string]any) (map[string]any, error) {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text, "```json")
+		text = strings.TrimPrefix(text, "```")
+		text = strings.TrimSuffix(strings.TrimSpace(text), "```")
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal([]byte(text), &decoded); err != nil {
+		return nil, fmt.Errorf("output is not a JSON object: %w", err)
+	}
+	if err := validateJSONSchema(schema, decoded, ""); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestValidateJSONSchema(t *testing.T) {
+	literal := map[string]any{
+		"type":     "object",
+		"required": []any{"name", "tags", "score"},
+		"properties": map[string]any{
+			"name":  map[string]any{"type": "string", "minLength": 2},
+			"tags":  map[string]any{"type": "array", "minItems": 1, "maxItems": 2, "items": map[string]any{"type": "string"}},
+			"score": map[string]any{"type": "number", "minimum": 0, "maximum": 1},
+			"level": map[string]any{"type": "string", "enum": []any{"low", "high"}},
+			"count": map[string]any{"type": "integer"},
+		},
+		"additionalProperties": false,
+	}
+	// The same schema decoded from JSON holds float64 keywords.
+	var decoded map[string]any
+	blob, _ := json.Marshal(literal)
+	if err := json.Unmarshal(blob, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		wantErr string
+	}{
+		{"valid", `{"name":"ok","tags":["a"],"score":0.5,"level":"low","count":3}`, ""},
+		{"missing field", `{"name":"ok","tags":["a"]}`, `missing required field "score"`},
+		{"unexpected field", `{"name":"ok","tags":["a"],"score":1,"extra":true}`, `unexpected field "extra"`},
+		{"short string", `{"name":" x ","tags":["a"],"score":1}`, "at least 2 characters"},
+		{"too few items", `{"name":"ok","tags":[],"score":1}`, "at least 1 items"},
+		{"too many items", `{"name":"ok","tags":["a","b","c"],"score":1}`, "at most 2 items"},
+		{"below minimum", `{"name":"ok","tags":["a"],"score":-0.1}`, "is below 0"},
+		{"above maximum", `{"name":"ok","tags":["a"],"score":1.5}`, "is above 1"},
+		{"enum", `{"name":"ok","tags":["a"],"score":1,"level":"mid"}`, "is not one of"},
+		{"integer", `{"name":"ok","tags":["a"],"score":1,"count":1.5}`, "expected integer"},
+		{"item type", `{"name":"ok","tags":[1],"score":1}`, "$.tags[0]: expected string"},
+	}
+	for _, schema := range []struct {
+		name   string
+		schema map[string]any
+	}{{"go literal", literal}, {"decoded json", decoded}} {
+		for _, tt := range tests {
+			t.Run(schema.name+"/"+tt.name, func(t *testing.T) {
+				_, err := decodeSchemaJSON(tt.value, schema.schema)
+				if tt.wantErr == "" {
+					if err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					return
+				}
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+			})
+		}
+	}
+}
+
+func TestDecodeSchemaJSONStripsFence(t *testing.T) {
+	schema := map[string]any{"type": "object"}
+	if _, err := decodeSchemaJSON("```json\n{\"a\": 1}\n```", schema); err != nil {
+		t.Fatalf("fenced JSON rejected: %v", err)
+	}
+	if _, err := decodeSchemaJSON("not json", schema); err == nil {
+		t.Fatal("expected an error for non-JSON output")
+	}
+}
+
+func TestStrictLLMSchemaDropsUnsupportedKeywords(t *testing.T) {
+	strict := strictLLMSchema(decisionObjectSchema)
+	blob, err := json.Marshal(strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, keyword := range llmStrictUnsupportedKeywords {
+		if strings.Contains(string(blob), `"`+keyword+`"`) {
+			t.Errorf("strict schema still contains %s", keyword)
+		}
+	}
+	for _, kept := range []string{`"additionalProperties":false`, `"enum"`, `"required"`} {
+		if !strings.Contains(string(blob), kept) {
+			t.Errorf("strict schema lost %s", kept)
+		}
+	}
+
+	// The original keeps enforcing the limits locally.
+	assumptions := decisionObjectSchema["properties"].(map[string]any)["assumptions"].(map[string]any)
+	if _, ok := assumptions["minItems"]; !ok {
+		t.Fatal("strictLLMSchema modified the source schema")
+	}
+
+	// A property that happens to be named like a keyword is kept.
+	named := strictLLMSchema(schemaObject("minimum", "number"))
+	if _, ok := named["properties"].(map[string]any)["minimum"]; !ok {
+		t.Fatal("property named minimum was dropped")
+	}
+}
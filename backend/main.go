@@ -9,6 +9,7 @@ import (
 	"net/smtp"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -32,33 +33,67 @@ type okResponse struct {
 }
 
 var (
-	smtpHost               string
-	smtpPort               string
-	smtpUser               string
-	smtpPass               string
-	smtpFrom               string
-	smtpTo                 string
-	waToken                string
-	waPhoneID              string
-	waTo                   string
-	waTemplate             string
-	waLang                 string
-	slackClientID          string
-	slackClientSecret      string
-	slackSigningSecret     string
-	slackRedirectURL       string
-	slackBotScopes         string
-	appUIBaseURL           string
-	integrationsStatePath  string
-	integrationsEncryptKey string
-	integrationsKeyPath    string
-	supabaseURL            string
-	supabaseServiceRoleKey string
-	supabaseSignalsTable   string
-	decisionOperatorAPIURL string
-	tinyFishBaseURL        string
-	tinyFishAPIKey         string
-	agentDemoMode          bool
+	smtpHost                 string
+	smtpPort                 string
+	smtpUser                 string
+	smtpPass                 string
+	smtpFrom                 string
+	smtpTo                   string
+	waToken                  string
+	waPhoneID                string
+	waTo                     string
+	waTemplate               string
+	waLang                   string
+	slackClientID            string
+	slackClientSecret        string
+	slackSigningSecret       string
+	slackRedirectURL         string
+	slackBotScopes           string
+	appUIBaseURL             string
+	integrationsStatePath    string
+	integrationsEncryptKey   string
+	integrationsKeyPath      string
+	agentRunsDir             string
+	supabaseURL              string
+	supabaseServiceRoleKey   string
+	supabaseSignalsTable     string
+	decisionOperatorAPIURL   string
+	tinyFishBaseURL          string
+	tinyFishAPIKey           string
+	tinyFishMode             string
+	tinyFishFixturesPath     string
+	tinyFishMaxRetries       int
+	tinyFishRetryBaseDelay   time.Duration
+	tinyFishRetryMaxDelay    time.Duration
+	tinyFishBreakerThreshold int
+	tinyFishBreakerCooldown  time.Duration
+	tinyFishCacheEnabled     bool
+	tinyFishCacheDir         string
+	tinyFishCacheTTL         time.Duration
+	tinyFishCacheMaxBytes    int
+	agentDemoMode            bool
+	agentRunTimeout          time.Duration
+	agentStepTimeout         time.Duration
+	agentMaxSessions         int
+	agentMaxConcurrentVisits int
+	agentMaxVisitsPerDomain  int
+	agentCredibilityPath     string
+	agentBriefTemplatesDir   string
+	llmProviderName          string
+	llmBaseURL               string
+	llmAPIKey                string
+	llmModel                 string
+	llmTimeout               time.Duration
+	llmInputCostPerMTok      float64
+	llmOutputCostPerMTok     float64
+	fallbackCompetitors      string
+	fallbackMaxRuns          int
+	fallbackRunRetention     time.Duration
+	linearAPIURL             string
+	githubAPIURL             string
+	discordAPIURL            string
+	teamsGraphAPIURL         string
+	teamsLoginURL            string
 )
 
 func main() {
@@ -67,6 +102,13 @@ func main() {
 	if err := initIntegrations(); err != nil {
 		log.Fatalf("failed to initialize integrations subsystem: %v", err)
 	}
+	startTeamsSubscriptionRenewal()
+	if err := initAgentRuns(); err != nil {
+		log.Fatalf("failed to initialize agent run store: %v", err)
+	}
+	if err := initOperatorLocalStore(); err != nil {
+		log.Fatalf("failed to initialize local decision run store: %v", err)
+	}
 
 	// Initialize Clerk
 	clerkSecretKey := strings.TrimSpace(os.Getenv("CLERK_SECRET_KEY"))
@@ -80,24 +122,23 @@ func main() {
 	// Public routes
 	mux.HandleFunc("/health", handleHealth)
 	mux.HandleFunc("/api/integrations", handleIntegrations)
-	mux.HandleFunc("/api/integrations/slack/connect-url", handleSlackConnectURL)
-	mux.HandleFunc("/api/integrations/slack/setup", handleSlackSetup)
-	mux.HandleFunc("/api/integrations/slack/setup/validate", handleSlackSetupValidate)
-	mux.HandleFunc("/api/integrations/slack/callback", handleSlackCallback)
-	mux.HandleFunc("/api/integrations/slack/channels", handleSlackChannels)
-	mux.HandleFunc("/api/integrations/slack/channels/import", handleSlackChannelsImport)
-	mux.HandleFunc("/api/integrations/slack/webhook", handleSlackWebhook)
-	mux.HandleFunc("/api/integrations/slack/disconnect", handleSlackDisconnect)
+	integrationProviders.Mount(mux)
 	mux.HandleFunc("/api/signals", handleSignals)
 	mux.HandleFunc("/api/operator/health", handleOperatorHealth)
 	mux.HandleFunc("/api/operator/connections/slack/import-from-state", handleOperatorSlackImportFromState)
 	mux.HandleFunc("/api/operator/connections/slack", handleOperatorSlackConnection)
 	mux.HandleFunc("/api/operator/connections/jira", handleOperatorJiraConnection)
+	mux.HandleFunc("/api/operator/connections/jira/projects", handleOperatorJiraProjects)
+	mux.HandleFunc("/api/operator/connections/linear", handleOperatorLinearConnection)
+	mux.HandleFunc("/api/operator/connections/linear/teams", handleOperatorLinearTeams)
 	mux.HandleFunc("/api/operator/decision-runs", handleOperatorDecisionRuns)
 	mux.HandleFunc("/api/operator/decision-runs/", handleOperatorDecisionRunByID)
 	mux.HandleFunc("/api/agent/config", handleAgentConfig)
 	mux.HandleFunc("/api/agent/feature-research", handleAgentFeatureResearch)
+	mux.HandleFunc("/api/agent/feature-research/plan", handleAgentFeatureResearchPlan)
 	mux.HandleFunc("/api/agent/runs/", handleAgentRuns)
+	mux.HandleFunc("/api/agent/brief-templates", handleAgentBriefTemplates)
+	mux.HandleFunc("/api/agent/brief-templates/", handleAgentBriefTemplates)
 
 	// Lead API - could be protected or public depending on requirements
 	// For now keeping it open as it's a contact form
@@ -154,13 +195,47 @@ func loadConfig() {
 	integrationsEncryptKey = strings.TrimSpace(os.Getenv("INTEGRATIONS_ENCRYPTION_KEY"))
 	integrationsStatePath = strings.TrimSpace(os.Getenv("INTEGRATIONS_STATE_PATH"))
 	integrationsKeyPath = strings.TrimSpace(os.Getenv("INTEGRATIONS_KEY_PATH"))
+	agentRunsDir = strings.TrimSpace(os.Getenv("AGENT_RUNS_DIR"))
+	agentCredibilityPath = strings.TrimSpace(os.Getenv("AGENT_CREDIBILITY_PATH"))
+	agentBriefTemplatesDir = strings.TrimSpace(os.Getenv("AGENT_BRIEF_TEMPLATES_DIR"))
 	supabaseURL = strings.TrimSpace(os.Getenv("SUPABASE_URL"))
 	supabaseServiceRoleKey = strings.TrimSpace(os.Getenv("SUPABASE_SERVICE_ROLE_KEY"))
 	supabaseSignalsTable = strings.TrimSpace(os.Getenv("SUPABASE_SIGNALS_TABLE"))
 	decisionOperatorAPIURL = strings.TrimSpace(os.Getenv("DECISION_OPERATOR_API_BASE_URL"))
 	tinyFishBaseURL = strings.TrimSpace(os.Getenv("TINYFISH_BASE_URL"))
 	tinyFishAPIKey = strings.TrimSpace(os.Getenv("TINYFISH_API_KEY"))
+	tinyFishMode = strings.ToLower(strings.TrimSpace(os.Getenv("TINYFISH_MODE")))
+	tinyFishFixturesPath = strings.TrimSpace(os.Getenv("TINYFISH_FIXTURES_PATH"))
+	tinyFishMaxRetries = parseIntEnv(os.Getenv("TINYFISH_MAX_RETRIES"), defaultTinyFishMaxRetries)
+	tinyFishRetryBaseDelay = parseDurationEnv(os.Getenv("TINYFISH_RETRY_BASE_DELAY"), defaultTinyFishRetryBaseDelay)
+	tinyFishRetryMaxDelay = parseDurationEnv(os.Getenv("TINYFISH_RETRY_MAX_DELAY"), defaultTinyFishRetryMaxDelay)
+	tinyFishBreakerThreshold = parseIntEnv(os.Getenv("TINYFISH_BREAKER_THRESHOLD"), defaultTinyFishBreakerThreshold)
+	tinyFishBreakerCooldown = parseDurationEnv(os.Getenv("TINYFISH_BREAKER_COOLDOWN"), defaultTinyFishBreakerCooldown)
+	tinyFishCacheEnabled = parseBoolEnv(os.Getenv("TINYFISH_CACHE_ENABLED"), true)
+	tinyFishCacheDir = strings.TrimSpace(os.Getenv("TINYFISH_CACHE_DIR"))
+	tinyFishCacheTTL = parseDurationEnv(os.Getenv("TINYFISH_CACHE_TTL"), defaultTinyFishCacheTTL)
+	tinyFishCacheMaxBytes = parseIntEnv(os.Getenv("TINYFISH_CACHE_MAX_BYTES"), defaultTinyFishCacheMaxBytes)
 	agentDemoMode = parseBoolEnv(os.Getenv("DEMO_MODE"), true)
+	agentRunTimeout = parseDurationEnv(os.Getenv("AGENT_RUN_TIMEOUT"), defaultAgentRunTimeout)
+	agentStepTimeout = parseDurationEnv(os.Getenv("AGENT_STEP_TIMEOUT"), defaultAgentStepTimeout)
+	agentMaxSessions = parseIntEnv(os.Getenv("AGENT_MAX_SESSIONS"), defaultAgentMaxSessions)
+	agentMaxConcurrentVisits = parseIntEnv(os.Getenv("AGENT_MAX_CONCURRENT_VISITS"), defaultAgentMaxConcurrentVisits)
+	agentMaxVisitsPerDomain = parseIntEnv(os.Getenv("AGENT_MAX_VISITS_PER_DOMAIN"), defaultAgentMaxVisitsPerDomain)
+	llmProviderName = strings.ToLower(strings.TrimSpace(os.Getenv("LLM_PROVIDER")))
+	llmBaseURL = strings.TrimSpace(os.Getenv("LLM_BASE_URL"))
+	llmAPIKey = strings.TrimSpace(os.Getenv("LLM_API_KEY"))
+	llmModel = strings.TrimSpace(os.Getenv("LLM_MODEL"))
+	llmTimeout = parseDurationEnv(os.Getenv("LLM_TIMEOUT"), defaultLLMTimeout)
+	llmInputCostPerMTok = parseFloatEnv(os.Getenv("LLM_INPUT_COST_PER_MTOK"), 0)
+	llmOutputCostPerMTok = parseFloatEnv(os.Getenv("LLM_OUTPUT_COST_PER_MTOK"), 0)
+	fallbackCompetitors = strings.TrimSpace(os.Getenv("DECISION_FALLBACK_COMPETITORS"))
+	fallbackMaxRuns = parseIntEnv(os.Getenv("DECISION_FALLBACK_MAX_RUNS"), defaultFallbackMaxRuns)
+	fallbackRunRetention = parseDurationEnv(os.Getenv("DECISION_FALLBACK_RUN_RETENTION"), defaultFallbackRunRetention)
+	linearAPIURL = strings.TrimSpace(os.Getenv("LINEAR_API_URL"))
+	githubAPIURL = strings.TrimSpace(os.Getenv("GITHUB_API_URL"))
+	discordAPIURL = strings.TrimSpace(os.Getenv("DISCORD_API_URL"))
+	teamsGraphAPIURL = strings.TrimSpace(os.Getenv("TEAMS_GRAPH_API_URL"))
+	teamsLoginURL = strings.TrimSpace(os.Getenv("TEAMS_LOGIN_URL"))
 
 	if slackRedirectURL == "" {
 		log.Println("INFO: SLACK_REDIRECT_URL not set. It will be auto-generated by Slack setup wizard.")
@@ -177,6 +252,12 @@ func loadConfig() {
 	if integrationsKeyPath == "" {
 		integrationsKeyPath = filepath.Join("data", "integrations.key")
 	}
+	if agentRunsDir == "" {
+		agentRunsDir = filepath.Join("data", "agent_runs")
+	}
+	if agentBriefTemplatesDir == "" {
+		agentBriefTemplatesDir = filepath.Join("data", "brief_templates")
+	}
 	if supabaseSignalsTable == "" {
 		supabaseSignalsTable = "signals"
 	}
@@ -186,6 +267,46 @@ func loadConfig() {
 	if tinyFishBaseURL == "" {
 		tinyFishBaseURL = "http://localhost:8787"
 	}
+	switch tinyFishMode {
+	case "", tinyFishModeLive:
+		tinyFishMode = tinyFishModeLive
+	case tinyFishModeRecord, tinyFishModeReplay:
+	default:
+		log.Printf("WARNING: unknown TINYFISH_MODE %q, using live adapter", tinyFishMode)
+		tinyFishMode = tinyFishModeLive
+	}
+	if tinyFishFixturesPath == "" {
+		tinyFishFixturesPath = filepath.Join("data", "tinyfish_fixtures.json")
+	}
+	switch llmProviderName {
+	case "", llmProviderFake:
+		llmProviderName = llmProviderFake
+	case llmProviderOpenAI, llmProviderAnthropic:
+	default:
+		log.Printf("WARNING: unknown LLM_PROVIDER %q, using fake provider", llmProviderName)
+		llmProviderName = llmProviderFake
+	}
+	if tinyFishCacheDir == "" {
+		tinyFishCacheDir = filepath.Join("data", "tinyfish_cache")
+	}
+	if fallbackCompetitors == "" {
+		fallbackCompetitors = defaultFallbackCompetitors
+	}
+	if linearAPIURL == "" {
+		linearAPIURL = defaultLinearAPIURL
+	}
+	if githubAPIURL == "" {
+		githubAPIURL = defaultGitHubAPIURL
+	}
+	if discordAPIURL == "" {
+		discordAPIURL = defaultDiscordAPIURL
+	}
+	if teamsGraphAPIURL == "" {
+		teamsGraphAPIURL = defaultTeamsGraphAPIURL
+	}
+	if teamsLoginURL == "" {
+		teamsLoginURL = defaultTeamsLoginURL
+	}
 
 	if slackClientID == "" || slackClientSecret == "" {
 		log.Println("INFO: Slack OAuth env config not set. You can configure Slack from the UI setup wizard.")
@@ -359,6 +480,45 @@ func parseBoolEnv(raw string, defaultValue bool) bool {
 	}
 }
 
+func parseIntEnv(raw string, defaultValue int) int {
+	normalized := strings.TrimSpace(raw)
+	if normalized == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.Atoi(normalized)
+	if err != nil || parsed < 0 {
+		log.Printf("WARNING: invalid integer %q, using %d", raw, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+func parseFloatEnv(raw string, defaultValue float64) float64 {
+	normalized := strings.TrimSpace(raw)
+	if normalized == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseFloat(normalized, 64)
+	if err != nil || parsed < 0 {
+		log.Printf("WARNING: invalid number %q, using %g", raw, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+func parseDurationEnv(raw string, defaultValue time.Duration) time.Duration {
+	normalized := strings.TrimSpace(raw)
+	if normalized == "" {
+		return defaultValue
+	}
+	parsed, err := time.ParseDuration(normalized)
+	if err != nil || parsed <= 0 {
+		log.Printf("WARNING: invalid duration %q, using %s", raw, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
 func sendEmail(host, port, user, pass, from, to string, payload leadPayload) error {
 	auth := smtp.PlainAuth("", user, pass, host)
 	addr := fmt.Sprintf("%s:%s", host, port)
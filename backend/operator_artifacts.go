@@ -0,0 +1,507 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	artifactTypeSlackSignals   = "slack_signals"
+	artifactTypeCompetitorScan = "competitor_scan"
+	artifactTypeDecisionObject = "decision_object"
+	artifactTypeJiraEpic       = "jira_epic"
+	artifactTypeLinearIssue    = "linear_issue"
+	artifactTypeGitHubIssue    = "github_issue"
+	artifactTypeRunLogs        = "run_logs"
+
+	runErrorArtifactValidation = "artifact_validation_failed"
+)
+
+var (
+	decisionRiskLevels     = []string{"low", "medium", "high"}
+	decisionPriorities     = []string{"High", "Medium", "Low"}
+	runLogStatuses         = []string{"queued", "running", "success", "failed", "skipped", "cancelled"}
+	errArtifactFieldsEmpty = errors.New("must not be empty")
+)
+
+type decisionObject struct {
+	Feature        string                 `json:"feature"`
+	Signals        decisionSignals        `json:"signals"`
+	Competitors    []decisionCompetitor   `json:"competitors"`
+	Assumptions    []decisionAssumption   `json:"assumptions"`
+	Recommendation decisionRecommendation `json:"recommendation"`
+}
+
+type decisionSignals struct {
+	TotalMentions int               `json:"total_mentions"`
+	TopChannels   []decisionChannel `json:"top_channels"`
+	Themes        []decisionTheme   `json:"themes"`
+	SampleQuotes  []decisionQuote   `json:"sample_quotes"`
+}
+
+type decisionAssumption struct {
+	Statement  string `json:"statement"`
+	Risk       string `json:"risk"`
+	Validation string `json:"validation"`
+	Metric     string `json:"metric"`
+}
+
+type decisionRecommendation struct {
+	Priority   string   `json:"priority"`
+	Confidence float64  `json:"confidence"`
+	NextSteps  []string `json:"next_steps"`
+}
+
+type slackSignalsArtifact struct {
+	TotalMentions int                  `json:"total_mentions"`
+	Channels      []decisionChannel    `json:"channels"`
+	Messages      []slackSignalMessage `json:"messages"`
+	Themes        []slackSignalTheme   `json:"themes"`
+}
+
+type slackSignalMessage struct {
+	Text      string `json:"text"`
+	User      string `json:"user"`
+	TS        string `json:"ts"`
+	Permalink string `json:"permalink,omitempty"`
+}
+
+type slackSignalTheme struct {
+	Label      string       `json:"label"`
+	Count      int          `json:"count"`
+	Confidence float64      `json:"confidence,omitempty"`
+	Keywords   []string     `json:"keywords,omitempty"`
+	Quotes     []themeQuote `json:"quotes,omitempty"`
+}
+
+type competitorScanArtifact struct {
+	Feature  string              `json:"feature"`
+	Findings []competitorFinding `json:"findings"`
+}
+
+type competitorFinding struct {
+	Competitor string `json:"competitor"`
+	Page       string `json:"page"`
+	Evidence   string `json:"evidence"`
+	URL        string `json:"url"`
+}
+
+type jiraEpicArtifact struct {
+	EpicKey string           `json:"epic_key"`
+	URL     string           `json:"url"`
+	Stories []jiraStoryIssue `json:"stories,omitempty"`
+}
+
+type jiraStoryIssue struct {
+	Key     string `json:"key"`
+	Summary string `json:"summary"`
+	URL     string `json:"url"`
+}
+
+type linearIssueArtifact struct {
+	Kind       string           `json:"kind"`
+	Identifier string           `json:"identifier"`
+	URL        string           `json:"url"`
+	Children   []linearIssueRef `json:"children,omitempty"`
+}
+
+type linearIssueRef struct {
+	Identifier string `json:"identifier"`
+	Title      string `json:"title"`
+	URL        string `json:"url"`
+}
+
+type githubIssueArtifact struct {
+	Repo   string `json:"repo"`
+	Number int    `json:"number"`
+	URL    string `json:"url"`
+}
+
+type runLogEntry struct {
+	Step    string `json:"step"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	At      string `json:"at"`
+}
+
+type artifactValidationError struct {
+	ArtifactID string `json:"artifact_id,omitempty"`
+	Type       string `json:"type"`
+	Message    string `json:"error"`
+}
+
+func (e artifactValidationError) Error() string {
+	return fmt.Sprintf("%s artifact %s is invalid: %s", e.Type, e.ArtifactID, e.Message)
+}
+
+// validateUpstreamArtifacts checks every known artifact from the upstream
+// operator against its Go type and returns the artifacts that passed. Unknown
+// types pass through so the upstream can add artifacts ahead of this backend.
+func validateUpstreamArtifacts(artifacts []map[string]any) ([]map[string]any, []artifactValidationError) {
+	valid := make([]map[string]any, 0, len(artifacts))
+	var failures []artifactValidationError
+	for _, artifact := range artifacts {
+		artifactType := stringFromAny(artifact["type"])
+		if err := validateUpstreamArtifact(artifactType, artifact["json"]); err != nil {
+			failures = append(failures, artifactValidationError{
+				ArtifactID: stringFromAny(artifact["id"]),
+				Type:       artifactType,
+				Message:    err.Error(),
+			})
+			continue
+		}
+		valid = append(valid, artifact)
+	}
+	return valid, failures
+}
+
+// validateOperatorArtifact checks an artifact this backend produced. Fields
+// the Go type does not declare are rejected, since they can only come from a
+// bug here.
+func validateOperatorArtifact(artifactType string, payload any) error {
+	return validateArtifact(artifactType, payload, true)
+}
+
+// validateUpstreamArtifact checks an artifact proxied from the upstream
+// operator. Required fields and types are enforced, but extra fields are
+// tolerated so the upstream can extend an artifact ahead of this backend.
+func validateUpstreamArtifact(artifactType string, payload any) error {
+	return validateArtifact(artifactType, payload, false)
+}
+
+func validateArtifact(artifactType string, payload any, strict bool) error {
+	if strings.TrimSpace(artifactType) == "" {
+		return errors.New("type is missing")
+	}
+	if payload == nil {
+		return errors.New("json is missing")
+	}
+	switch artifactType {
+	case artifactTypeDecisionObject:
+		var decoded decisionObject
+		if err := decodeArtifact(payload, &decoded, strict); err != nil {
+			return err
+		}
+		return decoded.validate()
+	case artifactTypeSlackSignals:
+		var decoded slackSignalsArtifact
+		if err := decodeArtifact(payload, &decoded, strict); err != nil {
+			return err
+		}
+		return decoded.validate()
+	case artifactTypeCompetitorScan:
+		var decoded competitorScanArtifact
+		if err := decodeArtifact(payload, &decoded, strict); err != nil {
+			return err
+		}
+		return decoded.validate()
+	case artifactTypeJiraEpic:
+		var decoded jiraEpicArtifact
+		if err := decodeArtifact(payload, &decoded, strict); err != nil {
+			return err
+		}
+		return decoded.validate()
+	case artifactTypeLinearIssue:
+		var decoded linearIssueArtifact
+		if err := decodeArtifact(payload, &decoded, strict); err != nil {
+			return err
+		}
+		return decoded.validate()
+	case artifactTypeGitHubIssue:
+		var decoded githubIssueArtifact
+		if err := decodeArtifact(payload, &decoded, strict); err != nil {
+			return err
+		}
+		return decoded.validate()
+	case artifactTypeRunLogs:
+		var decoded []runLogEntry
+		if err := decodeArtifact(payload, &decoded, strict); err != nil {
+			return err
+		}
+		for idx, entry := range decoded {
+			if err := entry.validate(strict); err != nil {
+				return fmt.Errorf("[%d]: %w", idx, err)
+			}
+		}
+	}
+	return nil
+}
+
+// decodeArtifactStrict round-trips payload through JSON into out, rejecting
+// fields the type does not declare.
+func decodeArtifactStrict(payload any, out any) error {
+	return decodeArtifact(payload, out, true)
+}
+
+// decodeArtifact round-trips payload through JSON into out. Type mismatches
+// always fail; unknown fields fail only when strict is set.
+func decodeArtifact(payload any, out any, strict bool) error {
+	blob, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(blob))
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(out); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (d decisionObject) validate() error {
+	if strings.TrimSpace(d.Feature) == "" {
+		return fieldError("feature", errArtifactFieldsEmpty)
+	}
+	if d.Signals.TotalMentions < 0 {
+		return fieldError("signals.total_mentions", errors.New("must not be negative"))
+	}
+	for idx, channel := range d.Signals.TopChannels {
+		if err := channel.validate(); err != nil {
+			return fieldError(fmt.Sprintf("signals.top_channels[%d]", idx), err)
+		}
+	}
+	for idx, theme := range d.Signals.Themes {
+		if strings.TrimSpace(theme.Label) == "" || theme.Count < 0 {
+			return fieldError(fmt.Sprintf("signals.themes[%d]", idx), errors.New("needs a label and a non-negative count"))
+		}
+	}
+	for idx, quote := range d.Signals.SampleQuotes {
+		if strings.TrimSpace(quote.Text) == "" {
+			return fieldError(fmt.Sprintf("signals.sample_quotes[%d].text", idx), errArtifactFieldsEmpty)
+		}
+		if err := validateArtifactURL(quote.URL, true); err != nil {
+			return fieldError(fmt.Sprintf("signals.sample_quotes[%d].url", idx), err)
+		}
+	}
+	for idx, competitor := range d.Competitors {
+		if strings.TrimSpace(competitor.Name) == "" || strings.TrimSpace(competitor.Evidence) == "" {
+			return fieldError(fmt.Sprintf("competitors[%d]", idx), errors.New("needs a name and evidence"))
+		}
+		if err := validateArtifactURL(competitor.URL, false); err != nil {
+			return fieldError(fmt.Sprintf("competitors[%d].url", idx), err)
+		}
+	}
+	if len(d.Assumptions) == 0 {
+		return fieldError("assumptions", errArtifactFieldsEmpty)
+	}
+	for idx, assumption := range d.Assumptions {
+		if strings.TrimSpace(assumption.Statement) == "" || strings.TrimSpace(assumption.Validation) == "" || strings.TrimSpace(assumption.Metric) == "" {
+			return fieldError(fmt.Sprintf("assumptions[%d]", idx), errors.New("needs a statement, validation and metric"))
+		}
+		if !slices.Contains(decisionRiskLevels, assumption.Risk) {
+			return fieldError(fmt.Sprintf("assumptions[%d].risk", idx), fmt.Errorf("%q is not one of %v", assumption.Risk, decisionRiskLevels))
+		}
+	}
+	if !slices.Contains(decisionPriorities, d.Recommendation.Priority) {
+		return fieldError("recommendation.priority", fmt.Errorf("%q is not one of %v", d.Recommendation.Priority, decisionPriorities))
+	}
+	if d.Recommendation.Confidence < 0 || d.Recommendation.Confidence > 1 {
+		return fieldError("recommendation.confidence", fmt.Errorf("%v is outside [0, 1]", d.Recommendation.Confidence))
+	}
+	if len(d.Recommendation.NextSteps) == 0 {
+		return fieldError("recommendation.next_steps", errArtifactFieldsEmpty)
+	}
+	for idx, step := range d.Recommendation.NextSteps {
+		if strings.TrimSpace(step) == "" {
+			return fieldError(fmt.Sprintf("recommendation.next_steps[%d]", idx), errArtifactFieldsEmpty)
+		}
+	}
+	return nil
+}
+
+func (c decisionChannel) validate() error {
+	if strings.TrimSpace(c.Name) == "" || c.Count < 0 {
+		return errors.New("needs a name and a non-negative count")
+	}
+	return nil
+}
+
+func (s slackSignalsArtifact) validate() error {
+	if s.TotalMentions < 0 {
+		return fieldError("total_mentions", errors.New("must not be negative"))
+	}
+	for idx, channel := range s.Channels {
+		if err := channel.validate(); err != nil {
+			return fieldError(fmt.Sprintf("channels[%d]", idx), err)
+		}
+	}
+	for idx, message := range s.Messages {
+		if strings.TrimSpace(message.Text) == "" {
+			return fieldError(fmt.Sprintf("messages[%d].text", idx), errArtifactFieldsEmpty)
+		}
+		if err := validateArtifactURL(message.Permalink, true); err != nil {
+			return fieldError(fmt.Sprintf("messages[%d].permalink", idx), err)
+		}
+	}
+	for idx, theme := range s.Themes {
+		if strings.TrimSpace(theme.Label) == "" || theme.Count < 0 {
+			return fieldError(fmt.Sprintf("themes[%d]", idx), errors.New("needs a label and a non-negative count"))
+		}
+	}
+	return nil
+}
+
+func (c competitorScanArtifact) validate() error {
+	if strings.TrimSpace(c.Feature) == "" {
+		return fieldError("feature", errArtifactFieldsEmpty)
+	}
+	for idx, finding := range c.Findings {
+		if strings.TrimSpace(finding.Competitor) == "" || strings.TrimSpace(finding.Evidence) == "" {
+			return fieldError(fmt.Sprintf("findings[%d]", idx), errors.New("needs a competitor and evidence"))
+		}
+		if err := validateArtifactURL(finding.URL, false); err != nil {
+			return fieldError(fmt.Sprintf("findings[%d].url", idx), err)
+		}
+	}
+	return nil
+}
+
+func (j jiraEpicArtifact) validate() error {
+	if err := validateJiraIssueKey(j.EpicKey); err != nil {
+		return fieldError("epic_key", err)
+	}
+	if err := validateArtifactURL(j.URL, false); err != nil {
+		return fieldError("url", err)
+	}
+	for idx, story := range j.Stories {
+		if err := validateJiraIssueKey(story.Key); err != nil {
+			return fieldError(fmt.Sprintf("stories[%d].key", idx), err)
+		}
+		if err := validateArtifactURL(story.URL, false); err != nil {
+			return fieldError(fmt.Sprintf("stories[%d].url", idx), err)
+		}
+	}
+	return nil
+}
+
+func (l linearIssueArtifact) validate() error {
+	if l.Kind != linearTargetIssue && l.Kind != linearTargetProject {
+		return fieldError("kind", fmt.Errorf("%q is not one of %s, %s", l.Kind, linearTargetIssue, linearTargetProject))
+	}
+	if strings.TrimSpace(l.Identifier) == "" {
+		return fieldError("identifier", errArtifactFieldsEmpty)
+	}
+	if err := validateArtifactURL(l.URL, false); err != nil {
+		return fieldError("url", err)
+	}
+	for idx, child := range l.Children {
+		if strings.TrimSpace(child.Identifier) == "" {
+			return fieldError(fmt.Sprintf("children[%d].identifier", idx), errArtifactFieldsEmpty)
+		}
+		if err := validateArtifactURL(child.URL, false); err != nil {
+			return fieldError(fmt.Sprintf("children[%d].url", idx), err)
+		}
+	}
+	return nil
+}
+
+func (g githubIssueArtifact) validate() error {
+	if !githubRepoPattern.MatchString(g.Repo) {
+		return fieldError("repo", fmt.Errorf("%q is not an owner/name repository", g.Repo))
+	}
+	if g.Number < 1 {
+		return fieldError("number", errors.New("must be positive"))
+	}
+	return fieldError("url", validateArtifactURL(g.URL, false))
+}
+
+func validateJiraIssueKey(key string) error {
+	project, number, found := strings.Cut(strings.TrimSpace(key), "-")
+	if !found || project == "" || number == "" || strings.ToUpper(project) != project || strings.Trim(number, "0123456789") != "" {
+		return fmt.Errorf("%q is not a Jira issue key", key)
+	}
+	return nil
+}
+
+// validate checks a run log entry. Statuses are only held to runLogStatuses
+// for logs this backend wrote; the upstream operator has its own status names
+// and one unfamiliar status must not fail its whole run.
+func (e runLogEntry) validate(strict bool) error {
+	if strings.TrimSpace(e.Step) == "" {
+		return fieldError("step", errArtifactFieldsEmpty)
+	}
+	if strict && !slices.Contains(runLogStatuses, e.Status) {
+		return fieldError("status", fmt.Errorf("%q is not one of %v", e.Status, runLogStatuses))
+	}
+	if _, err := time.Parse(time.RFC3339, e.At); err != nil {
+		return fieldError("at", errors.New("must be an RFC 3339 timestamp"))
+	}
+	return nil
+}
+
+func validateArtifactURL(raw string, optional bool) error {
+	if strings.TrimSpace(raw) == "" {
+		if optional {
+			return nil
+		}
+		return errArtifactFieldsEmpty
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return fmt.Errorf("%q is not an http(s) URL", raw)
+	}
+	return nil
+}
+
+func fieldError(field string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%s: %w", field, err)
+}
+
+func artifactValidationMessage(failures []artifactValidationError) string {
+	parts := make([]string, 0, len(failures))
+	for _, failure := range failures {
+		parts = append(parts, failure.Error())
+	}
+	return "Artifact validation failed: " + strings.Join(parts, "; ")
+}
+
+// validateDecisionRunDetail applies upstream artifact validation to a decision
+// run detail payload from the upstream operator. Invalid artifacts are removed
+// and the run is reported as failed with a validation error code.
+func validateDecisionRunDetail(body []byte) ([]byte, bool) {
+	var detail map[string]any
+	if err := json.Unmarshal(body, &detail); err != nil {
+		return body, false
+	}
+	rawArtifacts, ok := detail["artifacts"].([]any)
+	if !ok {
+		return body, false
+	}
+	artifacts := make([]map[string]any, 0, len(rawArtifacts))
+	var failures []artifactValidationError
+	for _, raw := range rawArtifacts {
+		artifact, ok := raw.(map[string]any)
+		if !ok {
+			failures = append(failures, artifactValidationError{Type: "unknown", Message: "artifact is not an object"})
+			continue
+		}
+		artifacts = append(artifacts, artifact)
+	}
+	valid, invalid := validateUpstreamArtifacts(artifacts)
+	failures = append(failures, invalid...)
+	if len(failures) == 0 {
+		return body, false
+	}
+
+	detail["artifacts"] = valid
+	detail["status"] = "failed"
+	detail["error"] = artifactValidationMessage(failures)
+	detail["error_code"] = runErrorArtifactValidation
+	detail["validation_errors"] = failures
+	rewritten, err := json.Marshal(detail)
+	if err != nil {
+		return body, false
+	}
+	return rewritten, true
+}
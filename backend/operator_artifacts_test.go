@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestArtifactStrictnessDependsOnOrigin(t *testing.T) {
+	withExtra := map[string]any{
+		"epic_key":  "PROD-12",
+		"url":       "https://acme.atlassian.net/browse/PROD-12",
+		"assignee":  "upstream-only",
+		"createdBy": map[string]any{"id": 7},
+	}
+	if err := validateOperatorArtifact(artifactTypeJiraEpic, withExtra); err == nil || !strings.Contains(err.Error(), "unknown field") {
+		t.Fatalf("local artifact with extra fields: %v, want unknown field error", err)
+	}
+	if err := validateUpstreamArtifact(artifactTypeJiraEpic, withExtra); err != nil {
+		t.Fatalf("upstream artifact with extra fields rejected: %v", err)
+	}
+	logs := []any{map[string]any{"step": "synthesize", "status": "error", "at": "2026-03-01T00:00:00Z"}}
+	if err := validateOperatorArtifact(artifactTypeRunLogs, logs); err == nil || !strings.Contains(err.Error(), "status") {
+		t.Fatalf("local log with unknown status: %v, want status error", err)
+	}
+
+	tests := []struct {
+		name         string
+		artifactType string
+		payload      any
+		wantErr      string
+	}{
+		{"wrong type", artifactTypeGitHubIssue, map[string]any{"repo": "acme/app", "number": "12", "url": "https://github.com/acme/app/issues/12"}, "cannot unmarshal"},
+		{"missing required field", artifactTypeJiraEpic, map[string]any{"url": "https://acme.atlassian.net/browse/PROD-12", "extra": true}, "epic_key"},
+		{"invalid value", artifactTypeLinearIssue, map[string]any{"kind": "epic", "identifier": "ENG-1", "url": "https://linear.app/x", "extra": 1}, "kind"},
+		{"missing payload", artifactTypeDecisionObject, nil, "json is missing"},
+		{"unknown artifact type", "future_artifact", map[string]any{"anything": true}, ""},
+		{"upstream log status", artifactTypeRunLogs, []any{map[string]any{"step": "synthesize", "status": "completed", "at": "2026-03-01T00:00:00Z"}}, ""},
+		{"log without step", artifactTypeRunLogs, []any{map[string]any{"status": "error", "at": "2026-03-01T00:00:00Z"}}, "step"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateUpstreamArtifact(tt.artifactType, tt.payload)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateDecisionRunDetail(t *testing.T) {
+	decision := referenceDecision(testDecisionInput())
+	decision["owner"] = "added upstream"
+	detail := map[string]any{
+		"id":     "run-1",
+		"status": "completed",
+		"artifacts": []any{
+			map[string]any{"id": "a1", "type": artifactTypeDecisionObject, "json": decision},
+			map[string]any{"id": "a2", "type": artifactTypeGitHubIssue, "json": map[string]any{"repo": "acme/app", "number": 3, "url": "https://github.com/acme/app/issues/3", "state": "open"}},
+			map[string]any{"id": "a4", "type": artifactTypeRunLogs, "json": []any{map[string]any{"step": "publish", "status": "completed", "at": "2026-03-01T00:00:00Z"}}},
+		},
+	}
+	body, _ := json.Marshal(detail)
+	if _, changed := validateDecisionRunDetail(body); changed {
+		t.Fatal("extra upstream fields and log statuses should not fail the run")
+	}
+
+	detail["artifacts"] = append(detail["artifacts"].([]any),
+		map[string]any{"id": "a3", "type": artifactTypeJiraEpic, "json": map[string]any{"epic_key": "not a key", "url": "https://acme.atlassian.net"}})
+	body, _ = json.Marshal(detail)
+	rewritten, changed := validateDecisionRunDetail(body)
+	if !changed {
+		t.Fatal("invalid artifact should fail the run")
+	}
+	var got map[string]any
+	if err := json.Unmarshal(rewritten, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["status"] != "failed" || got["error_code"] != runErrorArtifactValidation {
+		t.Fatalf("status %v, error_code %v", got["status"], got["error_code"])
+	}
+	if artifacts := got["artifacts"].([]any); len(artifacts) != 3 {
+		t.Fatalf("kept %d artifacts, want the 3 valid ones", len(artifacts))
+	}
+	failures := got["validation_errors"].([]any)
+	if len(failures) != 1 || failures[0].(map[string]any)["artifact_id"] != "a3" {
+		t.Fatalf("validation_errors = %v", failures)
+	}
+}
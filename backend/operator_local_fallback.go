@@ -1,41 +1,75 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 	"sort"
 	"strings"
-	"sync"
 	"time"
 )
 
-type localOperatorRun struct {
-	ID          string
-	WorkspaceID string
-	FeatureName string
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-}
+const localRunEventPollInterval = time.Second
 
-type localOperatorStore struct {
-	mu             sync.Mutex
-	runs           map[string]*localOperatorRun
-	jiraConnected  bool
-	slackConnected bool
+type localOperatorRun struct {
+	ID          string    `json:"id"`
+	WorkspaceID string    `json:"workspaceId"`
+	FeatureName string    `json:"featureName"`
+	Competitors []string  `json:"competitors"`
+	Sink        string    `json:"sink,omitempty"`
+	RerunOf     string    `json:"rerunOf,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+
+	Status           string                    `json:"status"`
+	CurrentStep      string                    `json:"currentStep"`
+	Error            string                    `json:"error,omitempty"`
+	ErrorCode        string                    `json:"errorCode,omitempty"`
+	ValidationErrors []artifactValidationError `json:"validationErrors,omitempty"`
+	Logs             []runLogEntry             `json:"logs"`
+	ArtifactTimes    map[string]time.Time      `json:"artifactTimes,omitempty"`
+	SlackSignals     *slackSignalsArtifact     `json:"slackSignals,omitempty"`
+	CompetitorScan   *competitorScanArtifact   `json:"competitorScan,omitempty"`
+	Decision         map[string]any            `json:"decision,omitempty"`
+	JiraEpic         *jiraEpicArtifact         `json:"jiraEpic,omitempty"`
+	LinearIssue      *linearIssueArtifact      `json:"linearIssue,omitempty"`
+	GitHubIssue      *githubIssueArtifact      `json:"githubIssue,omitempty"`
+	LLMUsage         *llmUsage                 `json:"llmUsage,omitempty"`
+	SinkProgress     localSinkProgress         `json:"sinkProgress"`
+
+	Events         []runEvent `json:"events,omitempty"`
+	EventSeq       int64      `json:"eventSeq,omitempty"`
+	eventStatus    string
+	eventStep      string
+	eventLogs      int
+	eventArtifacts map[string]struct{}
+	attempt        int
+	cancel         context.CancelFunc
 }
 
-var operatorLocalStore = localOperatorStore{
-	runs: map[string]*localOperatorRun{},
+// serveLocalDecisionOperator answers an operator request from the local
+// pipeline while the upstream is healthy, for runs only it can handle.
+func serveLocalDecisionOperator(w http.ResponseWriter, method string, targetPath string, body []byte) {
+	status, payload, ok := handleLocalOperatorRequest(method, targetPath, nil, body, "")
+	if !ok {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if fields, isMap := payload.(map[string]any); isMap && fields["mode"] == "local_fallback" {
+		fields["mode"] = "local"
+	}
+	writeJSON(w, status, payload)
 }
 
-func tryLocalDecisionOperatorFallback(w http.ResponseWriter, method string, targetPath string, body []byte, upstreamError string) bool {
+func tryLocalDecisionOperatorFallback(w http.ResponseWriter, method string, targetPath string, query url.Values, body []byte, upstreamError string) bool {
 	if !localOperatorFallbackEnabled() {
 		return false
 	}
 
-	status, payload, ok := handleLocalOperatorRequest(method, targetPath, body, upstreamError)
+	status, payload, ok := handleLocalOperatorRequest(method, targetPath, query, body, upstreamError)
 	if !ok {
 		return false
 	}
@@ -54,7 +88,7 @@ func localOperatorFallbackEnabled() bool {
 	}
 }
 
-func handleLocalOperatorRequest(method string, targetPath string, body []byte, upstreamError string) (int, any, bool) {
+func handleLocalOperatorRequest(method string, targetPath string, query url.Values, body []byte, upstreamError string) (int, any, bool) {
 	switch {
 	case targetPath == "/api/health" && method == "GET":
 		payload := map[string]any{
@@ -69,18 +103,21 @@ func handleLocalOperatorRequest(method string, targetPath string, body []byte, u
 	case targetPath == "/api/connections/slack/import-from-state" && method == "POST":
 		operatorLocalStore.mu.Lock()
 		operatorLocalStore.slackConnected = true
+		operatorLocalStore.saveLocked()
 		operatorLocalStore.mu.Unlock()
+		connection, _ := integrationStoreInstance.GetSlackConnection()
 		return 200, map[string]any{
 			"status":                 "connected",
 			"tool":                   "slack",
-			"team_name":              "Sentinent Labs",
-			"selected_channel_count": 8,
+			"team_name":              firstNonEmpty(connection.TeamName, "Local workspace"),
+			"selected_channel_count": len(integrationStoreInstance.GetSelectedSlackChannels()),
 			"mode":                   "local_fallback",
 		}, true
 
 	case targetPath == "/api/connections/slack" && method == "POST":
 		operatorLocalStore.mu.Lock()
 		operatorLocalStore.slackConnected = true
+		operatorLocalStore.saveLocked()
 		operatorLocalStore.mu.Unlock()
 		return 200, map[string]any{
 			"status": "connected",
@@ -89,29 +126,25 @@ func handleLocalOperatorRequest(method string, targetPath string, body []byte, u
 		}, true
 
 	case targetPath == "/api/connections/jira" && method == "POST":
-		var payload struct {
-			CredentialBlob struct {
-				BaseURL string `json:"base_url"`
-			} `json:"credential_blob"`
-		}
-		_ = json.Unmarshal(body, &payload)
-		isConnected := strings.TrimSpace(payload.CredentialBlob.BaseURL) != ""
-		operatorLocalStore.mu.Lock()
-		operatorLocalStore.jiraConnected = isConnected
-		operatorLocalStore.mu.Unlock()
-		return 200, map[string]any{
-			"status":   "connected",
-			"tool":     "jira",
-			"base_url": strings.TrimSpace(payload.CredentialBlob.BaseURL),
-			"mode":     "local_fallback",
-		}, true
+		// handleOperatorJiraConnection has already verified and stored the
+		// connection; report what the local pipeline will use.
+		conn, connected := integrationStoreInstance.GetJiraConnection()
+		summary := jiraConnectionSummary(conn, connected)
+		summary["mode"] = "local_fallback"
+		return 200, summary, true
 
 	case targetPath == "/api/decision-runs" && method == "GET":
-		return 200, localDecisionRunSummaries(), true
+		runQuery, err := parseDecisionRunQuery(query)
+		if err != nil {
+			return 400, errorResponse{Error: err.Error()}, true
+		}
+		return 200, localDecisionRunPage(runQuery), true
 
 	case targetPath == "/api/decision-runs" && method == "POST":
 		var payload struct {
-			FeatureName string `json:"feature_name"`
+			FeatureName string   `json:"feature_name"`
+			Competitors []string `json:"competitors"`
+			Sink        string   `json:"sink"`
 		}
 		if err := json.Unmarshal(body, &payload); err != nil {
 			return 400, errorResponse{Error: "invalid request body"}, true
@@ -121,319 +154,505 @@ func handleLocalOperatorRequest(method string, targetPath string, body []byte, u
 			return 400, errorResponse{Error: "feature_name is required"}, true
 		}
 
-		now := time.Now().UTC()
-		runID := fmt.Sprintf("run_%d", now.UnixNano())
-		operatorLocalStore.mu.Lock()
-		operatorLocalStore.runs[runID] = &localOperatorRun{
-			ID:          runID,
-			WorkspaceID: "local-workspace",
-			FeatureName: featureName,
-			CreatedAt:   now,
-			UpdatedAt:   now,
+		sink, err := parseDecisionSink(payload.Sink)
+		if err != nil {
+			return 400, errorResponse{Error: err.Error()}, true
 		}
-		operatorLocalStore.mu.Unlock()
 
+		runID := startLocalRun(featureName, localRunCompetitors(payload.Competitors), sink, "")
 		return 202, map[string]any{
 			"decision_run_id": runID,
 			"status":          "queued",
 			"mode":            "local_fallback",
 		}, true
 
-	case strings.HasPrefix(targetPath, "/api/decision-runs/") && method == "GET":
-		runID := strings.TrimPrefix(targetPath, "/api/decision-runs/")
-		runID = strings.Trim(runID, "/ ")
+	case strings.HasPrefix(targetPath, "/api/decision-runs/"):
+		rest := strings.Trim(strings.TrimPrefix(targetPath, "/api/decision-runs/"), "/ ")
+		runID, action, _ := strings.Cut(rest, "/")
 		if runID == "" {
 			return 400, errorResponse{Error: "missing decision run id"}, true
 		}
-		return localDecisionRunDetail(runID)
+		switch {
+		case action == "" && method == "GET":
+			return localDecisionRunDetail(runID)
+		case action == "" && method == "DELETE":
+			return deleteLocalRun(runID)
+		case action == "cancel" && method == "POST":
+			return cancelLocalRun(runID)
+		case action == "retry" && method == "POST":
+			return retryLocalRun(runID)
+		case action == "rerun" && method == "POST":
+			return rerunLocalRun(runID)
+		}
 	}
 
 	return 0, nil, false
 }
 
-func localDecisionRunSummaries() []map[string]any {
-	runs, jiraConnected := snapshotLocalRuns()
+func startLocalRun(featureName string, competitors []string, sink string, rerunOf string) string {
+	now := time.Now().UTC()
+	runID := fmt.Sprintf("run_%d", now.UnixNano())
+	run := &localOperatorRun{
+		ID:            runID,
+		WorkspaceID:   "local-workspace",
+		FeatureName:   featureName,
+		Competitors:   competitors,
+		Sink:          sink,
+		RerunOf:       rerunOf,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		Status:        "queued",
+		CurrentStep:   localStepSlackExtract,
+		ArtifactTimes: map[string]time.Time{},
+	}
+	message := "Run accepted and waiting for worker."
+	if rerunOf != "" {
+		message = fmt.Sprintf("Rerun of %s accepted and waiting for worker.", rerunOf)
+	}
+	run.log(localStepSlackExtract, "queued", message)
+
+	operatorLocalStore.mu.Lock()
+	operatorLocalStore.runs[runID] = run
+	run.syncEvents()
+	operatorLocalStore.pruneLocked(now)
+	operatorLocalStore.saveLocked()
+	operatorLocalStore.mu.Unlock()
+	go runLocalPipeline(runID)
+	return runID
+}
+
+// cancelLocalRun stops the run's live attempt; the pipeline notices through
+// its context and its remaining writes are dropped.
+func cancelLocalRun(runID string) (int, any, bool) {
+	status := ""
+	found := updateLocalRunWhen(runID, func(run *localOperatorRun) bool {
+		status = run.Status
+		return !localRunTerminal(run.Status)
+	}, func(run *localOperatorRun) {
+		if run.cancel != nil {
+			run.cancel()
+		}
+		run.Status = "cancelled"
+		run.log(run.CurrentStep, "cancelled", "Run cancelled by user.")
+		status = run.Status
+	})
+	return localRunTransition(runID, found, status, "cancelled")
+}
+
+// retryLocalRun resumes a failed or cancelled run from the step it stopped
+// at; steps that already produced an artifact are not repeated.
+func retryLocalRun(runID string) (int, any, bool) {
+	status := ""
+	found := updateLocalRunWhen(runID, func(run *localOperatorRun) bool {
+		status = run.Status
+		return run.Status == "failed" || run.Status == "cancelled"
+	}, func(run *localOperatorRun) {
+		run.Status = "queued"
+		run.Error = ""
+		run.ErrorCode = ""
+		run.ValidationErrors = nil
+		run.log(run.CurrentStep, "queued", fmt.Sprintf("Retry requested; resuming from %s.", run.CurrentStep))
+		status = run.Status
+	})
+	if found {
+		go runLocalPipeline(runID)
+	}
+	return localRunTransition(runID, found, status, "queued")
+}
+
+func rerunLocalRun(runID string) (int, any, bool) {
+	original, ok := readLocalRun(runID)
+	if !ok {
+		return 404, errorResponse{Error: "decision run not found"}, true
+	}
+	newRunID := startLocalRun(original.FeatureName, original.Competitors, original.decisionSink(), original.ID)
+	return 202, map[string]any{
+		"decision_run_id": newRunID,
+		"rerun_of":        original.ID,
+		"status":          "queued",
+		"mode":            "local_fallback",
+	}, true
+}
+
+func deleteLocalRun(runID string) (int, any, bool) {
+	operatorLocalStore.mu.Lock()
+	defer operatorLocalStore.mu.Unlock()
+	run, ok := operatorLocalStore.runs[runID]
+	if !ok {
+		return 404, errorResponse{Error: "decision run not found"}, true
+	}
+	if run.cancel != nil {
+		run.cancel()
+	}
+	delete(operatorLocalStore.runs, runID)
+	operatorLocalStore.saveLocked()
+	return 200, map[string]any{
+		"decision_run_id": runID,
+		"status":          "deleted",
+		"mode":            "local_fallback",
+	}, true
+}
+
+// localRunTransition reports the outcome of a guarded status change: 404 for
+// unknown runs and 409 when the run's status does not allow the transition.
+func localRunTransition(runID string, applied bool, status string, target string) (int, any, bool) {
+	if applied {
+		return 200, map[string]any{
+			"decision_run_id": runID,
+			"status":          target,
+			"mode":            "local_fallback",
+		}, true
+	}
+	if status == "" {
+		return 404, errorResponse{Error: "decision run not found"}, true
+	}
+	return 409, errorResponse{Error: fmt.Sprintf("decision run is %s", status)}, true
+}
+
+func localDecisionRunPage(query decisionRunQuery) decisionRunPage {
+	runs := snapshotLocalRuns()
 	sort.Slice(runs, func(i, j int) bool {
-		return runs[i].CreatedAt.After(runs[j].CreatedAt)
+		if !runs[i].CreatedAt.Equal(runs[j].CreatedAt) {
+			return runs[i].CreatedAt.After(runs[j].CreatedAt)
+		}
+		return runs[i].ID > runs[j].ID
 	})
 
-	summaries := make([]map[string]any, 0, len(runs))
+	page := decisionRunPage{Runs: make([]map[string]any, 0, min(len(runs), query.Limit))}
+	var last *localOperatorRun
 	for _, run := range runs {
-		projection := projectLocalRun(run, jiraConnected)
-		summaries = append(summaries, map[string]any{
+		if !query.matches(run.Status, run.FeatureName, run.CreatedAt) {
+			continue
+		}
+		page.Total++
+		if query.Cursor != nil && !query.Cursor.after(run.CreatedAt, run.ID) {
+			continue
+		}
+		if len(page.Runs) == query.Limit {
+			page.NextCursor = decisionRunCursor{CreatedAt: last.CreatedAt, ID: last.ID}.encode()
+			continue
+		}
+		last = run
+		page.Runs = append(page.Runs, map[string]any{
 			"id":           run.ID,
 			"feature_name": run.FeatureName,
-			"status":       projection.Status,
-			"current_step": projection.CurrentStep,
+			"status":       run.Status,
+			"current_step": run.CurrentStep,
 			"created_at":   run.CreatedAt.Format(time.RFC3339),
-			"updated_at":   projection.UpdatedAt.Format(time.RFC3339),
+			"updated_at":   run.UpdatedAt.Format(time.RFC3339),
 		})
 	}
-
-	return summaries
+	return page
 }
 
 func localDecisionRunDetail(runID string) (int, any, bool) {
-	operatorLocalStore.mu.Lock()
-	run, ok := operatorLocalStore.runs[runID]
-	jiraConnected := operatorLocalStore.jiraConnected
-	operatorLocalStore.mu.Unlock()
+	run, ok := readLocalRun(runID)
 	if !ok {
 		return 404, errorResponse{Error: "decision run not found"}, true
 	}
 
-	projection := projectLocalRun(run, jiraConnected)
-	return 200, map[string]any{
+	detail := map[string]any{
 		"id":           run.ID,
 		"workspace_id": run.WorkspaceID,
 		"feature_name": run.FeatureName,
-		"status":       projection.Status,
-		"current_step": projection.CurrentStep,
+		"competitors":  run.Competitors,
+		"sink":         run.decisionSink(),
+		"status":       run.Status,
+		"current_step": run.CurrentStep,
 		"error":        nil,
-		"artifacts":    projection.Artifacts,
+		"artifacts":    run.artifacts(),
 		"created_at":   run.CreatedAt.Format(time.RFC3339),
-		"updated_at":   projection.UpdatedAt.Format(time.RFC3339),
-	}, true
+		"updated_at":   run.UpdatedAt.Format(time.RFC3339),
+	}
+	if run.Error != "" {
+		detail["error"] = run.Error
+	}
+	if run.ErrorCode != "" {
+		detail["error_code"] = run.ErrorCode
+	}
+	if len(run.ValidationErrors) > 0 {
+		detail["validation_errors"] = run.ValidationErrors
+	}
+	if run.LLMUsage != nil {
+		detail["llm_usage"] = run.LLMUsage
+	}
+	if run.RerunOf != "" {
+		detail["rerun_of"] = run.RerunOf
+	}
+	return 200, detail, true
 }
 
-type localRunProjection struct {
-	Status      string
-	CurrentStep string
-	Artifacts   []map[string]any
-	UpdatedAt   time.Time
+// artifacts renders the run's step outputs in pipeline order, followed by the
+// run log, in the envelope shape the upstream operator returns.
+func (run *localOperatorRun) artifacts() []map[string]any {
+	artifacts := make([]map[string]any, 0, 5)
+	add := func(suffix string, artifactType string, payload any) {
+		artifacts = append(artifacts, map[string]any{
+			"id":         run.ID + "_" + suffix,
+			"type":       artifactType,
+			"created_at": run.ArtifactTimes[artifactType].Format(time.RFC3339),
+			"json":       payload,
+		})
+	}
+	if run.SlackSignals != nil {
+		add("slack", artifactTypeSlackSignals, run.SlackSignals)
+	}
+	if run.CompetitorScan != nil {
+		add("competitor", artifactTypeCompetitorScan, run.CompetitorScan)
+	}
+	if run.Decision != nil {
+		add("decision", artifactTypeDecisionObject, run.Decision)
+	}
+	if run.JiraEpic != nil {
+		add("jira", artifactTypeJiraEpic, run.JiraEpic)
+	}
+	if run.LinearIssue != nil {
+		add("linear", artifactTypeLinearIssue, run.LinearIssue)
+	}
+	if run.GitHubIssue != nil {
+		add("github", artifactTypeGitHubIssue, run.GitHubIssue)
+	}
+	artifacts = append(artifacts, map[string]any{
+		"id":         run.ID + "_logs",
+		"type":       artifactTypeRunLogs,
+		"created_at": run.UpdatedAt.Format(time.RFC3339),
+		"json":       run.Logs,
+	})
+	return artifacts
 }
 
-func projectLocalRun(run *localOperatorRun, jiraConnected bool) localRunProjection {
-	now := time.Now().UTC()
-	elapsed := now.Sub(run.CreatedAt)
-
-	status := "queued"
-	currentStep := "SLACK_EXTRACT"
-	if elapsed >= 2*time.Second {
-		status = "running"
-		currentStep = "SLACK_EXTRACT"
-	}
-	if elapsed >= 6*time.Second {
-		status = "running"
-		currentStep = "COMPETITOR_SCAN"
-	}
-	if elapsed >= 10*time.Second {
-		status = "running"
-		currentStep = "DECISION_SYNTHESIS"
-	}
-	if jiraConnected {
-		if elapsed >= 14*time.Second {
-			status = "running"
-			currentStep = "JIRA_CREATE_EPIC"
-		}
-		if elapsed >= 18*time.Second {
-			status = "completed"
-			currentStep = "DONE"
-		}
-	} else if elapsed >= 14*time.Second {
-		status = "completed"
-		currentStep = "DONE"
-	}
+// decisionSink defaults runs stored before sinks were selectable to Jira.
+func (run *localOperatorRun) decisionSink() string {
+	return firstNonEmpty(run.Sink, decisionSinkJira)
+}
 
-	return localRunProjection{
-		Status:      status,
-		CurrentStep: currentStep,
-		Artifacts:   buildLocalArtifacts(run, jiraConnected, elapsed),
-		UpdatedAt:   now,
-	}
+func (run *localOperatorRun) log(step string, status string, message string) {
+	run.Logs = append(run.Logs, runLogEntry{
+		Step:    step,
+		Status:  status,
+		Message: message,
+		At:      time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// updateLocalRun applies mutate under the store lock and records events for
+// whatever it changed.
+func updateLocalRun(runID string, mutate func(run *localOperatorRun)) bool {
+	return updateLocalRunWhen(runID, nil, mutate)
 }
 
-func buildLocalArtifacts(run *localOperatorRun, jiraConnected bool, elapsed time.Duration) []map[string]any {
-	feature := strings.TrimSpace(run.FeatureName)
-	if feature == "" {
-		feature = "Feature"
+// updateLocalRunWhen is updateLocalRun guarded by a condition checked under
+// the same lock; it reports whether mutate ran.
+func updateLocalRunWhen(runID string, when func(run *localOperatorRun) bool, mutate func(run *localOperatorRun)) bool {
+	operatorLocalStore.mu.Lock()
+	defer operatorLocalStore.mu.Unlock()
+	run, ok := operatorLocalStore.runs[runID]
+	if !ok || (when != nil && !when(run)) {
+		return false
 	}
-	featureLower := strings.ToLower(feature)
+	mutate(run)
+	run.UpdatedAt = time.Now().UTC()
+	run.syncEvents()
+	operatorLocalStore.saveLocked()
+	return true
+}
 
-	artifacts := make([]map[string]any, 0, 5)
-	logs := localRunLogs(run, jiraConnected, elapsed)
+func readLocalRun(runID string) (*localOperatorRun, bool) {
+	operatorLocalStore.mu.Lock()
+	defer operatorLocalStore.mu.Unlock()
+	run, ok := operatorLocalStore.runs[runID]
+	if !ok {
+		return nil, false
+	}
+	return run.snapshot(), true
+}
 
-	if elapsed >= 6*time.Second {
-		artifacts = append(artifacts, map[string]any{
-			"id":         run.ID + "_slack",
-			"type":       "slack_signals",
-			"created_at": run.CreatedAt.Add(6 * time.Second).Format(time.RFC3339),
-			"json": map[string]any{
-				"total_mentions": 42,
-				"channels": []map[string]any{
-					{"name": "#support", "count": 18},
-					{"name": "#product-feedback", "count": 12},
-					{"name": "#sales", "count": 8},
-				},
-				"messages": []map[string]any{
-					{
-						"text":      fmt.Sprintf("Can we add %s? Users asked for this in onboarding.", featureLower),
-						"user":      "A. Rivera",
-						"ts":        run.CreatedAt.Add(4 * time.Second).Format(time.RFC3339),
-						"permalink": "https://slack.example.com/archives/C001/p123",
-					},
-					{
-						"text":      fmt.Sprintf("%s would reduce eye strain for night usage.", feature),
-						"user":      "S. Chen",
-						"ts":        run.CreatedAt.Add(5 * time.Second).Format(time.RFC3339),
-						"permalink": "https://slack.example.com/archives/C004/p234",
-					},
-				},
-				"themes": []map[string]any{
-					{"label": "eye strain", "count": 14},
-					{"label": "night usage", "count": 11},
-					{"label": "accessibility", "count": 8},
-				},
-			},
-		})
+// syncEvents appends events for whatever changed since the last sync.
+// Callers must hold operatorLocalStore.mu.
+func (run *localOperatorRun) syncEvents() {
+	if run.eventArtifacts == nil {
+		run.eventArtifacts = map[string]struct{}{}
+	}
+	emit := func(kind string, data map[string]any) {
+		run.Events = appendRunEvent(run.Events, &run.EventSeq, kind, data)
 	}
 
-	if elapsed >= 10*time.Second {
-		artifacts = append(artifacts, map[string]any{
-			"id":         run.ID + "_competitor",
-			"type":       "competitor_scan",
-			"created_at": run.CreatedAt.Add(10 * time.Second).Format(time.RFC3339),
-			"json": map[string]any{
-				"feature": featureLower,
-				"findings": []map[string]any{
-					{"competitor": "Linear", "page": "changelog", "evidence": "Dark mode improvements for project views", "url": "https://linear.app/changelog"},
-					{"competitor": "Jira", "page": "release notes", "evidence": "Custom theme support available in cloud", "url": "https://www.atlassian.com/software/jira/release-notes"},
-					{"competitor": "Notion", "page": "help center", "evidence": "Theme toggle for low-light environments", "url": "https://www.notion.so/help"},
-				},
-			},
+	if run.CurrentStep != run.eventStep {
+		emit(runEventStep, map[string]any{"step": run.CurrentStep, "previous": run.eventStep})
+		run.eventStep = run.CurrentStep
+	}
+	for _, entry := range run.Logs[min(run.eventLogs, len(run.Logs)):] {
+		emit(runEventLog, map[string]any{
+			"step":      entry.Step,
+			"status":    entry.Status,
+			"message":   entry.Message,
+			"logged_at": entry.At,
 		})
 	}
+	run.eventLogs = len(run.Logs)
+	for _, artifact := range run.artifacts() {
+		artifactType, _ := artifact["type"].(string)
+		if artifactType == artifactTypeRunLogs {
+			continue
+		}
+		if _, seen := run.eventArtifacts[artifactType]; seen {
+			continue
+		}
+		run.eventArtifacts[artifactType] = struct{}{}
+		emit(runEventArtifact, map[string]any{"artifact": artifactType, "id": artifact["id"]})
+	}
+	if run.Status != run.eventStatus {
+		emit(runEventStatus, map[string]any{"status": run.Status, "step": run.CurrentStep})
+		run.eventStatus = run.Status
+	}
+}
 
-	if elapsed >= 14*time.Second {
-		artifacts = append(artifacts, map[string]any{
-			"id":         run.ID + "_decision",
-			"type":       "decision_object",
-			"created_at": run.CreatedAt.Add(14 * time.Second).Format(time.RFC3339),
-			"json": map[string]any{
-				"feature": feature,
-				"signals": map[string]any{
-					"total_mentions": 42,
-					"top_channels": []map[string]any{
-						{"name": "#support", "count": 18},
-						{"name": "#product-feedback", "count": 12},
-					},
-					"themes": []map[string]any{
-						{"label": "eye strain", "count": 14},
-						{"label": "night usage", "count": 11},
-					},
-					"sample_quotes": []map[string]any{
-						{
-							"text":   fmt.Sprintf("I need %s to use this after work hours.", featureLower),
-							"source": "slack",
-							"url":    "https://slack.example.com/archives/C001/p123",
-						},
-					},
-				},
-				"competitors": []map[string]any{
-					{"name": "Linear", "evidence": "Found in changelog feature rollouts", "url": "https://linear.app/changelog"},
-					{"name": "Jira", "evidence": "Found in release notes for cloud experience", "url": "https://www.atlassian.com/software/jira/release-notes"},
-				},
-				"assumptions": []map[string]any{
-					{"statement": "Users churn in night workflows due to brightness", "risk": "medium", "validation": "A/B theme toggle", "metric": "retention +2%"},
-					{"statement": "Support load drops when accessibility options increase", "risk": "low", "validation": "Track support tags", "metric": "ticket volume -10%"},
-					{"statement": "Theme parity is expected by enterprise buyers", "risk": "medium", "validation": "Sales call tracking", "metric": "objection frequency -20%"},
-					{"statement": "Competitor coverage creates urgency", "risk": "low", "validation": "Win/loss notes", "metric": "win rate +3%"},
-					{"statement": "Users discover theme controls in settings", "risk": "medium", "validation": "Onboarding tooltip", "metric": "feature adoption 40%"},
-				},
-				"recommendation": map[string]any{
-					"priority":   "High",
-					"confidence": 0.72,
-					"next_steps": []string{"Create Jira epic", "Run prototype usability test", "Measure retention for night users"},
-				},
-			},
-		})
+func localRunEventsAfter(runID string, lastID int64) ([]runEvent, string, bool) {
+	operatorLocalStore.mu.Lock()
+	defer operatorLocalStore.mu.Unlock()
+	run, ok := operatorLocalStore.runs[runID]
+	if !ok {
+		return nil, "", false
 	}
+	return append([]runEvent(nil), runEventsAfter(run.Events, lastID)...), run.Status, true
+}
 
-	if jiraConnected && elapsed >= 18*time.Second {
-		artifacts = append(artifacts, map[string]any{
-			"id":         run.ID + "_jira",
-			"type":       "jira_epic",
-			"created_at": run.CreatedAt.Add(18 * time.Second).Format(time.RFC3339),
-			"json": map[string]any{
-				"epic_key": "PROD-123",
-				"url":      "https://your-org.atlassian.net/browse/PROD-123",
-			},
-		})
+func tryLocalDecisionRunEvents(w http.ResponseWriter, r *http.Request, runID string) bool {
+	if !localOperatorFallbackEnabled() {
+		return false
 	}
+	serveLocalDecisionRunEvents(w, r, runID)
+	return true
+}
 
-	artifacts = append(artifacts, map[string]any{
-		"id":         run.ID + "_logs",
-		"type":       "run_logs",
-		"created_at": time.Now().UTC().Format(time.RFC3339),
-		"json":       logs,
-	})
+// serveLocalDecisionRunEvents streams a local run's events until it reaches a
+// terminal status or the client goes away.
+func serveLocalDecisionRunEvents(w http.ResponseWriter, r *http.Request, runID string) {
+	lastID := parseLastEventID(r)
+	if _, _, ok := localRunEventsAfter(runID, lastID); !ok {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "decision run not found"})
+		return
+	}
 
-	return artifacts
+	stream, ok := startSSE(w)
+	if !ok {
+		return
+	}
+	poll := time.NewTicker(localRunEventPollInterval)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		events, status, _ := localRunEventsAfter(runID, lastID)
+		for _, event := range events {
+			if err := stream.Event(event); err != nil {
+				return
+			}
+			lastID = event.Seq
+		}
+		if localRunTerminal(status) {
+			_ = stream.Event(runEvent{Type: runEventDone, At: time.Now().UTC(), Data: map[string]any{"status": status}})
+			stream.Flush()
+			return
+		}
+		stream.Flush()
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-poll.C:
+		case <-heartbeat.C:
+			if err := stream.Comment("keep-alive"); err != nil {
+				return
+			}
+		}
+	}
 }
 
-func localRunLogs(run *localOperatorRun, jiraConnected bool, elapsed time.Duration) []map[string]any {
-	logs := []map[string]any{
-		{"step": "SLACK_EXTRACT", "status": "queued", "message": "Run accepted and waiting for worker.", "at": run.CreatedAt.Format(time.RFC3339)},
-	}
+func localRunExists(runID string) bool {
+	operatorLocalStore.mu.Lock()
+	defer operatorLocalStore.mu.Unlock()
+	_, ok := operatorLocalStore.runs[runID]
+	return ok
+}
 
-	if elapsed >= 2*time.Second {
-		logs = append(logs, map[string]any{
-			"step":    "SLACK_EXTRACT",
-			"status":  "success",
-			"message": "Collected and normalized Slack messages.",
-			"at":      run.CreatedAt.Add(6 * time.Second).Format(time.RFC3339),
-		})
+// mergeLocalDecisionRuns adds local runs to an upstream run page. Both sides
+// page by the same (created_at, id) cursor, so the merged page takes the
+// newest runs of the two and its cursor continues both lists.
+func mergeLocalDecisionRuns(body []byte, values url.Values) []byte {
+	query, err := parseDecisionRunQuery(values)
+	if err != nil {
+		return body
 	}
-	if elapsed >= 6*time.Second {
-		logs = append(logs, map[string]any{
-			"step":    "COMPETITOR_SCAN",
-			"status":  "success",
-			"message": "Extracted evidence snippets from competitor pages.",
-			"at":      run.CreatedAt.Add(10 * time.Second).Format(time.RFC3339),
-		})
+	local := localDecisionRunPage(query)
+	if local.Total == 0 {
+		return body
 	}
-	if elapsed >= 10*time.Second {
-		logs = append(logs, map[string]any{
-			"step":    "DECISION_SYNTHESIS",
-			"status":  "success",
-			"message": "Generated strict decision object JSON.",
-			"at":      run.CreatedAt.Add(14 * time.Second).Format(time.RFC3339),
-		})
+	var page decisionRunPage
+	if err := json.Unmarshal(body, &page); err != nil {
+		return body
 	}
-	if jiraConnected && elapsed >= 14*time.Second {
-		logs = append(logs, map[string]any{
-			"step":    "JIRA_CREATE_EPIC",
-			"status":  "success",
-			"message": "Created Jira epic from decision object.",
-			"at":      run.CreatedAt.Add(18 * time.Second).Format(time.RFC3339),
-		})
+
+	localCreated := map[string]time.Time{}
+	for _, run := range snapshotLocalRuns() {
+		localCreated[run.ID] = run.CreatedAt
 	}
-	if (!jiraConnected && elapsed >= 14*time.Second) || (jiraConnected && elapsed >= 18*time.Second) {
-		logs = append(logs, map[string]any{
-			"step":    "DONE",
-			"status":  "success",
-			"message": "Run completed in local fallback mode.",
-			"at":      time.Now().UTC().Format(time.RFC3339),
-		})
+	createdAt := func(run map[string]any) time.Time {
+		if at, ok := localCreated[stringFromAny(run["id"])]; ok {
+			return at
+		}
+		at, _ := time.Parse(time.RFC3339Nano, stringFromAny(run["created_at"]))
+		return at
 	}
 
-	return logs
+	runs := append(page.Runs, local.Runs...)
+	sort.SliceStable(runs, func(i, j int) bool {
+		left, right := createdAt(runs[i]), createdAt(runs[j])
+		if !left.Equal(right) {
+			return left.After(right)
+		}
+		return stringFromAny(runs[i]["id"]) > stringFromAny(runs[j]["id"])
+	})
+	more := page.NextCursor != "" || local.NextCursor != "" || len(runs) > query.Limit
+	page.Runs = runs[:min(len(runs), query.Limit)]
+	page.Total += local.Total
+	page.NextCursor = ""
+	if more && len(page.Runs) > 0 {
+		last := page.Runs[len(page.Runs)-1]
+		page.NextCursor = decisionRunCursor{CreatedAt: createdAt(last), ID: stringFromAny(last["id"])}.encode()
+	}
+	merged, err := json.Marshal(page)
+	if err != nil {
+		return body
+	}
+	return merged
 }
 
-func snapshotLocalRuns() ([]*localOperatorRun, bool) {
+func snapshotLocalRuns() []*localOperatorRun {
 	operatorLocalStore.mu.Lock()
 	defer operatorLocalStore.mu.Unlock()
 
 	runs := make([]*localOperatorRun, 0, len(operatorLocalStore.runs))
 	for _, run := range operatorLocalStore.runs {
-		runs = append(runs, &localOperatorRun{
-			ID:          run.ID,
-			WorkspaceID: run.WorkspaceID,
-			FeatureName: run.FeatureName,
-			CreatedAt:   run.CreatedAt,
-			UpdatedAt:   run.UpdatedAt,
-		})
+		runs = append(runs, run.snapshot())
 	}
+	return runs
+}
 
-	return runs, operatorLocalStore.jiraConnected
+// snapshot copies the run without its event log so it can be read after the
+// lock is released; step outputs are replaced, never mutated, so they are
+// shared. Callers must hold operatorLocalStore.mu.
+func (run *localOperatorRun) snapshot() *localOperatorRun {
+	out := *run
+	out.Competitors = append([]string(nil), run.Competitors...)
+	out.ValidationErrors = append([]artifactValidationError(nil), run.ValidationErrors...)
+	out.Logs = append([]runLogEntry{}, run.Logs...)
+	out.ArtifactTimes = make(map[string]time.Time, len(run.ArtifactTimes))
+	for key, value := range run.ArtifactTimes {
+		out.ArtifactTimes[key] = value
+	}
+	out.Events = nil
+	out.eventArtifacts = nil
+	return &out
 }
@@ -0,0 +1,653 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	localStepSlackExtract      = "SLACK_EXTRACT"
+	localStepCompetitorScan    = "COMPETITOR_SCAN"
+	localStepDecisionSynthesis = "DECISION_SYNTHESIS"
+	localStepJiraCreateEpic    = "JIRA_CREATE_EPIC"
+	localStepLinearCreateIssue = "LINEAR_CREATE_ISSUE"
+	localStepDone              = "DONE"
+
+	defaultFallbackCompetitors = "Linear,Jira,Notion"
+	maxLocalSignalMessages     = 5
+	maxLocalSearchResults      = 2
+)
+
+// localPipelineStep is one stage of the fallback decision run. done reports
+// whether a previous attempt already produced the step's output, so a resumed
+// run picks up at the first unfinished step. Steps with a sink only run when
+// the run files its decision there.
+type localPipelineStep struct {
+	name string
+	sink string
+	done func(run *localOperatorRun) bool
+	run  func(ctx context.Context, run *localOperatorRun) error
+}
+
+var localPipelineSteps = []localPipelineStep{
+	{
+		name: localStepSlackExtract,
+		done: func(run *localOperatorRun) bool { return run.SlackSignals != nil },
+		run:  extractLocalSlackSignals,
+	},
+	{
+		name: localStepCompetitorScan,
+		done: func(run *localOperatorRun) bool { return run.CompetitorScan != nil },
+		run:  scanLocalCompetitors,
+	},
+	{
+		name: localStepDecisionSynthesis,
+		done: func(run *localOperatorRun) bool { return run.Decision != nil },
+		run:  synthesizeLocalDecision,
+	},
+	{
+		name: localStepJiraCreateEpic,
+		sink: decisionSinkJira,
+		done: func(run *localOperatorRun) bool { return run.JiraEpic != nil },
+		run:  createLocalJiraEpic,
+	},
+	{
+		name: localStepLinearCreateIssue,
+		sink: decisionSinkLinear,
+		done: func(run *localOperatorRun) bool { return run.LinearIssue != nil },
+		run:  createLocalLinearIssue,
+	},
+}
+
+// localSinkProgress records what a sink step created before the step
+// completed. A retried step resumes from it instead of filing the same epic,
+// issue or project a second time.
+type localSinkProgress struct {
+	JiraEpic       *jiraEpicArtifact    `json:"jiraEpic,omitempty"`
+	LinearIssue    *linearIssueArtifact `json:"linearIssue,omitempty"`
+	LinearParentID string               `json:"linearParentId,omitempty"`
+}
+
+// runLocalPipeline runs one attempt of the pipeline. Cancelling the run or
+// starting a new attempt (retry) cancels ctx, and every write checks the
+// attempt so a superseded goroutine cannot overwrite newer state.
+func runLocalPipeline(runID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), agentRunTimeout)
+	defer cancel()
+
+	var attempt *localOperatorRun
+	if !updateLocalRunWhen(runID, func(run *localOperatorRun) bool { return !localRunTerminal(run.Status) }, func(run *localOperatorRun) {
+		if run.cancel != nil {
+			run.cancel()
+		}
+		run.attempt++
+		run.cancel = cancel
+		attempt = run.snapshot()
+	}) {
+		return
+	}
+
+	for _, step := range localPipelineSteps {
+		current, ok := readLocalRun(runID)
+		if !ok || current.attempt != attempt.attempt || localRunTerminal(current.Status) {
+			return
+		}
+		if step.done(current) || (step.sink != "" && step.sink != current.decisionSink()) {
+			continue
+		}
+		if !updateLocalAttempt(attempt, func(run *localOperatorRun) {
+			run.Status = "running"
+			run.CurrentStep = step.name
+			run.log(step.name, "running", "Step started.")
+		}) {
+			return
+		}
+		if err := step.run(ctx, current); err != nil {
+			failLocalRun(attempt, step.name, err)
+			return
+		}
+	}
+
+	updateLocalAttempt(attempt, func(run *localOperatorRun) {
+		run.Status = "completed"
+		run.CurrentStep = localStepDone
+		run.log(localStepDone, "success", "Run completed in local fallback mode.")
+	})
+}
+
+// updateLocalAttempt applies mutate only while attempt is still the run's
+// live attempt.
+func updateLocalAttempt(attempt *localOperatorRun, mutate func(run *localOperatorRun)) bool {
+	return updateLocalRunWhen(attempt.ID, func(run *localOperatorRun) bool {
+		return run.attempt == attempt.attempt && !localRunTerminal(run.Status)
+	}, mutate)
+}
+
+func failLocalRun(attempt *localOperatorRun, step string, err error) {
+	if !updateLocalAttempt(attempt, func(run *localOperatorRun) {
+		run.Status = "failed"
+		run.CurrentStep = step
+		run.Error = err.Error()
+		var invalid artifactValidationError
+		switch {
+		case errors.As(err, &invalid):
+			run.ErrorCode = runErrorArtifactValidation
+			run.ValidationErrors = append(run.ValidationErrors, invalid)
+		case errors.Is(err, errInvalidDecisionObject):
+			run.ErrorCode = runErrorArtifactValidation
+		}
+		run.log(step, "failed", err.Error())
+	}) {
+		return
+	}
+	log.Printf("local decision run %s failed at %s: %v", attempt.ID, step, err)
+}
+
+// completeLocalStep validates a step's artifact before storing it, so the
+// fallback never serves output the proxy would have rejected.
+func completeLocalStep(run *localOperatorRun, step string, artifactType string, payload any, message string, store func(run *localOperatorRun)) error {
+	if err := validateOperatorArtifact(artifactType, payload); err != nil {
+		return artifactValidationError{ArtifactID: run.ID + "_" + artifactType, Type: artifactType, Message: err.Error()}
+	}
+	updateLocalAttempt(run, func(stored *localOperatorRun) {
+		store(stored)
+		stored.ArtifactTimes[artifactType] = time.Now().UTC()
+		stored.log(step, "success", message)
+	})
+	return nil
+}
+
+func localRunCompetitors(requested []string) []string {
+	if len(requested) == 0 {
+		requested = strings.Split(fallbackCompetitors, ",")
+	}
+	competitors := make([]string, 0, len(requested))
+	seen := map[string]struct{}{}
+	for _, raw := range requested {
+		name := strings.TrimSpace(raw)
+		key := strings.ToLower(name)
+		if name == "" {
+			continue
+		}
+		if _, exists := seen[key]; exists {
+			continue
+		}
+		seen[key] = struct{}{}
+		competitors = append(competitors, name)
+	}
+	if len(competitors) > maxAgentCompetitors {
+		competitors = competitors[:maxAgentCompetitors]
+	}
+	return competitors
+}
+
+func extractLocalSlackSignals(_ context.Context, run *localOperatorRun) error {
+	if integrationStoreInstance == nil {
+		return errors.New("integration store is not initialized")
+	}
+	signals := integrationStoreInstance.SearchSignals(run.FeatureName, maxSignalsInStore)
+	artifact := buildLocalSlackSignals(run.FeatureName, signals)
+
+	message := fmt.Sprintf("Matched %d signals across %d channels.", artifact.TotalMentions, len(artifact.Channels))
+	if len(signals) == 0 {
+		message = fmt.Sprintf("No stored signals mention %q yet.", run.FeatureName)
+	}
+	return completeLocalStep(run, localStepSlackExtract, artifactTypeSlackSignals, artifact, message, func(stored *localOperatorRun) {
+		stored.SlackSignals = &artifact
+	})
+}
+
+func buildLocalSlackSignals(feature string, signals []signalRecord) slackSignalsArtifact {
+	artifact := slackSignalsArtifact{
+		TotalMentions: len(signals),
+		Channels:      []decisionChannel{},
+		Messages:      []slackSignalMessage{},
+		Themes:        []slackSignalTheme{},
+	}
+
+	counts := map[string]int{}
+	for _, signal := range signals {
+		counts[localSignalChannel(signal)]++
+	}
+	for name, count := range counts {
+		artifact.Channels = append(artifact.Channels, decisionChannel{Name: name, Count: count})
+	}
+	sort.Slice(artifact.Channels, func(i, j int) bool {
+		if artifact.Channels[i].Count != artifact.Channels[j].Count {
+			return artifact.Channels[i].Count > artifact.Channels[j].Count
+		}
+		return artifact.Channels[i].Name < artifact.Channels[j].Name
+	})
+
+	// Signals come back oldest first; the artifact leads with the newest.
+	for idx := len(signals) - 1; idx >= 0 && len(artifact.Messages) < maxLocalSignalMessages; idx-- {
+		signal := signals[idx]
+		user := strings.TrimSpace(signal.Meta["user"])
+		if user == "" {
+			user = signal.Source
+		}
+		artifact.Messages = append(artifact.Messages, slackSignalMessage{
+			Text:      signal.Summary,
+			User:      user,
+			TS:        signal.OccurredAt.UTC().Format(time.RFC3339),
+			Permalink: localSignalPermalink(signal),
+		})
+	}
+
+	for _, cluster := range localSignalThemes(feature, signals) {
+		artifact.Themes = append(artifact.Themes, slackSignalTheme{
+			Label:      cluster.Label,
+			Count:      cluster.Count,
+			Confidence: cluster.Confidence,
+			Keywords:   cluster.Keywords,
+			Quotes:     cluster.Quotes,
+		})
+	}
+	return artifact
+}
+
+// localSignalChannel names the channel a signal came from: imported messages
+// carry the channel name, live events only the channel ID.
+func localSignalChannel(signal signalRecord) string {
+	if name := strings.TrimSpace(signal.Meta["channelName"]); name != "" {
+		return "#" + strings.TrimPrefix(name, "#")
+	}
+	if channel := strings.TrimSpace(signal.Meta["channel"]); channel != "" {
+		return channel
+	}
+	if repo := strings.TrimSpace(signal.Meta["repo"]); repo != "" {
+		return repo
+	}
+	return firstNonEmpty(signal.Source, "unknown")
+}
+
+func localSignalPermalink(signal signalRecord) string {
+	link := strings.TrimSpace(signal.Meta["permalink"])
+	parsed, err := url.Parse(link)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return ""
+	}
+	return link
+}
+
+func localSignalThemes(feature string, signals []signalRecord) []themeCluster {
+	docs := make([]themeDocument, 0, len(signals))
+	for _, signal := range signals {
+		docs = append(docs, themeDocument{
+			ID:     signal.ID,
+			Source: signal.Source,
+			Text:   signal.Summary,
+			URL:    localSignalPermalink(signal),
+		})
+	}
+	if len(docs) == 0 {
+		return nil
+	}
+	return clusterThemes(docs, []string{feature})
+}
+
+func scanLocalCompetitors(ctx context.Context, run *localOperatorRun) error {
+	scan := competitorScanArtifact{Feature: strings.ToLower(run.FeatureName), Findings: []competitorFinding{}}
+	if len(run.Competitors) == 0 {
+		return completeLocalStep(run, localStepCompetitorScan, artifactTypeCompetitorScan, scan, "No competitors configured; skipped scan.", func(stored *localOperatorRun) {
+			stored.CompetitorScan = &scan
+		})
+	}
+
+	adapter := newTinyFishAdapter()
+	sessionID, err := adapter.CreateSession(ctx, run.ID)
+	if err != nil {
+		return fmt.Errorf("create TinyFish session: %w", err)
+	}
+	defer func() {
+		if closeErr := adapter.CloseSession(context.Background(), sessionID); closeErr != nil {
+			log.Printf("WARNING: local decision run %s: close TinyFish session: %v", run.ID, closeErr)
+		}
+	}()
+
+	for _, competitor := range run.Competitors {
+		finding, err := scanLocalCompetitor(ctx, adapter, sessionID, competitor, run.FeatureName)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, errTinyFishCircuitOpen) {
+				return fmt.Errorf("TinyFish unavailable, stopped competitor scan: %w", err)
+			}
+			updateLocalAttempt(run, func(stored *localOperatorRun) {
+				stored.log(localStepCompetitorScan, "skipped", fmt.Sprintf("%s: %v", competitor, err))
+			})
+			continue
+		}
+		scan.Findings = append(scan.Findings, finding)
+	}
+
+	message := fmt.Sprintf("Extracted evidence for %d of %d competitors via %s.", len(scan.Findings), len(run.Competitors), tinyFishAdapterName())
+	return completeLocalStep(run, localStepCompetitorScan, artifactTypeCompetitorScan, scan, message, func(stored *localOperatorRun) {
+		stored.CompetitorScan = &scan
+	})
+}
+
+// scanLocalCompetitor searches for the competitor's take on the feature and
+// returns the first visited page with a usable snippet, preferring snippets
+// that mention the feature.
+func scanLocalCompetitor(ctx context.Context, adapter TinyFishAdapter, sessionID string, competitor string, feature string) (competitorFinding, error) {
+	query := fmt.Sprintf("%s %s", competitor, feature)
+	resp, err := runLocalTinyFishStep(ctx, adapter, sessionID, agentSearchSteps(query))
+	if err != nil {
+		return competitorFinding{}, fmt.Errorf("search failed: %w", err)
+	}
+	results := parseAgentSearchResults(resp, query)
+	if len(results) == 0 {
+		return competitorFinding{}, errors.New("search returned no results")
+	}
+
+	var lastErr error
+	for _, result := range results[:min(len(results), maxLocalSearchResults)] {
+		resp, err := runLocalTinyFishStep(ctx, adapter, sessionID, agentVisitSteps(result.URL))
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, errTinyFishCircuitOpen) {
+				return competitorFinding{}, err
+			}
+			lastErr = fmt.Errorf("visit %s failed: %w", result.URL, err)
+			continue
+		}
+		evidence := parseAgentVisitEvidence(resp, result, time.Now().UTC())
+		if len(evidence) == 0 {
+			continue
+		}
+		best := evidence[0]
+		for _, item := range evidence {
+			if mentionsAllTerms(item.Snippet, feature) {
+				best = item
+				break
+			}
+		}
+		return competitorFinding{
+			Competitor: competitor,
+			Page:       best.Title,
+			Evidence:   best.Snippet,
+			URL:        best.URL,
+		}, nil
+	}
+	if lastErr != nil {
+		return competitorFinding{}, lastErr
+	}
+	return competitorFinding{}, errors.New("no evidence found on result pages")
+}
+
+func mentionsAllTerms(text string, query string) bool {
+	text = strings.ToLower(text)
+	for _, term := range strings.Fields(strings.ToLower(query)) {
+		if !strings.Contains(text, term) {
+			return false
+		}
+	}
+	return true
+}
+
+// runLocalTinyFishStep shares the research agent's content cache and per-step
+// timeout.
+func runLocalTinyFishStep(ctx context.Context, adapter TinyFishAdapter, sessionID string, steps []TinyFishStep) (map[string]any, error) {
+	if err := validateTinyFishSteps(steps); err != nil {
+		return nil, err
+	}
+	if agentTinyFishCache != nil {
+		if resp, _, ok := agentTinyFishCache.Get(steps); ok {
+			return resp, nil
+		}
+	}
+	stepCtx, cancel := context.WithTimeout(ctx, agentStepTimeout)
+	defer cancel()
+	started := time.Now()
+	resp, err := adapter.RunSteps(stepCtx, sessionID, steps)
+	if err != nil {
+		if ctx.Err() == nil && errors.Is(stepCtx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("timed out after %s", agentStepTimeout)
+		}
+		return nil, err
+	}
+	if agentTinyFishCache != nil {
+		if cacheErr := agentTinyFishCache.Put(steps, resp, time.Since(started)); cacheErr != nil {
+			log.Printf("WARNING: tinyfish cache write failed: %v", cacheErr)
+		}
+	}
+	return resp, nil
+}
+
+func synthesizeLocalDecision(ctx context.Context, run *localOperatorRun) error {
+	provider := newLLMProvider()
+	synthCtx, cancel := context.WithTimeout(ctx, llmTimeout*(maxDecisionRepairs+1))
+	defer cancel()
+	decision, usage, err := synthesizeDecision(synthCtx, provider, localDecisionInput(run))
+	if usage.Calls > 0 {
+		updateLocalAttempt(run, func(stored *localOperatorRun) {
+			stored.LLMUsage = &usage
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("Generated strict decision object JSON with %s/%s (%d calls, %d input + %d output tokens, $%.4f).",
+		usage.Provider, usage.Model, usage.Calls, usage.InputTokens, usage.OutputTokens, usage.CostUSD)
+	return completeLocalStep(run, localStepDecisionSynthesis, artifactTypeDecisionObject, decision, message, func(stored *localOperatorRun) {
+		stored.Decision = decision
+	})
+}
+
+// localDecisionInput feeds synthesis only what the earlier steps found.
+func localDecisionInput(run *localOperatorRun) decisionSynthesisInput {
+	input := decisionSynthesisInput{Feature: run.FeatureName}
+	if signals := run.SlackSignals; signals != nil {
+		input.TotalMentions = signals.TotalMentions
+		input.Channels = append(input.Channels, signals.Channels...)
+		for _, theme := range signals.Themes {
+			input.Themes = append(input.Themes, decisionTheme{Label: theme.Label, Count: theme.Count})
+		}
+		for _, message := range signals.Messages {
+			input.Quotes = append(input.Quotes, decisionQuote{Text: message.Text, Source: "slack", URL: message.Permalink})
+		}
+	}
+	if scan := run.CompetitorScan; scan != nil {
+		for _, finding := range scan.Findings {
+			input.Competitors = append(input.Competitors, decisionCompetitor{
+				Name:     finding.Competitor,
+				Evidence: finding.Evidence,
+				URL:      finding.URL,
+			})
+		}
+	}
+	return input
+}
+
+// createLocalJiraEpic files the decision as an epic in the connected Jira
+// project with one child story per next step. A story that fails to create is
+// logged and skipped; the epic itself must succeed. Each issue is recorded as
+// soon as it exists, so a retry only files what is still missing.
+func createLocalJiraEpic(ctx context.Context, run *localOperatorRun) error {
+	if _, connected := integrationStoreInstance.GetJiraConnection(); !connected {
+		updateLocalAttempt(run, func(stored *localOperatorRun) {
+			stored.log(localStepJiraCreateEpic, "skipped", "Jira is not connected.")
+		})
+		return nil
+	}
+	client, conn, err := storedJiraClient()
+	if err != nil {
+		return err
+	}
+
+	epicFields, storyFields, err := jiraEpicFromDecision(conn, run.ID, run.Decision)
+	if err != nil {
+		return err
+	}
+	var epic jiraEpicArtifact
+	if recorded := run.SinkProgress.JiraEpic; recorded != nil {
+		epic = *recorded
+		epic.Stories = slices.Clone(recorded.Stories)
+		updateLocalAttempt(run, func(stored *localOperatorRun) {
+			stored.log(localStepJiraCreateEpic, "running", fmt.Sprintf("Resuming with Jira epic %s from an earlier attempt.", epic.EpicKey))
+		})
+	} else {
+		created, err := client.CreateIssue(ctx, epicFields)
+		if err != nil {
+			return fmt.Errorf("create jira epic in %s: %w", conn.ProjectKey, err)
+		}
+		epic = jiraEpicArtifact{EpicKey: created.Key, URL: client.BrowseURL(created.Key)}
+		recordLocalJiraProgress(run.ID, epic)
+	}
+
+	filed := map[string]bool{}
+	for _, story := range epic.Stories {
+		filed[story.Summary] = true
+	}
+	for _, fields := range storyFields {
+		if filed[fields.Summary] {
+			continue
+		}
+		fields.Parent = map[string]string{"key": epic.EpicKey}
+		story, err := client.CreateIssue(ctx, fields)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			updateLocalAttempt(run, func(stored *localOperatorRun) {
+				stored.log(localStepJiraCreateEpic, "skipped", fmt.Sprintf("Could not create story %q: %v", fields.Summary, err))
+			})
+			continue
+		}
+		epic.Stories = append(epic.Stories, jiraStoryIssue{Key: story.Key, Summary: fields.Summary, URL: client.BrowseURL(story.Key)})
+		recordLocalJiraProgress(run.ID, epic)
+	}
+
+	message := fmt.Sprintf("Created Jira epic %s with %d of %d stories from decision object.", epic.EpicKey, len(epic.Stories), len(storyFields))
+	return completeLocalStep(run, localStepJiraCreateEpic, artifactTypeJiraEpic, epic, message, func(stored *localOperatorRun) {
+		stored.JiraEpic = &epic
+	})
+}
+
+// createLocalLinearIssue files the decision in the connected Linear team,
+// either as an issue with one sub-issue per next step or as a project holding
+// those issues. Like the Jira step, a retry resumes from what was recorded.
+func createLocalLinearIssue(ctx context.Context, run *localOperatorRun) error {
+	if _, connected := integrationStoreInstance.GetLinearConnection(); !connected {
+		updateLocalAttempt(run, func(stored *localOperatorRun) {
+			stored.log(localStepLinearCreateIssue, "skipped", "Linear is not connected.")
+		})
+		return nil
+	}
+	client, conn, err := storedLinearClient()
+	if err != nil {
+		return err
+	}
+	var decision decisionObject
+	if err := decodeArtifactStrict(run.Decision, &decision); err != nil {
+		return fmt.Errorf("decode decision object: %w", err)
+	}
+	body := decisionMarkdown(decision, run.ID)
+
+	var created linearIssueArtifact
+	var parentID string
+	if recorded := run.SinkProgress.LinearIssue; recorded != nil {
+		created = *recorded
+		created.Children = slices.Clone(recorded.Children)
+		parentID = run.SinkProgress.LinearParentID
+		updateLocalAttempt(run, func(stored *localOperatorRun) {
+			stored.log(localStepLinearCreateIssue, "running", fmt.Sprintf("Resuming with Linear %s %s from an earlier attempt.", created.Kind, created.Identifier))
+		})
+	} else if conn.Target == linearTargetProject {
+		project, err := client.CreateProject(ctx, map[string]any{
+			"name":        decision.Feature,
+			"description": fmt.Sprintf("%s priority, %.0f%% confidence.", decision.Recommendation.Priority, decision.Recommendation.Confidence*100),
+			"content":     body,
+			"teamIds":     []string{conn.TeamID},
+		})
+		if err != nil {
+			return fmt.Errorf("create linear project in %s: %w", conn.TeamKey, err)
+		}
+		created = linearIssueArtifact{Kind: linearTargetProject, Identifier: firstNonEmpty(project.SlugID, project.ID), URL: project.URL}
+		parentID = project.ID
+		recordLocalLinearProgress(run.ID, created, parentID)
+	} else {
+		issue, err := client.CreateIssue(ctx, map[string]any{
+			"teamId":      conn.TeamID,
+			"title":       decision.Feature,
+			"description": body,
+		})
+		if err != nil {
+			return fmt.Errorf("create linear issue in %s: %w", conn.TeamKey, err)
+		}
+		created = linearIssueArtifact{Kind: linearTargetIssue, Identifier: issue.Identifier, URL: issue.URL}
+		parentID = issue.ID
+		recordLocalLinearProgress(run.ID, created, parentID)
+	}
+	child := map[string]any{"teamId": conn.TeamID}
+	if created.Kind == linearTargetProject {
+		child["projectId"] = parentID
+	} else {
+		child["parentId"] = parentID
+	}
+
+	filed := map[string]bool{}
+	for _, ref := range created.Children {
+		filed[ref.Title] = true
+	}
+	steps := 0
+	for _, step := range decision.Recommendation.NextSteps {
+		if strings.TrimSpace(step) == "" {
+			continue
+		}
+		steps++
+		if filed[step] {
+			continue
+		}
+		input := map[string]any{
+			"title":       step,
+			"description": fmt.Sprintf("Next step for %s from Sentient decision run %s.", decision.Feature, run.ID),
+		}
+		for key, value := range child {
+			input[key] = value
+		}
+		issue, err := client.CreateIssue(ctx, input)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			updateLocalAttempt(run, func(stored *localOperatorRun) {
+				stored.log(localStepLinearCreateIssue, "skipped", fmt.Sprintf("Could not create issue %q: %v", step, err))
+			})
+			continue
+		}
+		created.Children = append(created.Children, linearIssueRef{Identifier: issue.Identifier, Title: step, URL: issue.URL})
+		recordLocalLinearProgress(run.ID, created, parentID)
+	}
+
+	message := fmt.Sprintf("Created Linear %s %s with %d of %d next-step issues from decision object.", created.Kind, created.Identifier, len(created.Children), steps)
+	return completeLocalStep(run, localStepLinearCreateIssue, artifactTypeLinearIssue, created, message, func(stored *localOperatorRun) {
+		stored.LinearIssue = &created
+	})
+}
+
+// recordLocalJiraProgress stores the epic and stories created so far. It is
+// not tied to the attempt: the issues exist in Jira whether or not this
+// attempt has been superseded, and the next attempt must not file them again.
+func recordLocalJiraProgress(runID string, epic jiraEpicArtifact) {
+	epic.Stories = slices.Clone(epic.Stories)
+	updateLocalRun(runID, func(stored *localOperatorRun) {
+		stored.SinkProgress.JiraEpic = &epic
+	})
+}
+
+// recordLocalLinearProgress is recordLocalJiraProgress for the Linear issue
+// or project and its next-step issues.
+func recordLocalLinearProgress(runID string, created linearIssueArtifact, parentID string) {
+	created.Children = slices.Clone(created.Children)
+	updateLocalRun(runID, func(stored *localOperatorRun) {
+		stored.SinkProgress.LinearIssue = &created
+		stored.SinkProgress.LinearParentID = parentID
+	})
+}
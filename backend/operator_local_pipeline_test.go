@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// useTestIntegrations points the integration store and token cipher at a
+// fresh temp directory for the duration of the test.
+func useTestIntegrations(t *testing.T) {
+	t.Helper()
+	store, err := newIntegrationStore(filepath.Join(t.TempDir(), "integrations.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cipher, err := newTokenCipher("test-encryption-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prevStore, prevCipher := integrationStoreInstance, integrationTokenCipher
+	integrationStoreInstance, integrationTokenCipher = store, cipher
+	t.Cleanup(func() { integrationStoreInstance, integrationTokenCipher = prevStore, prevCipher })
+}
+
+func encryptForTest(t *testing.T, plain string) string {
+	t.Helper()
+	encrypted, err := integrationTokenCipher.Encrypt(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encrypted
+}
+
+// addTestLocalRun stores a run with a synthesized decision and returns its
+// snapshot, as runLocalPipeline hands it to a step.
+func addTestLocalRun(t *testing.T, id string, sink string) *localOperatorRun {
+	t.Helper()
+	input := testDecisionInput()
+	run := &localOperatorRun{
+		ID:            id,
+		FeatureName:   input.Feature,
+		Sink:          sink,
+		Status:        "running",
+		Decision:      referenceDecision(input),
+		ArtifactTimes: map[string]time.Time{},
+	}
+	operatorLocalStore.mu.Lock()
+	operatorLocalStore.runs[id] = run
+	operatorLocalStore.mu.Unlock()
+	t.Cleanup(func() {
+		operatorLocalStore.mu.Lock()
+		delete(operatorLocalStore.runs, id)
+		operatorLocalStore.mu.Unlock()
+	})
+	snapshot, _ := readLocalRun(id)
+	return snapshot
+}
+
+func TestLocalJiraStepRetryDoesNotDuplicateIssues(t *testing.T) {
+	useTestIntegrations(t)
+
+	var mu sync.Mutex
+	var epics int
+	created := map[string]int{}
+	var interrupt context.CancelFunc
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Fields jiraIssueFields `json:"fields"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		defer mu.Unlock()
+		if body.Fields.IssueType["id"] == "10000" {
+			epics++
+			_, _ = fmt.Fprintf(w, `{"id":"1","key":"PROD-%d"}`, epics)
+			return
+		}
+		// The first attempt is cut off after one story.
+		if interrupt != nil && len(created) == 1 {
+			interrupt()
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		created[body.Fields.Summary]++
+		_, _ = fmt.Fprintf(w, `{"id":"2","key":"PROD-%d"}`, 100+len(created))
+	}))
+	defer server.Close()
+
+	if err := integrationStoreInstance.UpsertJiraConnection(jiraConnectionRecord{
+		BaseURL:          server.URL,
+		AuthType:         jiraAuthAPIToken,
+		Email:            "pm@example.com",
+		EncryptedSecret:  encryptForTest(t, "token"),
+		ProjectKey:       "PROD",
+		EpicIssueTypeID:  "10000",
+		StoryIssueTypeID: "10001",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	run := addTestLocalRun(t, "run_jira_retry", decisionSinkJira)
+	ctx, cancel := context.WithCancel(context.Background())
+	interrupt = cancel
+	if err := createLocalJiraEpic(ctx, run); err == nil {
+		t.Fatal("expected the interrupted attempt to fail")
+	}
+	mu.Lock()
+	interrupt = nil
+	mu.Unlock()
+
+	retried, _ := readLocalRun(run.ID)
+	if retried.SinkProgress.JiraEpic == nil || retried.SinkProgress.JiraEpic.EpicKey != "PROD-1" {
+		t.Fatalf("epic key not recorded before the step finished: %+v", retried.SinkProgress)
+	}
+	if err := createLocalJiraEpic(context.Background(), retried); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+
+	final, _ := readLocalRun(run.ID)
+	nextSteps := len(final.Decision["recommendation"].(map[string]any)["next_steps"].([]string))
+	if epics != 1 {
+		t.Fatalf("created %d epics, want 1", epics)
+	}
+	if final.JiraEpic == nil || final.JiraEpic.EpicKey != "PROD-1" || len(final.JiraEpic.Stories) != nextSteps {
+		t.Fatalf("jira epic = %+v, want PROD-1 with %d stories", final.JiraEpic, nextSteps)
+	}
+	for summary, count := range created {
+		if count != 1 {
+			t.Errorf("story %q created %d times", summary, count)
+		}
+	}
+}
+
+func TestLocalLinearStepRetryDoesNotDuplicateIssues(t *testing.T) {
+	useTestIntegrations(t)
+
+	var mu sync.Mutex
+	var parents []map[string]any
+	children := map[string]int{}
+	var interrupt context.CancelFunc
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Variables struct {
+				Input map[string]any `json:"input"`
+			} `json:"variables"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		input := body.Variables.Input
+		mu.Lock()
+		defer mu.Unlock()
+		if _, child := input["parentId"]; !child {
+			parents = append(parents, input)
+			_, _ = fmt.Fprintf(w, `{"data":{"issueCreate":{"success":true,"issue":{"id":"parent-%d","identifier":"ENG-%d","url":"https://linear.app/acme/issue/ENG-%d"}}}}`, len(parents), len(parents), len(parents))
+			return
+		}
+		if input["parentId"] != "parent-1" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if interrupt != nil && len(children) == 1 {
+			interrupt()
+			_, _ = w.Write([]byte(`{"errors":[{"message":"interrupted"}]}`))
+			return
+		}
+		title := input["title"].(string)
+		children[title]++
+		_, _ = fmt.Fprintf(w, `{"data":{"issueCreate":{"success":true,"issue":{"id":"c","identifier":"ENG-%d","url":"https://linear.app/acme/issue/ENG-%d"}}}}`, 10+len(children), 10+len(children))
+	}))
+	defer server.Close()
+
+	prevURL := linearAPIURL
+	linearAPIURL = server.URL
+	t.Cleanup(func() { linearAPIURL = prevURL })
+	if err := integrationStoreInstance.UpsertLinearConnection(linearConnectionRecord{
+		EncryptedAPIKey: encryptForTest(t, "lin_api_test"),
+		TeamID:          "team-1",
+		TeamKey:         "ENG",
+		Target:          linearTargetIssue,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	run := addTestLocalRun(t, "run_linear_retry", decisionSinkLinear)
+	ctx, cancel := context.WithCancel(context.Background())
+	interrupt = cancel
+	if err := createLocalLinearIssue(ctx, run); err == nil {
+		t.Fatal("expected the interrupted attempt to fail")
+	}
+	mu.Lock()
+	interrupt = nil
+	mu.Unlock()
+
+	retried, _ := readLocalRun(run.ID)
+	if err := createLocalLinearIssue(context.Background(), retried); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+
+	final, _ := readLocalRun(run.ID)
+	nextSteps := len(final.Decision["recommendation"].(map[string]any)["next_steps"].([]string))
+	if len(parents) != 1 {
+		t.Fatalf("created %d parent issues, want 1", len(parents))
+	}
+	if final.LinearIssue == nil || final.LinearIssue.Identifier != "ENG-1" || len(final.LinearIssue.Children) != nextSteps {
+		t.Fatalf("linear issue = %+v, want ENG-1 with %d children", final.LinearIssue, nextSteps)
+	}
+	for title, count := range children {
+		if count != 1 {
+			t.Errorf("issue %q created %d times", title, count)
+		}
+	}
+}
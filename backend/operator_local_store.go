@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultFallbackMaxRuns      = 200
+	defaultFallbackRunRetention = 30 * 24 * time.Hour
+	localOperatorStateFileName  = "decision_runs.json"
+)
+
+type localOperatorStore struct {
+	mu             sync.Mutex
+	path           string
+	runs           map[string]*localOperatorRun
+	slackConnected bool
+}
+
+// localOperatorState is the on-disk form of operatorLocalStore.
+type localOperatorState struct {
+	SlackConnected bool                `json:"slackConnected"`
+	Runs           []*localOperatorRun `json:"runs"`
+}
+
+var operatorLocalStore = localOperatorStore{
+	runs: map[string]*localOperatorRun{},
+}
+
+// initOperatorLocalStore reloads fallback decision runs and connection state
+// from the integrations data directory and resumes runs that were in flight
+// when the server stopped. A file that cannot be read fails startup rather
+// than being replaced by an empty store on the next save.
+func initOperatorLocalStore() error {
+	path := filepath.Join(filepath.Dir(integrationsStatePath), localOperatorStateFileName)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create decision runs dir: %w", err)
+	}
+
+	operatorLocalStore.mu.Lock()
+	operatorLocalStore.path = path
+	if err := operatorLocalStore.loadLocked(); err != nil {
+		operatorLocalStore.path = ""
+		operatorLocalStore.mu.Unlock()
+		return err
+	}
+	pruned := operatorLocalStore.pruneLocked(time.Now().UTC())
+	inFlight := make([]string, 0)
+	for _, run := range operatorLocalStore.runs {
+		if !localRunTerminal(run.Status) {
+			inFlight = append(inFlight, run.ID)
+		}
+	}
+	if pruned > 0 {
+		operatorLocalStore.saveLocked()
+	}
+	operatorLocalStore.mu.Unlock()
+
+	if !localOperatorFallbackEnabled() {
+		return nil
+	}
+	sort.Strings(inFlight)
+	for _, runID := range inFlight {
+		log.Printf("INFO: resuming local decision run %s", runID)
+		updateLocalRun(runID, func(run *localOperatorRun) {
+			run.log(run.CurrentStep, "running", "Resumed after server restart.")
+		})
+		go runLocalPipeline(runID)
+	}
+	return nil
+}
+
+func (s *localOperatorStore) loadLocked() error {
+	content, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read decision runs: %w", err)
+	}
+	var state localOperatorState
+	if err := json.Unmarshal(content, &state); err != nil {
+		return fmt.Errorf("decode decision runs %s: %w", s.path, err)
+	}
+
+	s.slackConnected = state.SlackConnected
+	for _, run := range state.Runs {
+		if run == nil || run.ID == "" {
+			continue
+		}
+		if run.ArtifactTimes == nil {
+			run.ArtifactTimes = map[string]time.Time{}
+		}
+		run.restoreEventCursor()
+		s.runs[run.ID] = run
+	}
+	return nil
+}
+
+// restoreEventCursor marks the loaded state as already emitted so reloading
+// does not replay events the persisted log already holds.
+func (run *localOperatorRun) restoreEventCursor() {
+	run.eventStatus = run.Status
+	run.eventStep = run.CurrentStep
+	run.eventLogs = len(run.Logs)
+	run.eventArtifacts = map[string]struct{}{}
+	for artifactType := range run.ArtifactTimes {
+		run.eventArtifacts[artifactType] = struct{}{}
+	}
+}
+
+// persistLocked writes the store atomically. It is a no-op until
+// initOperatorLocalStore has set the path.
+func (s *localOperatorStore) persistLocked() error {
+	if s.path == "" {
+		return nil
+	}
+	state := localOperatorState{
+		SlackConnected: s.slackConnected,
+		Runs:           make([]*localOperatorRun, 0, len(s.runs)),
+	}
+	for _, run := range s.runs {
+		state.Runs = append(state.Runs, run)
+	}
+	sort.Slice(state.Runs, func(i, j int) bool {
+		return state.Runs[i].CreatedAt.Before(state.Runs[j].CreatedAt)
+	})
+
+	blob, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal decision runs: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, blob, 0o600); err != nil {
+		return fmt.Errorf("write decision runs: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("commit decision runs: %w", err)
+	}
+	return nil
+}
+
+// pruneLocked applies the retention policy: finished runs older than the
+// retention window are dropped, then the oldest finished runs until the store
+// is within its cap. In-flight runs are never pruned.
+func (s *localOperatorStore) pruneLocked(now time.Time) int {
+	finished := make([]*localOperatorRun, 0, len(s.runs))
+	for _, run := range s.runs {
+		if localRunTerminal(run.Status) {
+			finished = append(finished, run)
+		}
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].CreatedAt.Before(finished[j].CreatedAt)
+	})
+
+	pruned := 0
+	for _, run := range finished {
+		expired := fallbackRunRetention > 0 && now.Sub(run.CreatedAt) > fallbackRunRetention
+		overCap := fallbackMaxRuns > 0 && len(s.runs) > fallbackMaxRuns
+		if !expired && !overCap {
+			continue
+		}
+		delete(s.runs, run.ID)
+		pruned++
+	}
+	return pruned
+}
+
+func (s *localOperatorStore) saveLocked() {
+	if err := s.persistLocked(); err != nil {
+		log.Printf("WARNING: %v", err)
+	}
+}
+
+func localRunTerminal(status string) bool {
+	return status == "completed" || status == "failed" || status == "cancelled"
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// useTestOperatorStateDir points the local run store at an empty data
+// directory and returns the path of its state file.
+func useTestOperatorStateDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	prevStatePath := integrationsStatePath
+	integrationsStatePath = filepath.Join(dir, "integrations.json")
+
+	operatorLocalStore.mu.Lock()
+	prevPath, prevRuns, prevSlack := operatorLocalStore.path, operatorLocalStore.runs, operatorLocalStore.slackConnected
+	operatorLocalStore.path, operatorLocalStore.runs, operatorLocalStore.slackConnected = "", map[string]*localOperatorRun{}, false
+	operatorLocalStore.mu.Unlock()
+	t.Cleanup(func() {
+		integrationsStatePath = prevStatePath
+		operatorLocalStore.mu.Lock()
+		operatorLocalStore.path, operatorLocalStore.runs, operatorLocalStore.slackConnected = prevPath, prevRuns, prevSlack
+		operatorLocalStore.mu.Unlock()
+	})
+	return filepath.Join(dir, localOperatorStateFileName)
+}
+
+func TestInitOperatorLocalStoreRejectsCorruptState(t *testing.T) {
+	path := useTestOperatorStateDir(t)
+	corrupt := []byte(`{"slackConnected": true, "runs": [`)
+	if err := os.WriteFile(path, corrupt, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := initOperatorLocalStore(); err == nil {
+		t.Fatal("expected an error for a corrupt decision run store")
+	}
+	// Nothing may be persisted over the unreadable file.
+	operatorLocalStore.mu.Lock()
+	operatorLocalStore.saveLocked()
+	operatorLocalStore.mu.Unlock()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != string(corrupt) {
+		t.Fatalf("corrupt store was overwritten with %q", content)
+	}
+}
+
+func TestInitOperatorLocalStoreLoadsState(t *testing.T) {
+	path := useTestOperatorStateDir(t)
+	if err := initOperatorLocalStore(); err != nil {
+		t.Fatalf("missing store: %v", err)
+	}
+
+	state, err := json.Marshal(localOperatorState{Runs: []*localOperatorRun{
+		{ID: "run-1", FeatureName: "Export", Status: "completed", CurrentStep: "done", CreatedAt: time.Now().UTC()},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, state, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := initOperatorLocalStore(); err != nil {
+		t.Fatalf("valid store: %v", err)
+	}
+	if run, ok := readLocalRun("run-1"); !ok || run.FeatureName != "Export" {
+		t.Fatalf("run-1 was not loaded: %+v", run)
+	}
+}
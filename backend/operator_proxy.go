@@ -2,10 +2,16 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -46,22 +52,130 @@ func handleOperatorSlackConnection(w http.ResponseWriter, r *http.Request) {
 	proxyDecisionOperator(w, r, http.MethodPost, "/api/connections/slack", body)
 }
 
+// handleOperatorJiraConnection verifies and stores the Jira connection locally
+// before forwarding it, so the local pipeline can file epics even when the
+// upstream operator is down.
 func handleOperatorJiraConnection(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
+	switch r.Method {
+	case http.MethodGet:
+		conn, connected := integrationStoreInstance.GetJiraConnection()
+		writeJSON(w, http.StatusOK, jiraConnectionSummary(conn, connected))
+	case http.MethodDelete:
+		if err := integrationStoreInstance.DisconnectJira(); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to disconnect Jira"})
+			return
+		}
+		writeJSON(w, http.StatusOK, okResponse{Status: "ok"})
+	case http.MethodPost:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid request body"})
+			return
+		}
+		var req jiraConnectRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid request body"})
+			return
+		}
+		if _, _, err := connectJira(r.Context(), req); err != nil {
+			writeJSON(w, jiraConnectErrorStatus(err), errorResponse{Error: err.Error()})
+			return
+		}
+		proxyDecisionOperator(w, r, http.MethodPost, "/api/connections/jira", body)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleOperatorLinearConnection manages the Linear connection. Linear is
+// only used by the local pipeline, so nothing is forwarded upstream.
+func handleOperatorLinearConnection(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		conn, connected := integrationStoreInstance.GetLinearConnection()
+		writeJSON(w, http.StatusOK, linearConnectionSummary(conn, connected))
+	case http.MethodDelete:
+		if err := integrationStoreInstance.DisconnectLinear(); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to disconnect Linear"})
+			return
+		}
+		writeJSON(w, http.StatusOK, okResponse{Status: "ok"})
+	case http.MethodPost:
+		var req linearConnectRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid request body"})
+			return
+		}
+		conn, _, err := connectLinear(r.Context(), req)
+		if err != nil {
+			status := http.StatusBadRequest
+			var linearErr *linearStatusError
+			var netErr *url.Error
+			if errors.As(err, &linearErr) || errors.As(err, &netErr) {
+				status = http.StatusBadGateway
+			}
+			writeJSON(w, status, errorResponse{Error: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, linearConnectionSummary(conn, true))
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func handleOperatorLinearTeams(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	body, err := io.ReadAll(r.Body)
+	client, conn, err := storedLinearClient()
 	if err != nil {
-		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid request body"})
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
 		return
 	}
-	proxyDecisionOperator(w, r, http.MethodPost, "/api/connections/jira", body)
+	viewer, err := client.Viewer(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusBadGateway, errorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"team_key": conn.TeamKey,
+		"teams":    viewer.Teams.Nodes,
+	})
+}
+
+// handleOperatorJiraProjects lists the projects and issue types visible to the
+// stored Jira credentials.
+func handleOperatorJiraProjects(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	client, conn, err := storedJiraClient()
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	projects, err := client.Projects(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusBadGateway, errorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"project_key": conn.ProjectKey,
+		"projects":    projects,
+	})
 }
 
 func handleOperatorDecisionRuns(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
+		query, err := parseDecisionRunQuery(r.URL.Query())
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+			return
+		}
+		r.URL.RawQuery = query.Values().Encode()
 		proxyDecisionOperator(w, r, http.MethodGet, "/api/decision-runs", nil)
 	case http.MethodPost:
 		body, err := io.ReadAll(r.Body)
@@ -69,18 +183,31 @@ func handleOperatorDecisionRuns(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid request body"})
 			return
 		}
+		var payload struct {
+			Sink string `json:"sink"`
+		}
+		sink := ""
+		if err := json.Unmarshal(body, &payload); err == nil {
+			if sink, err = parseDecisionSink(payload.Sink); err != nil {
+				writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+				return
+			}
+		}
+		// The upstream operator has no Linear step, so Linear runs always go
+		// through the local pipeline.
+		if sink == decisionSinkLinear {
+			serveLocalDecisionOperator(w, http.MethodPost, "/api/decision-runs", body)
+			return
+		}
 		proxyDecisionOperator(w, r, http.MethodPost, "/api/decision-runs", body)
 	default:
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 	}
 }
 
-func handleOperatorDecisionRunByID(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
+var decisionRunActions = map[string]bool{"cancel": true, "retry": true, "rerun": true}
 
+func handleOperatorDecisionRunByID(w http.ResponseWriter, r *http.Request) {
 	prefix := "/api/operator/decision-runs/"
 	raw := strings.TrimPrefix(r.URL.Path, prefix)
 	raw = strings.TrimSpace(raw)
@@ -90,15 +217,204 @@ func handleOperatorDecisionRunByID(w http.ResponseWriter, r *http.Request) {
 	}
 
 	target := "/api/decision-runs/" + raw
-	proxyDecisionOperator(w, r, http.MethodGet, target, nil)
+	runID, action, _ := strings.Cut(strings.Trim(raw, "/"), "/")
+	// Runs the local pipeline owns (Linear runs, and runs started during an
+	// upstream outage) are unknown upstream and always served locally.
+	local := localRunExists(runID)
+	switch {
+	case r.Method == http.MethodGet && action == "events" && local:
+		serveLocalDecisionRunEvents(w, r, runID)
+	case r.Method == http.MethodGet && action == "events":
+		proxyDecisionOperatorEvents(w, r, runID, target)
+	case (r.Method == http.MethodGet || r.Method == http.MethodDelete) && action == "" && local:
+		serveLocalDecisionOperator(w, r.Method, target, nil)
+	case (r.Method == http.MethodGet || r.Method == http.MethodDelete) && action == "":
+		proxyDecisionOperator(w, r, r.Method, target, nil)
+	case r.Method == http.MethodPost && decisionRunActions[action]:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid request body"})
+			return
+		}
+		if len(bytes.TrimSpace(body)) == 0 {
+			body = []byte("{}")
+		}
+		if local {
+			serveLocalDecisionOperator(w, http.MethodPost, target, body)
+			return
+		}
+		proxyDecisionOperator(w, r, http.MethodPost, target, body)
+	case r.Method == http.MethodPost && action == "github-issue":
+		handleDecisionRunGitHubIssue(w, r, runID)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
 }
 
-func proxyDecisionOperator(w http.ResponseWriter, r *http.Request, method string, targetPath string, body []byte) {
+// githubTrackingIssueRuns holds the runs whose tracking issue is being
+// opened right now. Claiming a run and checking for an existing issue happen
+// under one lock, so concurrent requests cannot both file an issue.
+var githubTrackingIssueRuns = struct {
+	sync.Mutex
+	inFlight map[string]bool
+}{inFlight: map[string]bool{}}
+
+// handleDecisionRunGitHubIssue opens a GitHub tracking issue for a completed
+// run. The issue is recorded per run ID, upstream or local, and repeat calls
+// return it instead of filing a duplicate.
+func handleDecisionRunGitHubIssue(w http.ResponseWriter, r *http.Request, runID string) {
+	var req githubTrackingIssueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid json"})
+		return
+	}
+
+	githubTrackingIssueRuns.Lock()
+	existing, found := integrationStoreInstance.GetGitHubTrackingIssue(runID)
+	if !found {
+		if run, ok := readLocalRun(runID); ok && run.GitHubIssue != nil {
+			existing, found = *run.GitHubIssue, true
+		}
+	}
+	busy := githubTrackingIssueRuns.inFlight[runID]
+	if !found && !busy {
+		githubTrackingIssueRuns.inFlight[runID] = true
+	}
+	githubTrackingIssueRuns.Unlock()
+	switch {
+	case found:
+		writeJSON(w, http.StatusOK, existing)
+		return
+	case busy:
+		writeJSON(w, http.StatusConflict, errorResponse{Error: "a tracking issue is already being opened for this run"})
+		return
+	}
+	defer func() {
+		githubTrackingIssueRuns.Lock()
+		delete(githubTrackingIssueRuns.inFlight, runID)
+		githubTrackingIssueRuns.Unlock()
+	}()
+
+	issue, err := openGitHubTrackingIssue(r.Context(), runID, req.Repo)
+	if err != nil {
+		status := http.StatusBadRequest
+		var githubErr *githubStatusError
+		var netErr *url.Error
+		switch {
+		case errors.Is(err, errDecisionRunNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, errDecisionRunNotReady):
+			status = http.StatusConflict
+		case errors.As(err, &githubErr), errors.As(err, &netErr):
+			status = http.StatusBadGateway
+		}
+		writeJSON(w, status, errorResponse{Error: err.Error()})
+		return
+	}
+
+	// The issue exists now; failing to record it is logged rather than
+	// returned, since a retry would file a duplicate.
+	if err := integrationStoreInstance.SaveGitHubTrackingIssue(runID, issue); err != nil {
+		log.Printf("WARNING: decision run %s: record GitHub tracking issue: %v", runID, err)
+	}
+	updateLocalRun(runID, func(run *localOperatorRun) {
+		run.GitHubIssue = &issue
+		run.ArtifactTimes[artifactTypeGitHubIssue] = time.Now().UTC()
+		run.log(localStepDone, "success", fmt.Sprintf("Opened GitHub tracking issue %s#%d.", issue.Repo, issue.Number))
+	})
+	writeJSON(w, http.StatusCreated, issue)
+}
+
+var (
+	errDecisionRunNotFound = errors.New("decision run not found")
+	errDecisionRunNotReady = errors.New("decision run is not ready")
+)
+
+// fetchDecisionRunDetail reads a run from the local pipeline when it owns the
+// run, otherwise from the upstream operator, falling back to the local store
+// when the upstream is unavailable. Upstream detail goes through the same
+// artifact validation as the proxy, so callers never act on artifacts the UI
+// would have been shown as invalid.
+func fetchDecisionRunDetail(ctx context.Context, runID string) (map[string]any, error) {
+	if localRunExists(runID) {
+		return localDecisionRunDetailMap(runID)
+	}
+	target := "/api/decision-runs/" + url.PathEscape(runID)
+	upstreamErr := ""
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, decisionOperatorBaseURL()+target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	client := &http.Client{Timeout: 60 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		upstreamErr = fmt.Sprintf("upstream request failed: %v", err)
+	} else {
+		defer resp.Body.Close()
+		body, readErr := io.ReadAll(resp.Body)
+		switch {
+		case readErr != nil:
+			upstreamErr = "failed to read upstream response body"
+		case resp.StatusCode == http.StatusNotFound:
+			return nil, errDecisionRunNotFound
+		case resp.StatusCode >= http.StatusInternalServerError:
+			upstreamErr = fmt.Sprintf("upstream returned %d", resp.StatusCode)
+		case resp.StatusCode != http.StatusOK:
+			return nil, fmt.Errorf("decision operator returned %d", resp.StatusCode)
+		default:
+			if validated, changed := validateDecisionRunDetail(body); changed {
+				log.Printf("decision operator run %s: artifacts failed validation", runID)
+				body = validated
+			}
+			var detail map[string]any
+			if err := json.Unmarshal(body, &detail); err != nil {
+				return nil, fmt.Errorf("decode decision run: %w", err)
+			}
+			return detail, nil
+		}
+	}
+
+	if !localOperatorFallbackEnabled() {
+		return nil, fmt.Errorf("decision operator unreachable: %s", upstreamErr)
+	}
+	return localDecisionRunDetailMap(runID)
+}
+
+// localDecisionRunDetailMap is the local run detail in the decoded JSON form
+// an upstream response would have.
+func localDecisionRunDetailMap(runID string) (map[string]any, error) {
+	status, payload, _ := localDecisionRunDetail(runID)
+	if status == http.StatusNotFound {
+		return nil, errDecisionRunNotFound
+	}
+	blob, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	var detail map[string]any
+	if err := json.Unmarshal(blob, &detail); err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
+
+func isDecisionRunDetailPath(targetPath string) bool {
+	runID, ok := strings.CutPrefix(targetPath, "/api/decision-runs/")
+	runID = strings.Trim(runID, "/")
+	return ok && runID != "" && !strings.Contains(runID, "/")
+}
+
+func decisionOperatorBaseURL() string {
 	base := strings.TrimSuffix(strings.TrimSpace(decisionOperatorAPIURL), "/")
 	if base == "" {
 		base = "http://localhost:8000"
 	}
-	targetURL := base + targetPath
+	return base
+}
+
+func proxyDecisionOperator(w http.ResponseWriter, r *http.Request, method string, targetPath string, body []byte) {
+	targetURL := decisionOperatorBaseURL() + targetPath
 	if rawQuery := strings.TrimSpace(r.URL.RawQuery); rawQuery != "" {
 		targetURL = targetURL + "?" + rawQuery
 	}
@@ -121,7 +437,7 @@ func proxyDecisionOperator(w http.ResponseWriter, r *http.Request, method string
 	client := &http.Client{Timeout: 60 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		if tryLocalDecisionOperatorFallback(w, method, targetPath, body, fmt.Sprintf("upstream request failed: %v", err)) {
+		if tryLocalDecisionOperatorFallback(w, method, targetPath, r.URL.Query(), body, fmt.Sprintf("upstream request failed: %v", err)) {
 			return
 		}
 		writeJSON(w, http.StatusBadGateway, errorResponse{Error: fmt.Sprintf("decision operator unreachable: %v", err)})
@@ -131,7 +447,7 @@ func proxyDecisionOperator(w http.ResponseWriter, r *http.Request, method string
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		if tryLocalDecisionOperatorFallback(w, method, targetPath, body, "failed to read upstream response body") {
+		if tryLocalDecisionOperatorFallback(w, method, targetPath, r.URL.Query(), body, "failed to read upstream response body") {
 			return
 		}
 		writeJSON(w, http.StatusBadGateway, errorResponse{Error: "failed to read decision operator response"})
@@ -143,11 +459,21 @@ func proxyDecisionOperator(w http.ResponseWriter, r *http.Request, method string
 		if len(bytes.TrimSpace(respBody)) > 0 {
 			upstreamErr = upstreamErr + ": " + string(respBody)
 		}
-		if tryLocalDecisionOperatorFallback(w, method, targetPath, body, upstreamErr) {
+		if tryLocalDecisionOperatorFallback(w, method, targetPath, r.URL.Query(), body, upstreamErr) {
 			return
 		}
 	}
 
+	if method == http.MethodGet && isDecisionRunDetailPath(targetPath) && resp.StatusCode == http.StatusOK {
+		if validated, changed := validateDecisionRunDetail(respBody); changed {
+			log.Printf("decision operator %s: artifacts failed validation", targetPath)
+			respBody = validated
+		}
+	}
+	if method == http.MethodGet && targetPath == "/api/decision-runs" && resp.StatusCode == http.StatusOK {
+		respBody = mergeLocalDecisionRuns(respBody, r.URL.Query())
+	}
+
 	contentType := resp.Header.Get("Content-Type")
 	if strings.TrimSpace(contentType) == "" {
 		contentType = "application/json"
@@ -156,3 +482,60 @@ func proxyDecisionOperator(w http.ResponseWriter, r *http.Request, method string
 	w.WriteHeader(resp.StatusCode)
 	_, _ = w.Write(respBody)
 }
+
+// proxyDecisionOperatorEvents relays the upstream SSE stream chunk by chunk,
+// forwarding Last-Event-ID so the upstream can resume where the client left off.
+func proxyDecisionOperatorEvents(w http.ResponseWriter, r *http.Request, runID string, targetPath string) {
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, decisionOperatorBaseURL()+targetPath, nil)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to build operator request"})
+		return
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if lastID := strings.TrimSpace(r.Header.Get("Last-Event-ID")); lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		if r.Context().Err() != nil {
+			return
+		}
+		if tryLocalDecisionRunEvents(w, r, runID) {
+			return
+		}
+		writeJSON(w, http.StatusBadGateway, errorResponse{Error: fmt.Sprintf("decision operator unreachable: %v", err)})
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError && tryLocalDecisionRunEvents(w, r, runID) {
+		return
+	}
+
+	flusher, canFlush := w.(http.Flusher)
+	contentType := resp.Header.Get("Content-Type")
+	if strings.TrimSpace(contentType) == "" {
+		contentType = "text/event-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(resp.StatusCode)
+
+	buf := make([]byte, 4096)
+	for {
+		n, readErr := resp.Body.Read(buf)
+		if n > 0 {
+			if _, err := w.Write(buf[:n]); err != nil {
+				return
+			}
+			if canFlush {
+				flusher.Flush()
+			}
+		}
+		if readErr != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeOperator stands in for the upstream decision operator and records the
+// paths it was asked for.
+type fakeOperator struct {
+	mu       sync.Mutex
+	requests []string
+	handler  http.HandlerFunc
+}
+
+func useFakeOperator(t *testing.T, handler http.HandlerFunc) *fakeOperator {
+	t.Helper()
+	fake := &fakeOperator{handler: handler}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fake.mu.Lock()
+		fake.requests = append(fake.requests, r.Method+" "+r.URL.Path)
+		fake.mu.Unlock()
+		fake.handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	prev := decisionOperatorAPIURL
+	decisionOperatorAPIURL = server.URL
+	t.Cleanup(func() { decisionOperatorAPIURL = prev })
+	return fake
+}
+
+func (f *fakeOperator) calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.requests...)
+}
+
+// waitForLocalRun waits for a started run's pipeline goroutine to finish
+// and then removes the run.
+func waitForLocalRun(t *testing.T, runID string) {
+	t.Helper()
+	t.Cleanup(func() {
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if run, ok := readLocalRun(runID); !ok || localRunTerminal(run.Status) {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		operatorLocalStore.mu.Lock()
+		delete(operatorLocalStore.runs, runID)
+		operatorLocalStore.mu.Unlock()
+	})
+}
+
+func decodeTestJSON(t *testing.T, rec *httptest.ResponseRecorder) map[string]any {
+	t.Helper()
+	var out map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		t.Fatalf("decode %q: %v", rec.Body.String(), err)
+	}
+	return out
+}
+
+func TestLinearRunsUseLocalPipeline(t *testing.T) {
+	fake := useFakeOperator(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/api/decision-runs" {
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = w.Write([]byte(`{"decision_run_id":"up_1","status":"queued"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"not found"}`))
+	})
+
+	rec := httptest.NewRecorder()
+	handleOperatorDecisionRuns(rec, httptest.NewRequest(http.MethodPost, "/api/operator/decision-runs", strings.NewReader(`{"feature_name":"Dark mode","sink":"linear"}`)))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+	created := decodeTestJSON(t, rec)
+	runID := stringFromAny(created["decision_run_id"])
+	waitForLocalRun(t, runID)
+	if created["mode"] != "local" || !localRunExists(runID) {
+		t.Fatalf("linear run was not started locally: %v", created)
+	}
+	if calls := fake.calls(); len(calls) != 0 {
+		t.Fatalf("linear run reached the upstream: %v", calls)
+	}
+
+	rec = httptest.NewRecorder()
+	handleOperatorDecisionRunByID(rec, httptest.NewRequest(http.MethodGet, "/api/operator/decision-runs/"+runID, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("detail status %d: %s", rec.Code, rec.Body.String())
+	}
+	if detail := decodeTestJSON(t, rec); detail["sink"] != decisionSinkLinear || detail["id"] != runID {
+		t.Fatalf("detail = %v", detail)
+	}
+	if calls := fake.calls(); len(calls) != 0 {
+		t.Fatalf("local run detail was proxied: %v", calls)
+	}
+
+	rec = httptest.NewRecorder()
+	handleOperatorDecisionRuns(rec, httptest.NewRequest(http.MethodPost, "/api/operator/decision-runs", strings.NewReader(`{"feature_name":"Dark mode","sink":"jira"}`)))
+	if rec.Code != http.StatusAccepted || decodeTestJSON(t, rec)["decision_run_id"] != "up_1" {
+		t.Fatalf("jira run was not forwarded: %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handleOperatorDecisionRuns(rec, httptest.NewRequest(http.MethodPost, "/api/operator/decision-runs", strings.NewReader(`{"feature_name":"Dark mode","sink":"asana"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown sink status %d", rec.Code)
+	}
+}
+
+func TestDecisionRunListMergesLocalRuns(t *testing.T) {
+	base := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	useFakeOperator(t, func(w http.ResponseWriter, r *http.Request) {
+		query, err := parseDecisionRunQuery(r.URL.Query())
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		upstream := []map[string]any{
+			{"id": "up_3", "created_at": base.Add(3 * time.Hour).Format(time.RFC3339)},
+			{"id": "up_1", "created_at": base.Add(1 * time.Hour).Format(time.RFC3339)},
+		}
+		page := decisionRunPage{Runs: []map[string]any{}, Total: len(upstream)}
+		for _, run := range upstream {
+			at, _ := time.Parse(time.RFC3339, run["created_at"].(string))
+			if query.Cursor != nil && !query.Cursor.after(at, run["id"].(string)) {
+				continue
+			}
+			if len(page.Runs) == query.Limit {
+				last := page.Runs[len(page.Runs)-1]
+				lastAt, _ := time.Parse(time.RFC3339, last["created_at"].(string))
+				page.NextCursor = decisionRunCursor{CreatedAt: lastAt, ID: last["id"].(string)}.encode()
+				break
+			}
+			page.Runs = append(page.Runs, run)
+		}
+		_ = json.NewEncoder(w).Encode(page)
+	})
+
+	for _, run := range []*localOperatorRun{
+		{ID: "run_local_4", FeatureName: "Dark mode", Status: "completed", CreatedAt: base.Add(4*time.Hour + 500*time.Millisecond)},
+		{ID: "run_local_2", FeatureName: "Dark mode", Status: "failed", CreatedAt: base.Add(2 * time.Hour)},
+	} {
+		operatorLocalStore.mu.Lock()
+		operatorLocalStore.runs[run.ID] = run
+		operatorLocalStore.mu.Unlock()
+		waitForLocalRun(t, run.ID)
+	}
+
+	var ids []string
+	cursor := ""
+	for pages := 0; pages < 5; pages++ {
+		rec := httptest.NewRecorder()
+		handleOperatorDecisionRuns(rec, httptest.NewRequest(http.MethodGet, "/api/operator/decision-runs?limit=2&cursor="+cursor, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("list status %d: %s", rec.Code, rec.Body.String())
+		}
+		var page decisionRunPage
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+			t.Fatal(err)
+		}
+		if page.Total != 4 {
+			t.Fatalf("total = %d, want 4", page.Total)
+		}
+		for _, run := range page.Runs {
+			ids = append(ids, stringFromAny(run["id"]))
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	want := []string{"run_local_4", "up_3", "run_local_2", "up_1"}
+	if strings.Join(ids, ",") != strings.Join(want, ",") {
+		t.Fatalf("merged pages = %v, want %v", ids, want)
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultDecisionRunPageSize = 50
+	maxDecisionRunPageSize     = 200
+)
+
+const (
+	decisionSinkJira   = "jira"
+	decisionSinkLinear = "linear"
+)
+
+var (
+	decisionRunStatuses = []string{"queued", "running", "completed", "failed", "cancelled", "needs_user_action"}
+	decisionRunSinks    = []string{decisionSinkJira, decisionSinkLinear}
+)
+
+// parseDecisionSink validates the tracker a run files its decision in. Runs
+// that do not choose one use Jira.
+func parseDecisionSink(raw string) (string, error) {
+	sink := strings.ToLower(strings.TrimSpace(raw))
+	if sink == "" {
+		return decisionSinkJira, nil
+	}
+	if !slices.Contains(decisionRunSinks, sink) {
+		return "", fmt.Errorf("sink %q is not one of %s", sink, strings.Join(decisionRunSinks, ", "))
+	}
+	return sink, nil
+}
+
+// decisionRunQuery is the listing contract for GET /api/decision-runs, shared
+// by the proxy and the local fallback. Runs are ordered newest first by
+// created_at, then id.
+type decisionRunQuery struct {
+	Limit         int
+	Cursor        *decisionRunCursor
+	Statuses      []string
+	Feature       string
+	Search        []string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+}
+
+// decisionRunCursor points at the last run of the previous page.
+type decisionRunCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+type decisionRunPage struct {
+	Runs       []map[string]any `json:"runs"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+	Total      int              `json:"total"`
+}
+
+func parseDecisionRunQuery(values url.Values) (decisionRunQuery, error) {
+	query := decisionRunQuery{Limit: defaultDecisionRunPageSize}
+
+	if raw := strings.TrimSpace(values.Get("limit")); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxDecisionRunPageSize {
+			return query, fmt.Errorf("limit must be between 1 and %d", maxDecisionRunPageSize)
+		}
+		query.Limit = limit
+	}
+	if raw := strings.TrimSpace(values.Get("cursor")); raw != "" {
+		cursor, err := decodeDecisionRunCursor(raw)
+		if err != nil {
+			return query, err
+		}
+		query.Cursor = &cursor
+	}
+	for _, raw := range strings.Split(values.Get("status"), ",") {
+		status := strings.ToLower(strings.TrimSpace(raw))
+		if status == "" {
+			continue
+		}
+		if !slices.Contains(decisionRunStatuses, status) {
+			return query, fmt.Errorf("status %q is not one of %s", status, strings.Join(decisionRunStatuses, ", "))
+		}
+		if !slices.Contains(query.Statuses, status) {
+			query.Statuses = append(query.Statuses, status)
+		}
+	}
+	query.Feature = strings.TrimSpace(values.Get("feature"))
+	query.Search = strings.Fields(strings.ToLower(values.Get("q")))
+
+	var err error
+	if query.CreatedAfter, err = parseDecisionRunTime(values.Get("created_after")); err != nil {
+		return query, fmt.Errorf("created_after: %w", err)
+	}
+	if query.CreatedBefore, err = parseDecisionRunTime(values.Get("created_before")); err != nil {
+		return query, fmt.Errorf("created_before: %w", err)
+	}
+	if !query.CreatedAfter.IsZero() && !query.CreatedBefore.IsZero() && !query.CreatedAfter.Before(query.CreatedBefore) {
+		return query, errors.New("created_after must be before created_before")
+	}
+	return query, nil
+}
+
+// parseDecisionRunTime accepts an RFC 3339 timestamp or a bare date, which
+// means midnight UTC.
+func parseDecisionRunTime(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if parsed, err := time.Parse(time.RFC3339, raw); err == nil {
+		return parsed.UTC(), nil
+	}
+	if parsed, err := time.Parse(time.DateOnly, raw); err == nil {
+		return parsed, nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not an RFC 3339 timestamp or YYYY-MM-DD date", raw)
+}
+
+// Values renders the normalized query for the upstream operator.
+func (q decisionRunQuery) Values() url.Values {
+	values := url.Values{}
+	values.Set("limit", strconv.Itoa(q.Limit))
+	if q.Cursor != nil {
+		values.Set("cursor", q.Cursor.encode())
+	}
+	if len(q.Statuses) > 0 {
+		values.Set("status", strings.Join(q.Statuses, ","))
+	}
+	if q.Feature != "" {
+		values.Set("feature", q.Feature)
+	}
+	if len(q.Search) > 0 {
+		values.Set("q", strings.Join(q.Search, " "))
+	}
+	if !q.CreatedAfter.IsZero() {
+		values.Set("created_after", q.CreatedAfter.Format(time.RFC3339))
+	}
+	if !q.CreatedBefore.IsZero() {
+		values.Set("created_before", q.CreatedBefore.Format(time.RFC3339))
+	}
+	return values
+}
+
+func (q decisionRunQuery) matches(status string, feature string, createdAt time.Time) bool {
+	if len(q.Statuses) > 0 && !slices.Contains(q.Statuses, status) {
+		return false
+	}
+	if q.Feature != "" && !strings.EqualFold(strings.TrimSpace(feature), q.Feature) {
+		return false
+	}
+	if len(q.Search) > 0 && !mentionsAllTerms(feature, strings.Join(q.Search, " ")) {
+		return false
+	}
+	if !q.CreatedAfter.IsZero() && createdAt.Before(q.CreatedAfter) {
+		return false
+	}
+	if !q.CreatedBefore.IsZero() && !createdAt.Before(q.CreatedBefore) {
+		return false
+	}
+	return true
+}
+
+// after reports whether a run sorts after the cursor, i.e. belongs on a
+// later page.
+func (c decisionRunCursor) after(createdAt time.Time, id string) bool {
+	if !createdAt.Equal(c.CreatedAt) {
+		return createdAt.Before(c.CreatedAt)
+	}
+	return id < c.ID
+}
+
+func (c decisionRunCursor) encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID))
+}
+
+func decodeDecisionRunCursor(raw string) (decisionRunCursor, error) {
+	invalid := errors.New("cursor is invalid")
+	decoded, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return decisionRunCursor{}, invalid
+	}
+	stamp, id, found := strings.Cut(string(decoded), "|")
+	createdAt, err := time.Parse(time.RFC3339Nano, stamp)
+	if !found || err != nil || id == "" {
+		return decisionRunCursor{}, invalid
+	}
+	return decisionRunCursor{CreatedAt: createdAt, ID: id}, nil
+}
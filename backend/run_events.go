@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxRunEvents         = 500
+	sseHeartbeatInterval = 15 * time.Second
+	sseRetryInterval     = 2 * time.Second
+
+	runEventStatus   = "status"
+	runEventStep     = "step"
+	runEventProgress = "progress"
+	runEventLog      = "log"
+	runEventArtifact = "artifact"
+	runEventDone     = "done"
+)
+
+type runEvent struct {
+	Seq  int64          `json:"seq"`
+	Type string         `json:"type"`
+	At   time.Time      `json:"at"`
+	Data map[string]any `json:"data"`
+}
+
+// appendRunEvent numbers events from a per-run counter so IDs stay stable
+// after older events are trimmed from the retained window.
+func appendRunEvent(events []runEvent, seq *int64, kind string, data map[string]any) []runEvent {
+	*seq++
+	events = append(events, runEvent{
+		Seq:  *seq,
+		Type: kind,
+		At:   time.Now().UTC(),
+		Data: data,
+	})
+	if len(events) > maxRunEvents {
+		events = append([]runEvent(nil), events[len(events)-maxRunEvents:]...)
+	}
+	return events
+}
+
+func runEventsAfter(events []runEvent, lastID int64) []runEvent {
+	for idx, event := range events {
+		if event.Seq > lastID {
+			return events[idx:]
+		}
+	}
+	return nil
+}
+
+func parseLastEventID(r *http.Request) int64 {
+	raw := strings.TrimSpace(r.Header.Get("Last-Event-ID"))
+	if raw == "" {
+		raw = strings.TrimSpace(r.URL.Query().Get("lastEventId"))
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id < 0 {
+		return 0
+	}
+	return id
+}
+
+type sseWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+func startSSE(w http.ResponseWriter) (*sseWriter, bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "streaming unsupported"})
+		return nil, false
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetryInterval.Milliseconds())
+	flusher.Flush()
+	return &sseWriter{w: w, flusher: flusher}, true
+}
+
+func (s *sseWriter) Event(event runEvent) error {
+	payload := map[string]any{"at": event.At.Format(time.RFC3339)}
+	for key, value := range event.Data {
+		payload[key] = value
+	}
+	blob, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if event.Seq > 0 {
+		if _, err := fmt.Fprintf(s.w, "id: %d\n", event.Seq); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event.Type, blob)
+	return err
+}
+
+func (s *sseWriter) Comment(text string) error {
+	_, err := fmt.Fprintf(s.w, ": %s\n\n", text)
+	return err
+}
+
+func (s *sseWriter) Flush() {
+	s.flusher.Flush()
+}
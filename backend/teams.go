@@ -0,0 +1,1046 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	providerTeams                = "teams"
+	defaultTeamsGraphAPIURL      = "https://graph.microsoft.com/v1.0"
+	defaultTeamsLoginURL         = "https://login.microsoftonline.com"
+	teamsGraphScope              = "https://graph.microsoft.com/.default"
+	teamsRequestTimeout          = 20 * time.Second
+	teamsPageSize                = 50
+	maxTeamsImportPages          = 10
+	teamsNotificationTimeout     = 30 * time.Second
+	defaultTeamsConnectionDetail = "Capture feedback from Teams channels"
+	// Graph caps channel message subscriptions at one hour. They are created
+	// a little short of that and renewed well before they lapse.
+	teamsSubscriptionLifetime = 55 * time.Minute
+	teamsRenewBefore          = 20 * time.Minute
+	teamsRenewInterval        = 5 * time.Minute
+)
+
+var (
+	teamsHTMLTag = regexp.MustCompile(`<[^>]*>`)
+	// teamsMessageResource matches the relative message path a channel
+	// message notification carries. Anything else, such as an absolute URL,
+	// is not fetched with the Graph token.
+	teamsMessageResource = regexp.MustCompile(`^/?teams\('[^'/?#]+'\)/channels\('[^'/?#]+'\)/messages\('[^'/?#]+'\)(/replies\('[^'/?#]+'\))?$`)
+)
+
+type teamsSetupPersisted struct {
+	TenantID              string    `json:"tenantId"`
+	ClientID              string    `json:"clientId"`
+	TeamID                string    `json:"teamId"`
+	RedirectURL           string    `json:"redirectUrl"`
+	NotificationURL       string    `json:"notificationUrl"`
+	AppUIBaseURL          string    `json:"appUIBaseURL"`
+	EncryptedClientSecret string    `json:"encryptedClientSecret,omitempty"`
+	UpdatedAt             time.Time `json:"updatedAt"`
+}
+
+type teamsSetupUpsertRequest struct {
+	TenantID        string `json:"tenantId"`
+	ClientID        string `json:"clientId"`
+	ClientSecret    string `json:"clientSecret"`
+	TeamID          string `json:"teamId"`
+	RedirectURL     string `json:"redirectUrl"`
+	NotificationURL string `json:"notificationUrl"`
+	AppUIBaseURL    string `json:"appUIBaseURL"`
+}
+
+type teamsSetupConfigView struct {
+	TenantID        string `json:"tenantId"`
+	ClientID        string `json:"clientId"`
+	TeamID          string `json:"teamId"`
+	RedirectURL     string `json:"redirectUrl"`
+	NotificationURL string `json:"notificationUrl"`
+	AppUIBaseURL    string `json:"appUIBaseURL"`
+	HasClientSecret bool   `json:"hasClientSecret"`
+	UpdatedAt       string `json:"updatedAt,omitempty"`
+}
+
+type teamsSetupStatusView struct {
+	ReadyForConnect bool     `json:"readyForConnect"`
+	MissingFields   []string `json:"missingFields"`
+	Connected       bool     `json:"connected"`
+	Team            string   `json:"team,omitempty"`
+}
+
+type teamsSetupResponse struct {
+	Config teamsSetupConfigView `json:"config"`
+	Status teamsSetupStatusView `json:"status"`
+}
+
+type teamsRuntimeConfig struct {
+	TenantID        string
+	ClientID        string
+	ClientSecret    string
+	TeamID          string
+	RedirectURL     string
+	NotificationURL string
+	AppUIBaseURL    string
+}
+
+// teamsConnectionRecord is a consented tenant and the team read from it. Each
+// selected channel has a Graph subscription; notifications must echo the
+// connection's client state.
+type teamsConnectionRecord struct {
+	TenantID             string                    `json:"tenantId"`
+	TeamID               string                    `json:"teamId"`
+	TeamName             string                    `json:"teamName"`
+	EncryptedClientState string                    `json:"encryptedClientState"`
+	Subscriptions        []teamsSubscriptionRecord `json:"subscriptions"`
+	ConnectedAt          time.Time                 `json:"connectedAt"`
+	UpdatedAt            time.Time                 `json:"updatedAt"`
+}
+
+type teamsSubscriptionRecord struct {
+	ID          string    `json:"id"`
+	ChannelID   string    `json:"channelId"`
+	ChannelName string    `json:"channelName"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
+
+type graphTeam struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName"`
+}
+
+type graphChannel struct {
+	ID             string `json:"id"`
+	DisplayName    string `json:"displayName"`
+	MembershipType string `json:"membershipType"`
+}
+
+type graphChatMessage struct {
+	ID              string     `json:"id"`
+	ReplyToID       string     `json:"replyToId"`
+	MessageType     string     `json:"messageType"`
+	CreatedDateTime time.Time  `json:"createdDateTime"`
+	DeletedDateTime *time.Time `json:"deletedDateTime"`
+	WebURL          string     `json:"webUrl"`
+	From            *struct {
+		User *struct {
+			ID          string `json:"id"`
+			DisplayName string `json:"displayName"`
+		} `json:"user"`
+		Application *struct {
+			DisplayName string `json:"displayName"`
+		} `json:"application"`
+	} `json:"from"`
+	Body struct {
+		ContentType string `json:"contentType"`
+		Content     string `json:"content"`
+	} `json:"body"`
+	Replies []graphChatMessage `json:"replies"`
+}
+
+type graphSubscription struct {
+	ID                 string    `json:"id"`
+	Resource           string    `json:"resource"`
+	ExpirationDateTime time.Time `json:"expirationDateTime"`
+}
+
+type graphNotification struct {
+	SubscriptionID string `json:"subscriptionId"`
+	ClientState    string `json:"clientState"`
+	ChangeType     string `json:"changeType"`
+	Resource       string `json:"resource"`
+	TenantID       string `json:"tenantId"`
+}
+
+type teamsClient struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+type teamsStatusError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *teamsStatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("microsoft graph returned status %d", e.Status)
+	}
+	return fmt.Sprintf("microsoft graph returned status %d: %s", e.Status, e.Message)
+}
+
+// teamsTokens caches the app-only Graph token, which is valid for about an
+// hour, per tenant and client.
+var teamsTokens struct {
+	mu        sync.Mutex
+	key       string
+	token     string
+	expiresAt time.Time
+}
+
+func teamsAccessToken(ctx context.Context, cfg teamsRuntimeConfig) (string, error) {
+	key := cfg.TenantID + "|" + cfg.ClientID + "|" + cfg.ClientSecret
+	teamsTokens.mu.Lock()
+	defer teamsTokens.mu.Unlock()
+	if teamsTokens.key == key && time.Until(teamsTokens.expiresAt) > time.Minute {
+		return teamsTokens.token, nil
+	}
+
+	form := url.Values{}
+	form.Set("client_id", cfg.ClientID)
+	form.Set("client_secret", cfg.ClientSecret)
+	form.Set("scope", teamsGraphScope)
+	form.Set("grant_type", "client_credentials")
+	endpoint := strings.TrimRight(teamsLoginURL, "/") + "/" + url.PathEscape(cfg.TenantID) + "/oauth2/v2.0/token"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := &http.Client{Timeout: teamsRequestTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("microsoft token request: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("microsoft token request: %w", err)
+	}
+	var parsed struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+		Error       string `json:"error"`
+		Description string `json:"error_description"`
+	}
+	_ = json.Unmarshal(body, &parsed)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 || parsed.AccessToken == "" {
+		return "", &teamsStatusError{Status: resp.StatusCode, Code: parsed.Error, Message: firstNonEmpty(parsed.Description, parsed.Error)}
+	}
+	teamsTokens.key = key
+	teamsTokens.token = parsed.AccessToken
+	teamsTokens.expiresAt = time.Now().Add(time.Duration(parsed.ExpiresIn) * time.Second)
+	return parsed.AccessToken, nil
+}
+
+func newTeamsClient(ctx context.Context, cfg teamsRuntimeConfig) (*teamsClient, error) {
+	if missing := teamsMissingFields(cfg); len(missing) > 0 {
+		return nil, fmt.Errorf("missing required Teams setup fields: %s", strings.Join(missing, ", "))
+	}
+	token, err := teamsAccessToken(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &teamsClient{
+		baseURL: strings.TrimRight(teamsGraphAPIURL, "/"),
+		token:   token,
+		client:  &http.Client{Timeout: teamsRequestTimeout},
+	}, nil
+}
+
+func getTeamsClient(ctx context.Context) (*teamsClient, teamsConnectionRecord, teamsRuntimeConfig, error) {
+	conn, connected := getIntegrationConnection[teamsConnectionRecord](integrationStoreInstance, providerTeams)
+	if !connected {
+		return nil, conn, teamsRuntimeConfig{}, errors.New("teams is not connected")
+	}
+	if integrationTokenCipher == nil {
+		return nil, conn, teamsRuntimeConfig{}, errors.New("integration encryption is not configured")
+	}
+	cfg, err := currentTeamsRuntimeConfig()
+	if err != nil {
+		return nil, conn, cfg, err
+	}
+	client, err := newTeamsClient(ctx, cfg)
+	return client, conn, cfg, err
+}
+
+// do sends one Graph request to a path on the API.
+func (c *teamsClient) do(ctx context.Context, method, path string, payload any, out any) error {
+	return c.send(ctx, method, c.baseURL+"/"+strings.TrimPrefix(path, "/"), payload, out)
+}
+
+// checkNextLink makes sure an @odata.nextLink points back at the Graph API
+// before the token is sent to it.
+func (c *teamsClient) checkNextLink(link string) error {
+	next, err := url.Parse(link)
+	if err != nil {
+		return fmt.Errorf("invalid @odata.nextLink: %w", err)
+	}
+	base, err := url.Parse(c.baseURL)
+	if err != nil {
+		return err
+	}
+	if next.Scheme != base.Scheme || next.Host != base.Host {
+		return fmt.Errorf("@odata.nextLink points at %q, not the Graph API", next.Host)
+	}
+	return nil
+}
+
+// send sends one Graph request to an absolute URL on the API.
+func (c *teamsClient) send(ctx context.Context, method, target string, payload any, out any) error {
+	var body io.Reader
+	if payload != nil {
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("microsoft graph %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if err != nil {
+		return fmt.Errorf("microsoft graph %s: %w", method, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var parsed struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.Unmarshal(respBody, &parsed)
+		return &teamsStatusError{Status: resp.StatusCode, Code: parsed.Error.Code, Message: parsed.Error.Message}
+	}
+	if out == nil || len(respBody) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("decode microsoft graph response: %w", err)
+	}
+	return nil
+}
+
+func (c *teamsClient) Team(ctx context.Context, teamID string) (graphTeam, error) {
+	var team graphTeam
+	err := c.do(ctx, http.MethodGet, "/teams/"+url.PathEscape(teamID), nil, &team)
+	return team, err
+}
+
+func (c *teamsClient) Channels(ctx context.Context, teamID string) ([]integrationChannel, error) {
+	var out struct {
+		Value []graphChannel `json:"value"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/teams/"+url.PathEscape(teamID)+"/channels", nil, &out); err != nil {
+		return nil, err
+	}
+	channels := make([]integrationChannel, 0, len(out.Value))
+	for _, ch := range out.Value {
+		channels = append(channels, integrationChannel{ID: ch.ID, Name: ch.DisplayName, Type: ch.MembershipType})
+	}
+	slices.SortFunc(channels, func(a, b integrationChannel) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	return channels, nil
+}
+
+// Messages returns the newest channel messages with their replies, up to
+// maxTeamsImportPages pages.
+func (c *teamsClient) Messages(ctx context.Context, teamID, channelID string) ([]graphChatMessage, error) {
+	target := fmt.Sprintf("%s/teams/%s/channels/%s/messages?$top=%d&$expand=replies", c.baseURL, url.PathEscape(teamID), url.PathEscape(channelID), teamsPageSize)
+	var messages []graphChatMessage
+	for page := 0; page < maxTeamsImportPages && target != ""; page++ {
+		var out struct {
+			Value    []graphChatMessage `json:"value"`
+			NextLink string             `json:"@odata.nextLink"`
+		}
+		if err := c.send(ctx, http.MethodGet, target, nil, &out); err != nil {
+			return messages, err
+		}
+		messages = append(messages, out.Value...)
+		target = out.NextLink
+		if target != "" {
+			if err := c.checkNextLink(target); err != nil {
+				return messages, err
+			}
+		}
+	}
+	return messages, nil
+}
+
+// Message fetches the message a notification's resource points at, such as
+// teams('t')/channels('c')/messages('m').
+func (c *teamsClient) Message(ctx context.Context, resource string) (graphChatMessage, error) {
+	var msg graphChatMessage
+	if !teamsMessageResource.MatchString(resource) {
+		return msg, fmt.Errorf("%q is not a channel message resource", resource)
+	}
+	err := c.do(ctx, http.MethodGet, resource, nil, &msg)
+	return msg, err
+}
+
+func (c *teamsClient) CreateSubscription(ctx context.Context, resource, notificationURL, clientState string) (graphSubscription, error) {
+	var sub graphSubscription
+	err := c.do(ctx, http.MethodPost, "/subscriptions", map[string]any{
+		"changeType":                "created",
+		"notificationUrl":           notificationURL,
+		"resource":                  resource,
+		"expirationDateTime":        time.Now().UTC().Add(teamsSubscriptionLifetime).Format(time.RFC3339),
+		"clientState":               clientState,
+		"latestSupportedTlsVersion": "v1_2",
+	}, &sub)
+	return sub, err
+}
+
+func (c *teamsClient) RenewSubscription(ctx context.Context, subscriptionID string) (graphSubscription, error) {
+	var sub graphSubscription
+	err := c.do(ctx, http.MethodPatch, "/subscriptions/"+url.PathEscape(subscriptionID), map[string]any{
+		"expirationDateTime": time.Now().UTC().Add(teamsSubscriptionLifetime).Format(time.RFC3339),
+	}, &sub)
+	return sub, err
+}
+
+func (c *teamsClient) DeleteSubscription(ctx context.Context, subscriptionID string) error {
+	err := c.do(ctx, http.MethodDelete, "/subscriptions/"+url.PathEscape(subscriptionID), nil, nil)
+	var statusErr *teamsStatusError
+	if errors.As(err, &statusErr) && statusErr.Status == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+func teamsChannelMessagesResource(teamID, channelID string) string {
+	return fmt.Sprintf("/teams/%s/channels/%s/messages", teamID, channelID)
+}
+
+func currentTeamsRuntimeConfig() (teamsRuntimeConfig, error) {
+	setup, ok := getIntegrationSetup[teamsSetupPersisted](integrationStoreInstance, providerTeams)
+	if !ok {
+		return teamsRuntimeConfig{}, nil
+	}
+	cfg := teamsRuntimeConfig{
+		TenantID:        strings.TrimSpace(setup.TenantID),
+		ClientID:        strings.TrimSpace(setup.ClientID),
+		TeamID:          strings.TrimSpace(setup.TeamID),
+		RedirectURL:     strings.TrimSpace(setup.RedirectURL),
+		NotificationURL: strings.TrimSpace(setup.NotificationURL),
+		AppUIBaseURL:    strings.TrimSpace(setup.AppUIBaseURL),
+	}
+	if integrationTokenCipher != nil && setup.EncryptedClientSecret != "" {
+		decrypted, err := integrationTokenCipher.Decrypt(setup.EncryptedClientSecret)
+		if err != nil {
+			return teamsRuntimeConfig{}, fmt.Errorf("decrypt teams client secret: %w", err)
+		}
+		cfg.ClientSecret = strings.TrimSpace(decrypted)
+	}
+	return cfg, nil
+}
+
+func teamsMissingFields(cfg teamsRuntimeConfig) []string {
+	missing := make([]string, 0, 4)
+	if cfg.TenantID == "" {
+		missing = append(missing, "tenantId")
+	}
+	if cfg.ClientID == "" {
+		missing = append(missing, "clientId")
+	}
+	if cfg.ClientSecret == "" {
+		missing = append(missing, "clientSecret")
+	}
+	if cfg.TeamID == "" {
+		missing = append(missing, "teamId")
+	}
+	return missing
+}
+
+func inferredTeamsRedirectURL(r *http.Request) string {
+	return inferRequestBaseURL(r) + "/api/integrations/teams/callback"
+}
+
+func inferredTeamsNotificationURL(r *http.Request) string {
+	return inferRequestBaseURL(r) + "/api/integrations/teams/webhook"
+}
+
+func buildTeamsSetupResponse(r *http.Request) (teamsSetupResponse, error) {
+	cfg, err := currentTeamsRuntimeConfig()
+	if err != nil {
+		return teamsSetupResponse{}, err
+	}
+	missing := teamsMissingFields(cfg)
+	resp := teamsSetupResponse{
+		Config: teamsSetupConfigView{
+			TenantID:        cfg.TenantID,
+			ClientID:        cfg.ClientID,
+			TeamID:          cfg.TeamID,
+			RedirectURL:     firstNonEmpty(cfg.RedirectURL, inferredTeamsRedirectURL(r)),
+			NotificationURL: firstNonEmpty(cfg.NotificationURL, inferredTeamsNotificationURL(r)),
+			AppUIBaseURL:    firstNonEmpty(cfg.AppUIBaseURL, inferredIntegrationHubURL(r)),
+			HasClientSecret: cfg.ClientSecret != "",
+		},
+		Status: teamsSetupStatusView{
+			ReadyForConnect: len(missing) == 0,
+			MissingFields:   missing,
+		},
+	}
+	if setup, ok := getIntegrationSetup[teamsSetupPersisted](integrationStoreInstance, providerTeams); ok {
+		resp.Config.UpdatedAt = setup.UpdatedAt.Format(time.RFC3339)
+	}
+	if conn, ok := getIntegrationConnection[teamsConnectionRecord](integrationStoreInstance, providerTeams); ok {
+		resp.Status.Connected = true
+		resp.Status.Team = conn.TeamName
+	}
+	return resp, nil
+}
+
+var teamsSetupWizard = integrationSetupWizard[teamsSetupUpsertRequest, teamsSetupResponse]{
+	label:        "Teams",
+	readyMessage: "Teams setup is ready. An admin can grant consent from Integrations.",
+	view:         buildTeamsSetupResponse,
+	save:         saveTeamsSetup,
+	missing: func() ([]string, error) {
+		cfg, err := currentTeamsRuntimeConfig()
+		return teamsMissingFields(cfg), err
+	},
+}
+
+func saveTeamsSetup(w http.ResponseWriter, r *http.Request, req teamsSetupUpsertRequest) bool {
+	existing, _ := getIntegrationSetup[teamsSetupPersisted](integrationStoreInstance, providerTeams)
+	setup := teamsSetupPersisted{
+		TenantID:              strings.TrimSpace(req.TenantID),
+		ClientID:              strings.TrimSpace(req.ClientID),
+		TeamID:                strings.TrimSpace(req.TeamID),
+		RedirectURL:           firstNonEmpty(strings.TrimSpace(req.RedirectURL), existing.RedirectURL, inferredTeamsRedirectURL(r)),
+		NotificationURL:       firstNonEmpty(strings.TrimSpace(req.NotificationURL), existing.NotificationURL, inferredTeamsNotificationURL(r)),
+		AppUIBaseURL:          firstNonEmpty(strings.TrimSpace(req.AppUIBaseURL), existing.AppUIBaseURL, inferredIntegrationHubURL(r)),
+		EncryptedClientSecret: existing.EncryptedClientSecret,
+		UpdatedAt:             time.Now().UTC(),
+	}
+	for field, value := range map[string]string{
+		"redirectUrl":     setup.RedirectURL,
+		"notificationUrl": setup.NotificationURL,
+		"appUIBaseURL":    setup.AppUIBaseURL,
+	} {
+		if _, err := url.ParseRequestURI(value); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: field + " must be a valid absolute URL"})
+			return false
+		}
+	}
+	if secret := strings.TrimSpace(req.ClientSecret); secret != "" {
+		enc, err := integrationTokenCipher.Encrypt(secret)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to encrypt client secret"})
+			return false
+		}
+		setup.EncryptedClientSecret = enc
+	}
+
+	if err := saveIntegrationSetup(integrationStoreInstance, providerTeams, setup); err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to store teams setup"})
+		return false
+	}
+	return true
+}
+
+// teamsAuthorizeRequest points at the tenant's admin consent page. The app
+// reads channels with application permissions, so a tenant admin has to
+// approve it once.
+func teamsAuthorizeRequest(r *http.Request) (integrationAuthorizeRequest, error) {
+	cfg, err := currentTeamsRuntimeConfig()
+	if err != nil {
+		return integrationAuthorizeRequest{}, err
+	}
+	params := url.Values{}
+	params.Set("client_id", cfg.ClientID)
+	params.Set("redirect_uri", firstNonEmpty(cfg.RedirectURL, inferredTeamsRedirectURL(r)))
+	return integrationAuthorizeRequest{
+		URL:     strings.TrimRight(teamsLoginURL, "/") + "/" + url.PathEscape(cfg.TenantID) + "/adminconsent",
+		Params:  params,
+		Missing: teamsMissingFields(cfg),
+	}, nil
+}
+
+func handleTeamsCallback(w http.ResponseWriter, r *http.Request) {
+	cfg, cfgErr := currentTeamsRuntimeConfig()
+	appBaseURL := firstNonEmpty(cfg.AppUIBaseURL, inferredIntegrationHubURL(r))
+	if !checkIntegrationCallback(w, r, providerTeams, "Teams", appBaseURL, cfgErr) {
+		return
+	}
+	query := r.URL.Query()
+	if !strings.EqualFold(query.Get("admin_consent"), "true") {
+		redirectIntegrationResult(w, r, appBaseURL, providerTeams, "error", "Missing admin consent")
+		return
+	}
+	if tenant := strings.TrimSpace(query.Get("tenant")); tenant != "" && !strings.EqualFold(tenant, cfg.TenantID) {
+		redirectIntegrationResult(w, r, appBaseURL, providerTeams, "error", "Consent was granted in a different tenant")
+		return
+	}
+
+	client, err := newTeamsClient(r.Context(), cfg)
+	if err != nil {
+		log.Printf("teams token request failed: %v", err)
+		redirectIntegrationResult(w, r, appBaseURL, providerTeams, "error", "Failed to sign in to Microsoft Graph")
+		return
+	}
+	team, err := client.Team(r.Context(), cfg.TeamID)
+	if err != nil {
+		log.Printf("teams team lookup failed: %v", err)
+		redirectIntegrationResult(w, r, appBaseURL, providerTeams, "error", "The app cannot read the configured team")
+		return
+	}
+	clientState, err := randomHex(24)
+	if err != nil {
+		redirectIntegrationResult(w, r, appBaseURL, providerTeams, "error", "Failed to create Teams client state")
+		return
+	}
+	encryptedState, err := integrationTokenCipher.Encrypt(clientState)
+	if err != nil {
+		redirectIntegrationResult(w, r, appBaseURL, providerTeams, "error", "Failed to secure Teams client state")
+		return
+	}
+
+	now := time.Now().UTC()
+	connection := teamsConnectionRecord{
+		TenantID:             cfg.TenantID,
+		TeamID:               team.ID,
+		TeamName:             team.DisplayName,
+		EncryptedClientState: encryptedState,
+		Subscriptions:        []teamsSubscriptionRecord{},
+		ConnectedAt:          now,
+		UpdatedAt:            now,
+	}
+	// Reconsenting keeps the channel selection and live subscriptions, which
+	// were created with the existing client state. Channels selected in
+	// another team mean nothing in this one.
+	if existing, ok := getIntegrationConnection[teamsConnectionRecord](integrationStoreInstance, providerTeams); ok {
+		if existing.TeamID == connection.TeamID {
+			connection.EncryptedClientState = existing.EncryptedClientState
+			connection.Subscriptions = existing.Subscriptions
+			connection.ConnectedAt = existing.ConnectedAt
+		} else if err := integrationStoreInstance.SetSelectedSources(providerTeams, nil); err != nil {
+			log.Printf("failed to clear teams channel selection: %v", err)
+		}
+	}
+	if err := saveIntegrationConnection(integrationStoreInstance, providerTeams, connection); err != nil {
+		log.Printf("failed to save teams connection: %v", err)
+		redirectIntegrationResult(w, r, appBaseURL, providerTeams, "error", "Failed to store Teams connection")
+		return
+	}
+	redirectIntegrationResult(w, r, appBaseURL, providerTeams, "connected", "Microsoft Teams connected")
+}
+
+func deleteTeamsSubscriptions(ctx context.Context) {
+	client, conn, _, err := getTeamsClient(ctx)
+	if err != nil {
+		return
+	}
+	for _, sub := range conn.Subscriptions {
+		if err := client.DeleteSubscription(ctx, sub.ID); err != nil {
+			log.Printf("teams delete subscription %s failed: %v", sub.ID, err)
+		}
+	}
+}
+
+// saveTeamsSubscription adds sub, or replaces the subscription with the same
+// ID. It fails after a disconnect so a late renewal cannot resurrect it.
+func saveTeamsSubscription(sub teamsSubscriptionRecord) error {
+	return updateIntegrationConnection(integrationStoreInstance, providerTeams, func(conn *teamsConnectionRecord) {
+		if idx := slices.IndexFunc(conn.Subscriptions, func(existing teamsSubscriptionRecord) bool { return existing.ID == sub.ID }); idx >= 0 {
+			conn.Subscriptions[idx] = sub
+		} else {
+			conn.Subscriptions = append(conn.Subscriptions, sub)
+		}
+	})
+}
+
+func removeTeamsSubscription(subscriptionID string) error {
+	return updateIntegrationConnection(integrationStoreInstance, providerTeams, func(conn *teamsConnectionRecord) {
+		conn.Subscriptions = slices.DeleteFunc(conn.Subscriptions, func(sub teamsSubscriptionRecord) bool {
+			return sub.ID == subscriptionID
+		})
+	})
+}
+
+// teamsSession serves the shared channel routes from the connected team.
+type teamsSession struct {
+	client *teamsClient
+	conn   teamsConnectionRecord
+	cfg    teamsRuntimeConfig
+}
+
+func openTeamsSession(ctx context.Context) (channelSession, error) {
+	client, conn, cfg, err := getTeamsClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return teamsSession{client: client, conn: conn, cfg: cfg}, nil
+}
+
+func (s teamsSession) Channels(ctx context.Context) ([]integrationChannel, error) {
+	channels, err := s.client.Channels(ctx, s.conn.TeamID)
+	for idx := range channels {
+		channels[idx].Subscribed = slices.ContainsFunc(s.conn.Subscriptions, func(sub teamsSubscriptionRecord) bool {
+			return sub.ChannelID == channels[idx].ID
+		})
+	}
+	return channels, err
+}
+
+// History returns channel messages and their replies as signals.
+func (s teamsSession) History(ctx context.Context, channelID string, channelName string) ([]signalRecord, error) {
+	messages, err := s.client.Messages(ctx, s.conn.TeamID, channelID)
+	signals := make([]signalRecord, 0, len(messages))
+	for _, root := range messages {
+		for _, msg := range append([]graphChatMessage{root}, root.Replies...) {
+			if signal, ok := teamsMessageSignal(s.conn.TeamID, channelID, channelName, msg); ok {
+				signals = append(signals, signal)
+			}
+		}
+	}
+	return signals, err
+}
+
+func (s teamsSession) SyncSelection(ctx context.Context, channels []integrationChannel) []string {
+	return syncTeamsSubscriptions(ctx, s.client, s.cfg, channels)
+}
+
+// syncTeamsSubscriptions subscribes to every selected channel and drops
+// subscriptions for channels that are no longer selected. Graph validates the
+// notification URL while the subscription is created, so this fails unless
+// the webhook is reachable from Microsoft.
+func syncTeamsSubscriptions(ctx context.Context, client *teamsClient, cfg teamsRuntimeConfig, channels []integrationChannel) []string {
+	errorsOut := make([]string, 0)
+	conn, connected := getIntegrationConnection[teamsConnectionRecord](integrationStoreInstance, providerTeams)
+	if !connected {
+		return append(errorsOut, "teams is not connected")
+	}
+	clientState, err := integrationTokenCipher.Decrypt(conn.EncryptedClientState)
+	if err != nil {
+		return append(errorsOut, "failed to decrypt Teams client state")
+	}
+	selected := integrationStoreInstance.GetSelectedSources(providerTeams)
+
+	for _, sub := range conn.Subscriptions {
+		if slices.Contains(selected, sub.ChannelID) {
+			continue
+		}
+		if err := client.DeleteSubscription(ctx, sub.ID); err != nil {
+			errorsOut = append(errorsOut, fmt.Sprintf("%s: %v", sub.ChannelID, err))
+			continue
+		}
+		if err := removeTeamsSubscription(sub.ID); err != nil {
+			errorsOut = append(errorsOut, fmt.Sprintf("%s: failed to save subscription", sub.ChannelID))
+		}
+	}
+
+	for _, channelID := range selected {
+		if slices.ContainsFunc(conn.Subscriptions, func(sub teamsSubscriptionRecord) bool { return sub.ChannelID == channelID }) {
+			continue
+		}
+		channelName := channelID
+		if idx := slices.IndexFunc(channels, func(ch integrationChannel) bool { return ch.ID == channelID }); idx >= 0 {
+			channelName = channels[idx].Name
+		}
+		created, err := client.CreateSubscription(ctx, teamsChannelMessagesResource(conn.TeamID, channelID), cfg.NotificationURL, clientState)
+		if err != nil {
+			errorsOut = append(errorsOut, fmt.Sprintf("%s: %v", channelID, err))
+			continue
+		}
+		record := teamsSubscriptionRecord{ID: created.ID, ChannelID: channelID, ChannelName: channelName, ExpiresAt: created.ExpirationDateTime}
+		if err := saveTeamsSubscription(record); err != nil {
+			errorsOut = append(errorsOut, fmt.Sprintf("%s: failed to save subscription", channelID))
+		}
+	}
+	return errorsOut
+}
+
+// renewTeamsSubscriptions extends subscriptions that expire within
+// teamsRenewBefore. A subscription Graph no longer knows about is recreated.
+func renewTeamsSubscriptions(ctx context.Context) {
+	conn, connected := getIntegrationConnection[teamsConnectionRecord](integrationStoreInstance, providerTeams)
+	if !connected || len(conn.Subscriptions) == 0 {
+		return
+	}
+	client, conn, cfg, err := getTeamsClient(ctx)
+	if err != nil {
+		log.Printf("teams subscription renewal skipped: %v", err)
+		return
+	}
+
+	expired := false
+	for _, sub := range conn.Subscriptions {
+		if time.Until(sub.ExpiresAt) > teamsRenewBefore {
+			continue
+		}
+		renewed, err := client.RenewSubscription(ctx, sub.ID)
+		var statusErr *teamsStatusError
+		if errors.As(err, &statusErr) && statusErr.Status == http.StatusNotFound {
+			log.Printf("teams subscription %s for %s is gone; recreating", sub.ID, sub.ChannelID)
+			_ = removeTeamsSubscription(sub.ID)
+			expired = true
+			continue
+		}
+		if err != nil {
+			log.Printf("teams subscription %s renewal failed: %v", sub.ID, err)
+			continue
+		}
+		sub.ExpiresAt = renewed.ExpirationDateTime
+		if err := saveTeamsSubscription(sub); err != nil {
+			log.Printf("teams subscription %s renewal not saved: %v", sub.ID, err)
+		}
+	}
+	if expired {
+		channels, err := client.Channels(ctx, conn.TeamID)
+		if err != nil {
+			log.Printf("teams channel refresh failed: %v", err)
+		}
+		for _, msg := range syncTeamsSubscriptions(ctx, client, cfg, channels) {
+			log.Printf("teams subscription recreate: %s", msg)
+		}
+	}
+}
+
+func startTeamsSubscriptionRenewal() {
+	go func() {
+		ticker := time.NewTicker(teamsRenewInterval)
+		defer ticker.Stop()
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), teamsRenewInterval)
+			renewTeamsSubscriptions(ctx)
+			cancel()
+			<-ticker.C
+		}
+	}()
+}
+
+func teamsMessageText(msg graphChatMessage) string {
+	text := msg.Body.Content
+	if strings.EqualFold(msg.Body.ContentType, "html") {
+		text = html.UnescapeString(teamsHTMLTag.ReplaceAllString(text, " "))
+	}
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// teamsMessageSignal returns false for system events, deleted and bot
+// messages, and messages without text.
+func teamsMessageSignal(teamID, channelID, channelName string, msg graphChatMessage) (signalRecord, bool) {
+	if msg.MessageType != "message" || msg.DeletedDateTime != nil || msg.From == nil || msg.From.User == nil {
+		return signalRecord{}, false
+	}
+	text := teamsMessageText(msg)
+	if text == "" {
+		return signalRecord{}, false
+	}
+	eventType, title := "message", fmt.Sprintf("#%s message", channelName)
+	if msg.ReplyToID != "" {
+		eventType, title = "reply", fmt.Sprintf("#%s reply", channelName)
+	}
+	occurredAt := msg.CreatedDateTime.UTC()
+	if occurredAt.IsZero() {
+		occurredAt = time.Now().UTC()
+	}
+	meta := map[string]string{
+		"eventType":   eventType,
+		"teamId":      teamID,
+		"channel":     channelID,
+		"channelName": channelName,
+		"user":        msg.From.User.DisplayName,
+		"permalink":   msg.WebURL,
+	}
+	if msg.ReplyToID != "" {
+		meta["replyTo"] = msg.ReplyToID
+	}
+	return signalRecord{
+		ID:         fmt.Sprintf("teams:%s:%s", channelID, msg.ID),
+		Source:     "Teams",
+		Title:      title,
+		Summary:    truncateText(text, 500),
+		OccurredAt: occurredAt,
+		Meta:       meta,
+	}, true
+}
+
+// handleTeamsWebhook receives Graph change notifications. Graph first posts a
+// validationToken that must be echoed as text/plain; after that each
+// notification carries the subscription's clientState and a resource path,
+// but not the message itself.
+func handleTeamsWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if token := r.URL.Query().Get("validationToken"); token != "" {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, token)
+		return
+	}
+
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "unable to read body"})
+		return
+	}
+	var envelope struct {
+		Value []json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid notification payload"})
+		return
+	}
+	conn, connected := getIntegrationConnection[teamsConnectionRecord](integrationStoreInstance, providerTeams)
+	if !connected || integrationTokenCipher == nil {
+		writeJSON(w, http.StatusAccepted, okResponse{Status: "ignored"})
+		return
+	}
+	clientState, err := integrationTokenCipher.Decrypt(conn.EncryptedClientState)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to resolve Teams client state"})
+		return
+	}
+
+	verified := 0
+	accepted := make([]graphNotification, 0, len(envelope.Value))
+	for _, raw := range envelope.Value {
+		var notification graphNotification
+		if err := json.Unmarshal(raw, &notification); err != nil {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(notification.ClientState), []byte(clientState)) != 1 {
+			continue
+		}
+		verified++
+		known := slices.ContainsFunc(conn.Subscriptions, func(sub teamsSubscriptionRecord) bool {
+			return sub.ID == notification.SubscriptionID
+		})
+		if !known || notification.ChangeType != "created" || !teamsMessageResource.MatchString(notification.Resource) {
+			continue
+		}
+		// The resource path names the message, so it doubles as the event ID.
+		isNew, err := integrationStoreInstance.RecordEvent(providerTeams, notification.Resource, notification.ChangeType, raw)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to persist notification"})
+			return
+		}
+		if isNew {
+			accepted = append(accepted, notification)
+		}
+	}
+	if verified == 0 && len(envelope.Value) > 0 {
+		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "invalid Teams client state"})
+		return
+	}
+
+	if len(accepted) > 0 {
+		go processTeamsNotifications(accepted)
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{"status": "ok", "accepted": len(accepted)})
+}
+
+func processTeamsNotifications(notifications []graphNotification) {
+	ctx, cancel := context.WithTimeout(context.Background(), teamsNotificationTimeout)
+	defer cancel()
+
+	client, conn, _, err := getTeamsClient(ctx)
+	for _, notification := range notifications {
+		if err != nil {
+			integrationStoreInstance.UpdateEventStatus(providerTeams, notification.Resource, "failed", err.Error())
+			continue
+		}
+		idx := slices.IndexFunc(conn.Subscriptions, func(sub teamsSubscriptionRecord) bool {
+			return sub.ID == notification.SubscriptionID
+		})
+		if idx < 0 {
+			integrationStoreInstance.UpdateEventStatus(providerTeams, notification.Resource, "ignored", "subscription removed")
+			continue
+		}
+		sub := conn.Subscriptions[idx]
+		msg, fetchErr := client.Message(ctx, notification.Resource)
+		if fetchErr != nil {
+			integrationStoreInstance.UpdateEventStatus(providerTeams, notification.Resource, "failed", fetchErr.Error())
+			continue
+		}
+		signal, ok := teamsMessageSignal(conn.TeamID, sub.ChannelID, sub.ChannelName, msg)
+		if !ok {
+			integrationStoreInstance.UpdateEventStatus(providerTeams, notification.Resource, "ignored", "not a user message")
+			continue
+		}
+		if err := integrationStoreInstance.AddSignal(signal); err != nil {
+			integrationStoreInstance.UpdateEventStatus(providerTeams, notification.Resource, "failed", "unable to persist signal")
+			continue
+		}
+		integrationStoreInstance.UpdateEventStatus(providerTeams, notification.Resource, "processed", "")
+	}
+}
+
+// teamsIntegration mounts the Teams handlers on the integration registry.
+type teamsIntegration struct{}
+
+func (teamsIntegration) Name() string { return providerTeams }
+
+func (teamsIntegration) Summary() integrationSummary {
+	summary := integrationSummary{
+		Provider: providerTeams,
+		Name:     "Microsoft Teams",
+		Status:   "Disconnected",
+		Detail:   defaultTeamsConnectionDetail,
+	}
+	if conn, ok := getIntegrationConnection[teamsConnectionRecord](integrationStoreInstance, providerTeams); ok {
+		summary.Status = "Connected"
+		summary.ConnectedAt = conn.ConnectedAt.Format(time.RFC3339)
+		summary.Detail = fmt.Sprintf("%s team connected (%d selected channels, %d subscriptions, %d notifications received)",
+			conn.TeamName, len(integrationStoreInstance.GetSelectedSources(providerTeams)), len(conn.Subscriptions), integrationStoreInstance.EventCount(providerTeams))
+	}
+	return summary
+}
+
+func (teamsIntegration) ConnectURL(w http.ResponseWriter, r *http.Request) {
+	serveIntegrationConnectURL(w, r, providerTeams, "Teams", teamsAuthorizeRequest)
+}
+
+func (teamsIntegration) Callback(w http.ResponseWriter, r *http.Request) {
+	handleTeamsCallback(w, r)
+}
+
+func (teamsIntegration) Disconnect(w http.ResponseWriter, r *http.Request) {
+	serveIntegrationDisconnect(w, r, providerTeams, "Teams", deleteTeamsSubscriptions)
+}
+
+func (teamsIntegration) Sources(w http.ResponseWriter, r *http.Request) {
+	serveIntegrationChannels(w, r, providerTeams, openTeamsSession)
+}
+
+func (teamsIntegration) Import(w http.ResponseWriter, r *http.Request) {
+	serveIntegrationChannelsImport(w, r, providerTeams, openTeamsSession)
+}
+
+func (teamsIntegration) Webhook(w http.ResponseWriter, r *http.Request) {
+	handleTeamsWebhook(w, r)
+}
+
+func (t teamsIntegration) ExtraRoutes() map[string]http.HandlerFunc {
+	return map[string]http.HandlerFunc{
+		"setup":           teamsSetupWizard.Setup,
+		"setup/validate":  teamsSetupWizard.Validate,
+		"channels":        t.Sources,
+		"channels/import": t.Import,
+	}
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testTeamsMessageResource = "teams('team1')/channels('c1')/messages('m1')"
+
+// fakeGraph serves the Microsoft login and Graph endpoints the Teams client
+// uses, and records subscription changes.
+type fakeGraph struct {
+	mu      sync.Mutex
+	created []map[string]any
+	deleted []string
+}
+
+func useFakeGraph(t *testing.T) *fakeGraph {
+	t.Helper()
+	fake := &fakeGraph{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tenant-1/oauth2/v2.0/token" {
+			_, _ = w.Write([]byte(`{"access_token":"graph-token","expires_in":3600}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer graph-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.URL.Path == "/teams/team1/channels":
+			_, _ = w.Write([]byte(`{"value":[{"id":"c1","displayName":"general","membershipType":"standard"},
+				{"id":"c2","displayName":"Feedback","membershipType":"standard"}]}`))
+		case strings.HasSuffix(r.URL.Path, "/messages('m1')"):
+			_, _ = w.Write([]byte(`{"id":"m1","messageType":"message","createdDateTime":"2026-03-01T00:00:00Z",
+				"webUrl":"https://teams.example/m1","from":{"user":{"id":"u1","displayName":"Alex"}},
+				"body":{"contentType":"html","content":"<p>Export &amp; import is slow</p>"}}`))
+		case r.URL.Path == "/subscriptions" && r.Method == http.MethodPost:
+			var body map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			fake.mu.Lock()
+			fake.created = append(fake.created, body)
+			id := fmt.Sprintf("sub-new-%d", len(fake.created))
+			fake.mu.Unlock()
+			w.WriteHeader(http.StatusCreated)
+			_, _ = fmt.Fprintf(w, `{"id":%q,"resource":%q,"expirationDateTime":%q}`, id, body["resource"], time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+		case strings.HasPrefix(r.URL.Path, "/subscriptions/") && r.Method == http.MethodDelete:
+			fake.mu.Lock()
+			fake.deleted = append(fake.deleted, strings.TrimPrefix(r.URL.Path, "/subscriptions/"))
+			fake.mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	prevGraph, prevLogin := teamsGraphAPIURL, teamsLoginURL
+	teamsGraphAPIURL, teamsLoginURL = server.URL, server.URL
+	t.Cleanup(func() {
+		teamsGraphAPIURL, teamsLoginURL = prevGraph, prevLogin
+		teamsTokens.mu.Lock()
+		teamsTokens.key = ""
+		teamsTokens.mu.Unlock()
+	})
+	return fake
+}
+
+// useEvilServer starts a server that is not Graph and returns it with a
+// count of the requests it received.
+func useEvilServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	hits := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		_, _ = w.Write([]byte(`{"value":[]}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, hits
+}
+
+// connectTestTeams stores a Teams setup and a connection to team1 with one
+// subscription, sub-1, on channel c1.
+func connectTestTeams(t *testing.T) {
+	t.Helper()
+	useTestIntegrations(t)
+	setup := teamsSetupPersisted{
+		TenantID:              "tenant-1",
+		ClientID:              "client-1",
+		TeamID:                "team1",
+		NotificationURL:       "https://sentient.example/api/integrations/teams/webhook",
+		EncryptedClientSecret: encryptForTest(t, "secret"),
+	}
+	if err := saveIntegrationSetup(integrationStoreInstance, providerTeams, setup); err != nil {
+		t.Fatal(err)
+	}
+	connection := teamsConnectionRecord{
+		TenantID:             "tenant-1",
+		TeamID:               "team1",
+		TeamName:             "Product",
+		EncryptedClientState: encryptForTest(t, "state-1"),
+		Subscriptions:        []teamsSubscriptionRecord{{ID: "sub-1", ChannelID: "c1", ChannelName: "general", ExpiresAt: time.Now().Add(time.Hour)}},
+	}
+	if err := saveIntegrationConnection(integrationStoreInstance, providerTeams, connection); err != nil {
+		t.Fatal(err)
+	}
+	if err := integrationStoreInstance.SetSelectedSources(providerTeams, []string{"c1"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func postTeamsNotifications(t *testing.T, notifications ...graphNotification) *httptest.ResponseRecorder {
+	t.Helper()
+	body, err := json.Marshal(map[string]any{"value": notifications})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	teamsIntegration{}.Webhook(rec, httptest.NewRequest(http.MethodPost, "/api/integrations/teams/webhook", strings.NewReader(string(body))))
+	return rec
+}
+
+func testTeamsNotification(subscriptionID string, clientState string) graphNotification {
+	return graphNotification{
+		SubscriptionID: subscriptionID,
+		ClientState:    clientState,
+		ChangeType:     "created",
+		Resource:       testTeamsMessageResource,
+		TenantID:       "tenant-1",
+	}
+}
+
+// waitForTeamsEvent waits for the notification goroutine to finish with the
+// event, so it does not outlive the test's store.
+func waitForTeamsEvent(t *testing.T, eventID string) string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		integrationStoreInstance.mu.Lock()
+		status := ""
+		for _, event := range integrationStoreInstance.data.RawEvents {
+			if event.Provider == providerTeams && event.EventID == eventID {
+				status = event.Status
+			}
+		}
+		integrationStoreInstance.mu.Unlock()
+		if status != "" && status != "pending" {
+			return status
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("teams event %s was not processed", eventID)
+	return ""
+}
+
+func TestTeamsWebhookEchoesValidationToken(t *testing.T) {
+	useTestIntegrations(t)
+	rec := httptest.NewRecorder()
+	teamsIntegration{}.Webhook(rec, httptest.NewRequest(http.MethodPost, "/api/integrations/teams/webhook?validationToken=Validation%3A+abc+123", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/plain" {
+		t.Fatalf("content type = %q, want text/plain", got)
+	}
+	if got := rec.Body.String(); got != "Validation: abc 123" {
+		t.Fatalf("body = %q, want the decoded token", got)
+	}
+}
+
+func TestTeamsWebhookRejectsClientStateMismatch(t *testing.T) {
+	connectTestTeams(t)
+	rec := postTeamsNotifications(t, testTeamsNotification("sub-1", "not-the-state"))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401: %s", rec.Code, rec.Body)
+	}
+	if got := integrationStoreInstance.EventCount(providerTeams); got != 0 {
+		t.Fatalf("rejected notification was recorded as %d events", got)
+	}
+}
+
+func TestTeamsWebhookIgnoresUnknownSubscriptions(t *testing.T) {
+	connectTestTeams(t)
+	rec := postTeamsNotifications(t, testTeamsNotification("sub-removed", "state-1"))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202: %s", rec.Code, rec.Body)
+	}
+	if got := decodeTestJSON(t, rec)["accepted"]; got != float64(0) {
+		t.Fatalf("accepted = %v, want 0", got)
+	}
+	if got := integrationStoreInstance.EventCount(providerTeams); got != 0 {
+		t.Fatalf("unknown subscription was recorded as %d events", got)
+	}
+}
+
+func TestTeamsWebhookDedupesNotifications(t *testing.T) {
+	useFakeGraph(t)
+	connectTestTeams(t)
+
+	first := postTeamsNotifications(t, testTeamsNotification("sub-1", "state-1"))
+	if first.Code != http.StatusAccepted || decodeTestJSON(t, first)["accepted"] != float64(1) {
+		t.Fatalf("first delivery: %d %s", first.Code, first.Body)
+	}
+	if status := waitForTeamsEvent(t, testTeamsMessageResource); status != "processed" {
+		t.Fatalf("event status = %q, want processed", status)
+	}
+
+	second := postTeamsNotifications(t, testTeamsNotification("sub-1", "state-1"))
+	if second.Code != http.StatusAccepted || decodeTestJSON(t, second)["accepted"] != float64(0) {
+		t.Fatalf("redelivery: %d %s", second.Code, second.Body)
+	}
+	if got := integrationStoreInstance.EventCount(providerTeams); got != 1 {
+		t.Fatalf("events recorded = %d, want 1", got)
+	}
+	signals := integrationStoreInstance.ListSignals("Teams", 10)
+	if len(signals) != 1 || signals[0].ID != "teams:c1:m1" || signals[0].Summary != "Export & import is slow" {
+		t.Fatalf("unexpected signals: %+v", signals)
+	}
+}
+
+func TestTeamsChannelSelectionSyncsSubscriptions(t *testing.T) {
+	fake := useFakeGraph(t)
+	connectTestTeams(t)
+
+	rec := httptest.NewRecorder()
+	teamsIntegration{}.Sources(rec, httptest.NewRequest(http.MethodPut, "/api/integrations/teams/sources", strings.NewReader(`{"channelIds":["c2","missing"]}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("select: status = %d: %s", rec.Code, rec.Body)
+	}
+	if errs, _ := decodeTestJSON(t, rec)["errors"].([]any); len(errs) != 0 {
+		t.Fatalf("unexpected sync errors: %v", errs)
+	}
+	fake.mu.Lock()
+	deleted, created := slices.Clone(fake.deleted), slices.Clone(fake.created)
+	fake.mu.Unlock()
+	if !slices.Equal(deleted, []string{"sub-1"}) {
+		t.Fatalf("deleted subscriptions = %v, want [sub-1]", deleted)
+	}
+	if len(created) != 1 || created[0]["resource"] != "/teams/team1/channels/c2/messages" || created[0]["clientState"] != "state-1" {
+		t.Fatalf("unexpected subscription requests: %v", created)
+	}
+
+	conn, _ := getIntegrationConnection[teamsConnectionRecord](integrationStoreInstance, providerTeams)
+	if len(conn.Subscriptions) != 1 || conn.Subscriptions[0].ChannelID != "c2" || conn.Subscriptions[0].ChannelName != "Feedback" {
+		t.Fatalf("stored subscriptions = %+v", conn.Subscriptions)
+	}
+
+	rec = httptest.NewRecorder()
+	teamsIntegration{}.Sources(rec, httptest.NewRequest(http.MethodGet, "/api/integrations/teams/sources", nil))
+	var listed integrationChannelsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &listed); err != nil {
+		t.Fatal(err)
+	}
+	for _, ch := range listed.Channels {
+		want := ch.ID == "c2"
+		if ch.Selected != want || ch.Subscribed != want {
+			t.Fatalf("channel %s: selected=%v subscribed=%v, want %v", ch.ID, ch.Selected, ch.Subscribed, want)
+		}
+	}
+}
+
+func TestTeamsWebhookIgnoresForeignResources(t *testing.T) {
+	useFakeGraph(t)
+	connectTestTeams(t)
+	evil, hits := useEvilServer(t)
+
+	resources := []string{
+		evil.URL + "/x",
+		"//" + strings.TrimPrefix(evil.URL, "http://") + "/x",
+		testTeamsMessageResource + "/../../x",
+		"teams('team1')/channels('c1')/messages('m1')?$expand=x",
+		"subscriptions",
+	}
+	for _, resource := range resources {
+		notification := testTeamsNotification("sub-1", "state-1")
+		notification.Resource = resource
+		rec := postTeamsNotifications(t, notification)
+		if rec.Code != http.StatusAccepted || decodeTestJSON(t, rec)["accepted"] != float64(0) {
+			t.Errorf("%s: %d %s, want it ignored", resource, rec.Code, rec.Body)
+		}
+	}
+	if got := integrationStoreInstance.EventCount(providerTeams); got != 0 {
+		t.Fatalf("foreign resources were recorded as %d events", got)
+	}
+
+	client := &teamsClient{baseURL: teamsGraphAPIURL, token: "graph-token", client: &http.Client{}}
+	if _, err := client.Message(context.Background(), evil.URL+"/x"); err == nil {
+		t.Fatal("expected an absolute resource to be refused")
+	}
+	if _, err := client.Message(context.Background(), "/"+testTeamsMessageResource); err != nil {
+		t.Fatalf("message resource: %v", err)
+	}
+	if got := hits.Load(); got != 0 {
+		t.Fatalf("the Graph token was sent to another host %d times", got)
+	}
+}
+
+func TestTeamsMessagesFollowsOnlyGraphNextLinks(t *testing.T) {
+	evil, hits := useEvilServer(t)
+	var graph *httptest.Server
+	graph = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next := graph.URL + "/teams/team1/channels/c1/messages?page=2"
+		if r.URL.Query().Get("page") == "2" {
+			next = evil.URL + "/teams/team1/channels/c1/messages?page=3"
+		}
+		_, _ = fmt.Fprintf(w, `{"value":[{"id":"m%s"}],"@odata.nextLink":%q}`, r.URL.Query().Get("page"), next)
+	}))
+	t.Cleanup(graph.Close)
+
+	client := &teamsClient{baseURL: graph.URL, token: "graph-token", client: &http.Client{}}
+	messages, err := client.Messages(context.Background(), "team1", "c1")
+	if err == nil || !strings.Contains(err.Error(), "nextLink") {
+		t.Fatalf("err = %v, want the foreign nextLink refused", err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("messages = %+v, want both Graph pages", messages)
+	}
+	if got := hits.Load(); got != 0 {
+		t.Fatalf("the Graph token was sent to another host %d times", got)
+	}
+}
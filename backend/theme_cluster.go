@@ -0,0 +1,367 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	themeSimilarityThreshold = 0.2
+	themeBigramLabelBoost    = 1.5
+	maxThemeDocuments        = 400
+	maxThemes                = 8
+	maxThemeKeywords         = 3
+	maxThemeQuotes           = 3
+	maxThemeQuoteLength      = 280
+)
+
+var themeStopWords = toWordSet(`a about above after again against all also am an and any are as at be because been
+before being below between both but by can could did do does doing down during each even ever every few for from
+further get gets got had has have having he her here hers him his how however i if in into is it its itself just
+let like lot lots make makes many may me might more most much must my need needs no nor not now of off on once
+only or other our ours out over own please really same she should since so some still such than that the their
+theirs them then there these they this those through to too under until up us use used using very via want wants
+was way we well were what when where which while who whom why will with within without would yes yet you your
+yours thing things someone something anyone people user users team teams feature features`)
+
+type themeDocument struct {
+	ID     string
+	Source string
+	Text   string
+	URL    string
+	Weight int
+}
+
+type themeQuote struct {
+	Text   string `json:"text"`
+	Source string `json:"source"`
+	URL    string `json:"url,omitempty"`
+	Ref    string `json:"ref"`
+}
+
+type themeCluster struct {
+	Label      string         `json:"label"`
+	Count      int            `json:"count"`
+	Confidence float64        `json:"confidence"`
+	Keywords   []string       `json:"keywords"`
+	Sources    map[string]int `json:"sources"`
+	Quotes     []themeQuote   `json:"quotes"`
+}
+
+type themeVector map[string]float64
+
+type themeGroup struct {
+	members []int
+	sum     themeVector
+}
+
+// clusterThemes groups documents by TF-IDF cosine similarity. Documents are
+// visited in input order and ties are broken lexically, so identical input
+// always yields identical themes.
+func clusterThemes(docs []themeDocument, extraStopWords []string) []themeCluster {
+	if len(docs) > maxThemeDocuments {
+		docs = docs[:maxThemeDocuments]
+	}
+	stop := map[string]struct{}{}
+	for _, word := range extraStopWords {
+		for _, token := range strings.FieldsFunc(strings.ToLower(word), isThemeSeparator) {
+			stop[token] = struct{}{}
+			stop[stemThemeToken(token)] = struct{}{}
+		}
+	}
+
+	terms := make([][]string, len(docs))
+	docFreq := map[string]int{}
+	for idx, doc := range docs {
+		terms[idx] = themeTerms(doc.Text, stop)
+		seen := map[string]struct{}{}
+		for _, term := range terms[idx] {
+			if _, dup := seen[term]; dup {
+				continue
+			}
+			seen[term] = struct{}{}
+			docFreq[term]++
+		}
+	}
+
+	vectors := make([]themeVector, len(docs))
+	for idx, docTerms := range terms {
+		if len(docTerms) == 0 {
+			continue
+		}
+		vec := themeVector{}
+		for _, term := range docTerms {
+			vec[term]++
+		}
+		for term, count := range vec {
+			idf := math.Log(float64(1+len(docs))/float64(1+docFreq[term])) + 1
+			vec[term] = count / float64(len(docTerms)) * idf
+		}
+		vectors[idx] = vec.normalized()
+	}
+
+	groups := make([]*themeGroup, 0)
+	for idx, vec := range vectors {
+		if vec == nil {
+			continue
+		}
+		best, bestSim := -1, 0.0
+		for g, group := range groups {
+			if sim := vec.dot(group.sum.normalized()); sim > bestSim {
+				best, bestSim = g, sim
+			}
+		}
+		if best >= 0 && bestSim >= themeSimilarityThreshold {
+			groups[best].add(idx, vec)
+			continue
+		}
+		group := &themeGroup{sum: themeVector{}}
+		group.add(idx, vec)
+		groups = append(groups, group)
+	}
+
+	merged := make([]*themeGroup, 0, len(groups))
+	for _, group := range groups {
+		absorbed := false
+		for _, target := range merged {
+			if group.sum.normalized().dot(target.sum.normalized()) >= themeSimilarityThreshold {
+				for _, member := range group.members {
+					target.add(member, vectors[member])
+				}
+				absorbed = true
+				break
+			}
+		}
+		if !absorbed {
+			merged = append(merged, group)
+		}
+	}
+
+	clustered := 0
+	multi := false
+	for _, group := range merged {
+		clustered += group.weight(docs)
+		if group.weight(docs) > 1 {
+			multi = true
+		}
+	}
+
+	themes := make([]themeCluster, 0, len(merged))
+	for _, group := range merged {
+		if multi && group.weight(docs) < 2 {
+			continue
+		}
+		themes = append(themes, group.theme(docs, vectors, clustered))
+	}
+	sort.SliceStable(themes, func(i, j int) bool {
+		if themes[i].Count != themes[j].Count {
+			return themes[i].Count > themes[j].Count
+		}
+		return themes[i].Label < themes[j].Label
+	})
+	if len(themes) > maxThemes {
+		themes = themes[:maxThemes]
+	}
+	return themes
+}
+
+func (g *themeGroup) add(idx int, vec themeVector) {
+	g.members = append(g.members, idx)
+	for term, weight := range vec {
+		g.sum[term] += weight
+	}
+}
+
+func (g *themeGroup) weight(docs []themeDocument) int {
+	total := 0
+	for _, member := range g.members {
+		total += max(docs[member].Weight, 1)
+	}
+	return total
+}
+
+func (g *themeGroup) theme(docs []themeDocument, vectors []themeVector, total int) themeCluster {
+	centroid := g.sum.normalized()
+
+	type rankedTerm struct {
+		term  string
+		score float64
+	}
+	coverage := map[string]int{}
+	for _, member := range g.members {
+		for term := range vectors[member] {
+			coverage[term]++
+		}
+	}
+	ranked := make([]rankedTerm, 0, len(centroid))
+	for term, weight := range centroid {
+		weight *= float64(coverage[term]) / float64(len(g.members))
+		if strings.Contains(term, " ") {
+			weight *= themeBigramLabelBoost
+		}
+		ranked = append(ranked, rankedTerm{term: term, score: weight})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
+		}
+		return ranked[i].term < ranked[j].term
+	})
+
+	theme := themeCluster{
+		Count:      g.weight(docs),
+		Confidence: roundTo(float64(g.weight(docs))/float64(max(total, 1)), 2),
+		Keywords:   make([]string, 0, maxThemeKeywords),
+		Sources:    map[string]int{},
+		Quotes:     make([]themeQuote, 0, maxThemeQuotes),
+	}
+	for _, candidate := range ranked {
+		if len(theme.Keywords) == maxThemeKeywords {
+			break
+		}
+		overlaps := false
+		for _, keyword := range theme.Keywords {
+			if strings.Contains(keyword, candidate.term) || strings.Contains(candidate.term, keyword) {
+				overlaps = true
+				break
+			}
+		}
+		if overlaps {
+			continue
+		}
+		if theme.Label == "" {
+			theme.Label = candidate.term
+		}
+		theme.Keywords = append(theme.Keywords, candidate.term)
+	}
+
+	members := append([]int(nil), g.members...)
+	for _, member := range members {
+		theme.Sources[docs[member].Source] += max(docs[member].Weight, 1)
+	}
+	sort.SliceStable(members, func(i, j int) bool {
+		return vectors[members[i]].dot(centroid) > vectors[members[j]].dot(centroid)
+	})
+	quoted := map[string]struct{}{}
+	for _, member := range members {
+		doc := docs[member]
+		key := strings.ToLower(strings.Join(strings.Fields(doc.Text), " "))
+		if _, dup := quoted[key]; dup {
+			continue
+		}
+		quoted[key] = struct{}{}
+		theme.Quotes = append(theme.Quotes, themeQuote{
+			Text:   truncateText(strings.TrimSpace(doc.Text), maxThemeQuoteLength),
+			Source: doc.Source,
+			URL:    doc.URL,
+			Ref:    doc.ID,
+		})
+		if len(theme.Quotes) == maxThemeQuotes {
+			break
+		}
+	}
+	return theme
+}
+
+func themeTerms(text string, stop map[string]struct{}) []string {
+	tokens := make([]string, 0, 16)
+	for _, raw := range strings.FieldsFunc(strings.ToLower(text), isThemeSeparator) {
+		token := strings.Trim(raw, "-'")
+		if len([]rune(token)) < 3 || isThemeNumber(token) {
+			continue
+		}
+		stemmed := stemThemeToken(token)
+		if _, skip := themeStopWords[token]; skip {
+			continue
+		}
+		if _, skip := stop[token]; skip {
+			continue
+		}
+		if _, skip := stop[stemmed]; skip {
+			continue
+		}
+		tokens = append(tokens, stemmed)
+	}
+
+	terms := make([]string, 0, len(tokens)*2)
+	terms = append(terms, tokens...)
+	for idx := 1; idx < len(tokens); idx++ {
+		if tokens[idx-1] != tokens[idx] {
+			terms = append(terms, tokens[idx-1]+" "+tokens[idx])
+		}
+	}
+	return terms
+}
+
+func stemThemeToken(token string) string {
+	switch {
+	case len(token) > 4 && strings.HasSuffix(token, "ies"):
+		return strings.TrimSuffix(token, "ies") + "y"
+	case len(token) > 4 && strings.HasSuffix(token, "s") &&
+		!strings.HasSuffix(token, "ss") && !strings.HasSuffix(token, "us") && !strings.HasSuffix(token, "is"):
+		return strings.TrimSuffix(token, "s")
+	default:
+		return token
+	}
+}
+
+func isThemeSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '\''
+}
+
+func isThemeNumber(token string) bool {
+	for _, r := range token {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Sums walk terms in sorted order: float addition is not associative and map
+// order would otherwise leak into similarity scores.
+func (v themeVector) sortedTerms() []string {
+	terms := make([]string, 0, len(v))
+	for term := range v {
+		terms = append(terms, term)
+	}
+	sort.Strings(terms)
+	return terms
+}
+
+func (v themeVector) normalized() themeVector {
+	norm := 0.0
+	for _, term := range v.sortedTerms() {
+		norm += v[term] * v[term]
+	}
+	if norm == 0 {
+		return themeVector{}
+	}
+	norm = math.Sqrt(norm)
+	out := make(themeVector, len(v))
+	for term, weight := range v {
+		out[term] = weight / norm
+	}
+	return out
+}
+
+func (v themeVector) dot(other themeVector) float64 {
+	if len(other) < len(v) {
+		v, other = other, v
+	}
+	sum := 0.0
+	for _, term := range v.sortedTerms() {
+		sum += v[term] * other[term]
+	}
+	return sum
+}
+
+func toWordSet(words string) map[string]struct{} {
+	set := map[string]struct{}{}
+	for _, word := range strings.Fields(words) {
+		set[word] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func themeDocs(texts ...string) []themeDocument {
+	docs := make([]themeDocument, 0, len(texts))
+	for idx, text := range texts {
+		docs = append(docs, themeDocument{ID: fmt.Sprintf("d%d", idx+1), Source: "Slack", Text: text})
+	}
+	return docs
+}
+
+func TestThemeTerms(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Dark mode please", []string{"dark", "mode", "dark mode"}},
+		{"Batteries and classes", []string{"battery", "classe", "battery classe"}},
+		{"The status of the API is 404", []string{"status", "api", "status api"}},
+		{"export export CSV", []string{"export", "export", "csv", "export csv"}},
+		{"", []string{}},
+	}
+	for _, tt := range tests {
+		got := themeTerms(tt.text, map[string]struct{}{})
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("themeTerms(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+
+	stop := map[string]struct{}{"dark": {}}
+	if got := themeTerms("dark mode", stop); !reflect.DeepEqual(got, []string{"mode"}) {
+		t.Errorf("extra stop words not applied: %q", got)
+	}
+}
+
+func TestThemeVectorScoring(t *testing.T) {
+	a := themeVector{"dark": 3, "mode": 4}.normalized()
+	if math.Abs(a["dark"]-0.6) > 1e-9 || math.Abs(a["mode"]-0.8) > 1e-9 {
+		t.Fatalf("normalized = %v", a)
+	}
+	if got := a.dot(a); math.Abs(got-1) > 1e-9 {
+		t.Errorf("self similarity = %v, want 1", got)
+	}
+	if got := a.dot(themeVector{"export": 1}); got != 0 {
+		t.Errorf("disjoint similarity = %v, want 0", got)
+	}
+	if got := (themeVector{}).normalized(); len(got) != 0 {
+		t.Errorf("empty vector normalized to %v", got)
+	}
+}
+
+func TestClusterThemesGroupsAndOrders(t *testing.T) {
+	docs := themeDocs(
+		"Dark mode would reduce eye strain at night",
+		"CSV export is missing from reports",
+		"Please add dark mode, eye strain is real",
+		"Export to CSV for the finance reports",
+		"Dark mode for night shifts",
+		"Calendar sync with Google",
+	)
+	themes := clusterThemes(docs, nil)
+	if len(themes) != 2 {
+		t.Fatalf("got %d themes, want 2: %+v", len(themes), themes)
+	}
+
+	dark, export := themes[0], themes[1]
+	if dark.Label != "dark mode" || dark.Count != 3 {
+		t.Errorf("first theme = %q (%d), want dark mode (3)", dark.Label, dark.Count)
+	}
+	if export.Count != 2 {
+		t.Errorf("second theme count = %d, want 2", export.Count)
+	}
+	// The singleton calendar document is dropped once real clusters exist, and
+	// confidence is the share of clustered weight, singletons included.
+	if dark.Confidence != 0.5 {
+		t.Errorf("confidence = %v, want 0.5", dark.Confidence)
+	}
+	if dark.Sources["Slack"] != 3 || len(dark.Quotes) != 3 {
+		t.Errorf("sources %v, quotes %d", dark.Sources, len(dark.Quotes))
+	}
+	for _, keyword := range dark.Keywords[1:] {
+		if keyword == "dark" || keyword == "mode" {
+			t.Errorf("keyword %q overlaps the label", keyword)
+		}
+	}
+}
+
+func TestClusterThemesTieBreaks(t *testing.T) {
+	// Two equally sized themes are ordered by label.
+	docs := themeDocs(
+		"zebra stripes pattern",
+		"apple orchard harvest",
+		"zebra stripes everywhere",
+		"apple orchard tour",
+	)
+	themes := clusterThemes(docs, nil)
+	if len(themes) != 2 {
+		t.Fatalf("got %d themes: %+v", len(themes), themes)
+	}
+	if themes[0].Count != themes[1].Count {
+		t.Fatalf("counts differ: %d vs %d", themes[0].Count, themes[1].Count)
+	}
+	if themes[0].Label > themes[1].Label {
+		t.Errorf("tie not broken by label: %q before %q", themes[0].Label, themes[1].Label)
+	}
+
+	// Weight counts a document as several mentions.
+	weighted := themeDocs("zebra stripes pattern", "apple orchard harvest", "zebra stripes everywhere", "apple orchard tour")
+	weighted[1].Weight = 5
+	themes = clusterThemes(weighted, nil)
+	if themes[0].Count != 6 || themes[0].Sources["Slack"] != 6 {
+		t.Errorf("weighted theme = %+v, want count 6 first", themes[0])
+	}
+}
+
+func TestClusterThemesIsDeterministic(t *testing.T) {
+	docs := themeDocs(
+		"Dark mode would reduce eye strain at night",
+		"CSV export is missing from reports",
+		"Please add dark mode, eye strain is real",
+		"Export to CSV for the finance reports",
+		"Dark mode for night shifts",
+		"Dark theme contrast is too low",
+		"Reports should export to Excel too",
+	)
+	first := clusterThemes(docs, []string{"please"})
+	for i := 0; i < 20; i++ {
+		if again := clusterThemes(docs, []string{"please"}); !reflect.DeepEqual(first, again) {
+			t.Fatalf("run %d differs:\n%+v\n%+v", i, first, again)
+		}
+	}
+}
+
+func TestClusterThemesEdgeCases(t *testing.T) {
+	if got := clusterThemes(nil, nil); len(got) != 0 {
+		t.Errorf("no documents gave %+v", got)
+	}
+	// Without any multi-document cluster, singletons are kept.
+	single := clusterThemes(themeDocs("Calendar sync with Google"), nil)
+	if len(single) != 1 || single[0].Count != 1 {
+		t.Errorf("singleton = %+v", single)
+	}
+	// Identical text is quoted once.
+	dupes := clusterThemes(themeDocs("dark mode now", "Dark  mode now", "dark mode now please"), nil)
+	if len(dupes) != 1 || len(dupes[0].Quotes) != 2 {
+		t.Errorf("duplicate quotes not collapsed: %+v", dupes)
+	}
+
+	many := make([]string, 0, 2*(maxThemes+2))
+	for i := 0; i < maxThemes+2; i++ {
+		word := fmt.Sprintf("topic%c", 'a'+i)
+		many = append(many, word+" alpha"+word, word+" beta"+word)
+	}
+	if got := clusterThemes(themeDocs(many...), nil); len(got) != maxThemes {
+		t.Errorf("got %d themes, want cap %d", len(got), maxThemes)
+	}
+}
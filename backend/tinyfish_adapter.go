@@ -2,10 +2,13 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"hash/crc32"
 	"io"
+	"log"
 	"net/http"
 	"net/url"
 	"strings"
@@ -13,7 +16,7 @@ import (
 )
 
 type TinyFishStep struct {
-	Action     string            `json:"action"`
+	Action     TinyFishAction    `json:"action"`
 	URL        string            `json:"url,omitempty"`
 	Selector   string            `json:"selector,omitempty"`
 	Text       string            `json:"text,omitempty"`
@@ -24,36 +27,95 @@ type TinyFishStep struct {
 }
 
 type TinyFishAdapter interface {
-	CreateSession(runID string) (string, error)
-	RunSteps(sessionID string, steps []TinyFishStep) (map[string]any, error)
-	CloseSession(sessionID string) error
+	CreateSession(ctx context.Context, runID string) (string, error)
+	RunSteps(ctx context.Context, sessionID string, steps []TinyFishStep) (map[string]any, error)
+	CloseSession(ctx context.Context, sessionID string) error
+	SessionAlive(ctx context.Context, sessionID string) (bool, error)
 	IsDemoMode() bool
 }
 
 type tinyFishHTTPAdapter struct {
-	baseURL string
-	apiKey  string
-	client  *http.Client
+	baseURL    string
+	apiKey     string
+	client     *http.Client
+	maxRetries int
+	breaker    *circuitBreaker
 }
 
 type tinyFishMockAdapter struct{}
 
 func newTinyFishAdapter() TinyFishAdapter {
+	switch tinyFishMode {
+	case tinyFishModeRecord:
+		return &tinyFishRecordAdapter{inner: newLiveTinyFishAdapter(), fixtures: tinyFishFixtures()}
+	case tinyFishModeReplay:
+		return &tinyFishReplayAdapter{fixtures: tinyFishFixtures()}
+	default:
+		return newLiveTinyFishAdapter()
+	}
+}
+
+func newLiveTinyFishAdapter() TinyFishAdapter {
 	if agentDemoMode {
 		return &tinyFishMockAdapter{}
 	}
 	return &tinyFishHTTPAdapter{
-		baseURL: strings.TrimSuffix(strings.TrimSpace(tinyFishBaseURL), "/"),
-		apiKey:  strings.TrimSpace(tinyFishAPIKey),
-		client:  &http.Client{Timeout: 60 * time.Second},
+		baseURL:    strings.TrimSuffix(strings.TrimSpace(tinyFishBaseURL), "/"),
+		apiKey:     strings.TrimSpace(tinyFishAPIKey),
+		client:     &http.Client{Timeout: 60 * time.Second},
+		maxRetries: tinyFishMaxRetries,
+		breaker:    sharedTinyFishBreaker(),
+	}
+}
+
+// tinyFishFixtures returns the shared fixture store. initTinyFishFixtures has
+// already failed startup if the file could not be loaded; a store that failed
+// later still serves what it has and refuses to record.
+func tinyFishFixtures() *tinyFishFixtureStore {
+	store, err := loadTinyFishFixtureStore(tinyFishFixturesPath)
+	if err != nil {
+		log.Printf("WARNING: tinyfish fixtures unavailable: %v", err)
+	}
+	if store == nil {
+		return &tinyFishFixtureStore{path: tinyFishFixturesPath, fixtures: map[string]tinyFishFixture{}, loadErr: err}
+	}
+	return store
+}
+
+func initTinyFishFixtures() error {
+	if tinyFishMode != tinyFishModeRecord && tinyFishMode != tinyFishModeReplay {
+		return nil
+	}
+	_, err := loadTinyFishFixtureStore(tinyFishFixturesPath)
+	return err
+}
+
+func tinyFishUsesHTTP() bool {
+	return !agentDemoMode && tinyFishMode != tinyFishModeReplay
+}
+
+func tinyFishAdapterName() string {
+	live := "http"
+	if agentDemoMode {
+		live = "mock"
+	}
+	switch tinyFishMode {
+	case tinyFishModeRecord:
+		return "record:" + live
+	case tinyFishModeReplay:
+		return "replay"
+	default:
+		return live
 	}
 }
 
 func (a *tinyFishHTTPAdapter) IsDemoMode() bool { return false }
 
-func (a *tinyFishHTTPAdapter) CreateSession(runID string) (string, error) {
+func (a *tinyFishHTTPAdapter) CreateSession(ctx context.Context, runID string) (string, error) {
+	// Creating a session is not idempotent: retrying after a timeout can leave
+	// the first session running remotely, so it gets a single attempt.
 	payload := map[string]any{"runId": runID}
-	respBody, err := a.request(http.MethodPost, "/sessions", payload)
+	respBody, err := a.send(ctx, http.MethodPost, "/sessions", payload, 0)
 	if err != nil {
 		return "", err
 	}
@@ -67,35 +129,88 @@ func (a *tinyFishHTTPAdapter) CreateSession(runID string) (string, error) {
 	return "", fmt.Errorf("tinyfish createSession missing session id")
 }
 
-func (a *tinyFishHTTPAdapter) RunSteps(sessionID string, steps []TinyFishStep) (map[string]any, error) {
+func (a *tinyFishHTTPAdapter) RunSteps(ctx context.Context, sessionID string, steps []TinyFishStep) (map[string]any, error) {
 	payload := map[string]any{
 		"steps": steps,
 	}
-	return a.request(http.MethodPost, "/sessions/"+url.PathEscape(sessionID)+"/run", payload)
+	return a.request(ctx, http.MethodPost, "/sessions/"+url.PathEscape(sessionID)+"/run", payload)
 }
 
-func (a *tinyFishHTTPAdapter) CloseSession(sessionID string) error {
-	_, err := a.request(http.MethodDelete, "/sessions/"+url.PathEscape(sessionID), nil)
+func (a *tinyFishHTTPAdapter) CloseSession(ctx context.Context, sessionID string) error {
+	_, err := a.request(ctx, http.MethodDelete, "/sessions/"+url.PathEscape(sessionID), nil)
 	return err
 }
 
-func (a *tinyFishHTTPAdapter) request(method, path string, payload any) (map[string]any, error) {
-	endpoint := a.baseURL + path
-	var body io.Reader
+func (a *tinyFishHTTPAdapter) SessionAlive(ctx context.Context, sessionID string) (bool, error) {
+	_, err := a.request(ctx, http.MethodGet, "/sessions/"+url.PathEscape(sessionID), nil)
+	var statusErr *tinyFishStatusError
+	if errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusNotFound || statusErr.StatusCode == http.StatusGone) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (a *tinyFishHTTPAdapter) request(ctx context.Context, method, path string, payload any) (map[string]any, error) {
+	return a.send(ctx, method, path, payload, a.maxRetries)
+}
+
+func (a *tinyFishHTTPAdapter) send(ctx context.Context, method, path string, payload any, maxRetries int) (map[string]any, error) {
+	var raw []byte
 	if payload != nil {
-		raw, err := json.Marshal(payload)
+		encoded, err := json.Marshal(payload)
 		if err != nil {
 			return nil, err
 		}
+		raw = encoded
+	}
+
+	if err := a.breaker.Allow(); err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		decoded, err := a.do(ctx, method, path, raw)
+		if err == nil {
+			a.breaker.Success()
+			return decoded, nil
+		}
+		lastErr = err
+		if !isRetryableTinyFishError(ctx, err) || attempt >= maxRetries || ctx.Err() != nil {
+			break
+		}
+
+		var retryAfter time.Duration
+		var statusErr *tinyFishStatusError
+		if errors.As(err, &statusErr) {
+			retryAfter = statusErr.RetryAfter
+		}
+		delay := tinyFishBackoff(attempt, retryAfter)
+		log.Printf("tinyfish %s %s attempt %d failed, retrying in %s: %v", method, path, attempt+1, delay, err)
+		if err := sleepContext(ctx, delay); err != nil {
+			break
+		}
+	}
+
+	recordTinyFishOutcome(ctx, a.breaker, lastErr)
+	return nil, lastErr
+}
+
+func (a *tinyFishHTTPAdapter) do(ctx context.Context, method, path string, raw []byte) (map[string]any, error) {
+	var body io.Reader
+	if raw != nil {
 		body = bytes.NewReader(raw)
 	}
 
-	req, err := http.NewRequest(method, endpoint, body)
+	req, err := http.NewRequestWithContext(ctx, method, a.baseURL+path, body)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("Accept", "application/json")
-	if payload != nil {
+	if raw != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
 	if a.apiKey != "" {
@@ -113,7 +228,13 @@ func (a *tinyFishHTTPAdapter) request(method, path string, payload any) (map[str
 		return nil, err
 	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("tinyfish %s %s failed (%d): %s", method, path, resp.StatusCode, strings.TrimSpace(string(respBytes)))
+		return nil, &tinyFishStatusError{
+			Method:     method,
+			Path:       path,
+			StatusCode: resp.StatusCode,
+			Body:       strings.TrimSpace(string(respBytes)),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
 	}
 
 	if len(bytes.TrimSpace(respBytes)) == 0 {
@@ -129,18 +250,24 @@ func (a *tinyFishHTTPAdapter) request(method, path string, payload any) (map[str
 
 func (a *tinyFishMockAdapter) IsDemoMode() bool { return true }
 
-func (a *tinyFishMockAdapter) CreateSession(runID string) (string, error) {
+func (a *tinyFishMockAdapter) CreateSession(ctx context.Context, runID string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
 	return "mock-session-" + runID, nil
 }
 
-func (a *tinyFishMockAdapter) RunSteps(_ string, steps []TinyFishStep) (map[string]any, error) {
+func (a *tinyFishMockAdapter) RunSteps(ctx context.Context, _ string, steps []TinyFishStep) (map[string]any, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	query := ""
 	visitURL := ""
 	for _, step := range steps {
-		if step.Action == "type" && strings.TrimSpace(step.Text) != "" {
+		if step.Action == TinyFishActionType && strings.TrimSpace(step.Text) != "" {
 			query = step.Text
 		}
-		if step.Action == "goto" && strings.TrimSpace(step.URL) != "" {
+		if step.Action == TinyFishActionGoto && strings.TrimSpace(step.URL) != "" {
 			visitURL = step.URL
 		}
 	}
@@ -185,7 +312,11 @@ func (a *tinyFishMockAdapter) RunSteps(_ string, steps []TinyFishStep) (map[stri
 	return map[string]any{}, nil
 }
 
-func (a *tinyFishMockAdapter) CloseSession(_ string) error { return nil }
+func (a *tinyFishMockAdapter) CloseSession(_ context.Context, _ string) error { return nil }
+
+func (a *tinyFishMockAdapter) SessionAlive(_ context.Context, sessionID string) (bool, error) {
+	return strings.HasPrefix(sessionID, "mock-session-"), nil
+}
 
 func mockHash(input string) int {
 	return int(crc32.ChecksumIEEE([]byte(strings.ToLower(strings.TrimSpace(input)))))
@@ -208,5 +339,5 @@ func extractHost(rawURL string) string {
 	if err != nil || parsed.Host == "" {
 		return "web"
 	}
-	return strings.TrimPrefix(parsed.Host, "www.")
+	return strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
 }
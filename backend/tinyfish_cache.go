@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTinyFishCacheTTL      = 24 * time.Hour
+	defaultTinyFishCacheMaxBytes = 64 << 20
+)
+
+type tinyFishCacheEntry struct {
+	Key        string         `json:"key"`
+	Steps      []TinyFishStep `json:"steps"`
+	Response   map[string]any `json:"response"`
+	StoredAt   time.Time      `json:"storedAt"`
+	FetchMS    int64          `json:"fetchMs"`
+	size       int64
+	lastUsedAt time.Time
+}
+
+type tinyFishCache struct {
+	mu       sync.Mutex
+	dir      string
+	ttl      time.Duration
+	maxBytes int64
+	entries  map[string]*tinyFishCacheEntry
+	bytes    int64
+}
+
+type tinyFishCacheView struct {
+	Enabled  bool   `json:"enabled"`
+	Dir      string `json:"dir,omitempty"`
+	TTL      string `json:"ttl,omitempty"`
+	Entries  int    `json:"entries"`
+	Bytes    int64  `json:"bytes"`
+	MaxBytes int64  `json:"max_bytes,omitempty"`
+}
+
+type agentCacheStats struct {
+	Hits     int   `json:"hits"`
+	Misses   int   `json:"misses"`
+	Bypassed bool  `json:"bypassed,omitempty"`
+	SavedMS  int64 `json:"saved_ms"`
+}
+
+var agentTinyFishCache *tinyFishCache
+
+// initTinyFishCache opens the step cache for live HTTP runs only: recording
+// must see real traffic, replay is already served from fixtures and demo
+// responses must never leak into real runs. The cache is an optimization, so
+// a broken cache dir disables it instead of failing boot.
+func initTinyFishCache() {
+	agentTinyFishCache = nil
+	if !tinyFishCacheEnabled || tinyFishMode != tinyFishModeLive || !tinyFishUsesHTTP() {
+		return
+	}
+	cache, err := openTinyFishCache(tinyFishCacheDir, tinyFishCacheTTL, int64(tinyFishCacheMaxBytes))
+	if err != nil {
+		log.Printf("WARNING: tinyfish cache disabled: %v", err)
+		return
+	}
+	agentTinyFishCache = cache
+}
+
+func openTinyFishCache(dir string, ttl time.Duration, maxBytes int64) (*tinyFishCache, error) {
+	if strings.TrimSpace(dir) == "" {
+		return nil, errors.New("tinyfish cache dir is empty")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create tinyfish cache dir: %w", err)
+	}
+	if ttl <= 0 {
+		ttl = defaultTinyFishCacheTTL
+	}
+	if maxBytes <= 0 {
+		maxBytes = defaultTinyFishCacheMaxBytes
+	}
+
+	c := &tinyFishCache{
+		dir:      dir,
+		ttl:      ttl,
+		maxBytes: maxBytes,
+		entries:  map[string]*tinyFishCacheEntry{},
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read tinyfish cache dir: %w", err)
+	}
+	now := time.Now().UTC()
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(dir, file.Name())
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var entry tinyFishCacheEntry
+		if err := json.Unmarshal(content, &entry); err != nil || entry.Key == "" || now.Sub(entry.StoredAt) > ttl {
+			_ = os.Remove(path)
+			continue
+		}
+		entry.size = info.Size()
+		entry.lastUsedAt = info.ModTime().UTC()
+		c.entries[entry.Key] = &entry
+		c.bytes += entry.size
+	}
+	c.evictLocked()
+	return c, nil
+}
+
+func (c *tinyFishCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+func (c *tinyFishCache) Get(steps []TinyFishStep) (map[string]any, int64, bool) {
+	key := tinyFishStepsKey(steps)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, 0, false
+	}
+	now := time.Now().UTC()
+	if now.Sub(entry.StoredAt) > c.ttl {
+		c.removeLocked(key)
+		return nil, 0, false
+	}
+	entry.lastUsedAt = now
+	// The file's mtime carries LRU order across restarts.
+	_ = os.Chtimes(c.path(key), now, now)
+	return cloneFixtureResponse(entry.Response), entry.FetchMS, true
+}
+
+func (c *tinyFishCache) Put(steps []TinyFishStep, response map[string]any, fetched time.Duration) error {
+	key := tinyFishStepsKey(steps)
+	now := time.Now().UTC()
+	entry := &tinyFishCacheEntry{
+		Key:        key,
+		Steps:      append([]TinyFishStep(nil), steps...),
+		Response:   cloneFixtureResponse(response),
+		StoredAt:   now,
+		FetchMS:    fetched.Milliseconds(),
+		lastUsedAt: now,
+	}
+	blob, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshal tinyfish cache entry: %w", err)
+	}
+	entry.size = int64(len(blob))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry.size > c.maxBytes {
+		return nil
+	}
+	path := c.path(key)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, blob, 0o600); err != nil {
+		return fmt.Errorf("write tinyfish cache entry: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("commit tinyfish cache entry: %w", err)
+	}
+	if previous, ok := c.entries[key]; ok {
+		c.bytes -= previous.size
+	}
+	c.entries[key] = entry
+	c.bytes += entry.size
+	c.evictLocked()
+	return nil
+}
+
+func (c *tinyFishCache) evictLocked() {
+	if c.bytes <= c.maxBytes {
+		return
+	}
+	keys := make([]string, 0, len(c.entries))
+	for key := range c.entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return c.entries[keys[i]].lastUsedAt.Before(c.entries[keys[j]].lastUsedAt)
+	})
+	for _, key := range keys {
+		if c.bytes <= c.maxBytes {
+			return
+		}
+		c.removeLocked(key)
+	}
+}
+
+func (c *tinyFishCache) removeLocked(key string) {
+	entry, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	if err := os.Remove(c.path(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("WARNING: failed to evict tinyfish cache entry %s: %v", key, err)
+	}
+	c.bytes -= entry.size
+	delete(c.entries, key)
+}
+
+func (s *agentCacheStats) record(hit bool, savedMS int64) {
+	if hit {
+		s.Hits++
+		s.SavedMS += savedMS
+		return
+	}
+	s.Misses++
+}
+
+func (c *tinyFishCache) View() tinyFishCacheView {
+	if c == nil {
+		return tinyFishCacheView{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return tinyFishCacheView{
+		Enabled:  true,
+		Dir:      c.dir,
+		TTL:      c.ttl.String(),
+		Entries:  len(c.entries),
+		Bytes:    c.bytes,
+		MaxBytes: c.maxBytes,
+	}
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	tinyFishModeLive   = "live"
+	tinyFishModeRecord = "record"
+	tinyFishModeReplay = "replay"
+
+	tinyFishFixtureVersion = 1
+	replaySessionPrefix    = "replay-session-"
+)
+
+type tinyFishFixture struct {
+	Key        string         `json:"key"`
+	Steps      []TinyFishStep `json:"steps"`
+	Response   map[string]any `json:"response"`
+	RecordedAt time.Time      `json:"recordedAt"`
+}
+
+type tinyFishFixtureFile struct {
+	Version  int               `json:"version"`
+	Fixtures []tinyFishFixture `json:"fixtures"`
+}
+
+// tinyFishFixtureStore is shared by every adapter using the same path. A file
+// that exists but cannot be read or parsed leaves the store read-only, so
+// recording never overwrites fixtures it failed to load.
+type tinyFishFixtureStore struct {
+	mu       sync.Mutex
+	path     string
+	fixtures map[string]tinyFishFixture
+	loadErr  error
+}
+
+type tinyFishRecordAdapter struct {
+	inner    TinyFishAdapter
+	fixtures *tinyFishFixtureStore
+}
+
+type tinyFishReplayAdapter struct {
+	fixtures *tinyFishFixtureStore
+}
+
+var (
+	tinyFishFixtureStoresMu sync.Mutex
+	tinyFishFixtureStores   = map[string]*tinyFishFixtureStore{}
+)
+
+func loadTinyFishFixtureStore(path string) (*tinyFishFixtureStore, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, errors.New("tinyfish fixtures path is empty")
+	}
+
+	tinyFishFixtureStoresMu.Lock()
+	defer tinyFishFixtureStoresMu.Unlock()
+	if store, ok := tinyFishFixtureStores[path]; ok {
+		return store, store.loadErr
+	}
+
+	store := &tinyFishFixtureStore{
+		path:     path,
+		fixtures: map[string]tinyFishFixture{},
+	}
+	tinyFishFixtureStores[path] = store
+	store.loadErr = store.load()
+	return store, store.loadErr
+}
+
+func (s *tinyFishFixtureStore) load() error {
+	content, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read tinyfish fixtures: %w", err)
+	}
+	if len(content) == 0 {
+		return nil
+	}
+	var file tinyFishFixtureFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return fmt.Errorf("parse tinyfish fixtures %s: %w", s.path, err)
+	}
+	for _, fixture := range file.Fixtures {
+		key := fixture.Key
+		if key == "" {
+			key = tinyFishStepsKey(fixture.Steps)
+		}
+		fixture.Key = key
+		s.fixtures[key] = fixture
+	}
+	return nil
+}
+
+func (s *tinyFishFixtureStore) Lookup(steps []TinyFishStep) (map[string]any, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fixture, ok := s.fixtures[tinyFishStepsKey(steps)]
+	if !ok {
+		return nil, false
+	}
+	return cloneFixtureResponse(fixture.Response), true
+}
+
+func (s *tinyFishFixtureStore) Record(steps []TinyFishStep, response map[string]any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loadErr != nil {
+		return fmt.Errorf("refusing to overwrite unreadable fixtures: %w", s.loadErr)
+	}
+
+	key := tinyFishStepsKey(steps)
+	s.fixtures[key] = tinyFishFixture{
+		Key:        key,
+		Steps:      append([]TinyFishStep(nil), steps...),
+		Response:   cloneFixtureResponse(response),
+		RecordedAt: time.Now().UTC(),
+	}
+	return s.persistLocked()
+}
+
+func (s *tinyFishFixtureStore) Count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.fixtures)
+}
+
+func (s *tinyFishFixtureStore) persistLocked() error {
+	file := tinyFishFixtureFile{
+		Version:  tinyFishFixtureVersion,
+		Fixtures: make([]tinyFishFixture, 0, len(s.fixtures)),
+	}
+	for _, fixture := range s.fixtures {
+		file.Fixtures = append(file.Fixtures, fixture)
+	}
+	sort.Slice(file.Fixtures, func(i, j int) bool {
+		return file.Fixtures[i].Key < file.Fixtures[j].Key
+	})
+
+	blob, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal tinyfish fixtures: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("create tinyfish fixtures dir: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, blob, 0o600); err != nil {
+		return fmt.Errorf("write tinyfish fixtures: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func cloneFixtureResponse(response map[string]any) map[string]any {
+	if response == nil {
+		return map[string]any{}
+	}
+	blob, err := json.Marshal(response)
+	if err != nil {
+		return map[string]any{}
+	}
+	var out map[string]any
+	if err := json.Unmarshal(blob, &out); err != nil {
+		return map[string]any{}
+	}
+	return out
+}
+
+func tinyFishStepsKey(steps []TinyFishStep) string {
+	normalized := make([]TinyFishStep, 0, len(steps))
+	for _, step := range steps {
+		action := TinyFishAction(strings.TrimSpace(string(step.Action)))
+		if action == TinyFishActionWait {
+			continue
+		}
+		normalized = append(normalized, TinyFishStep{
+			Action:     action,
+			URL:        normalizeFixtureURL(step.URL),
+			Selector:   strings.TrimSpace(step.Selector),
+			Text:       strings.ToLower(strings.Join(strings.Fields(step.Text), " ")),
+			MaxScrolls: step.MaxScrolls,
+			Limit:      step.Limit,
+			Fields:     step.Fields,
+		})
+	}
+
+	blob, _ := json.Marshal(normalized)
+	sum := sha256.Sum256(blob)
+	return hex.EncodeToString(sum[:])
+}
+
+func normalizeFixtureURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" {
+		return raw
+	}
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	parsed.Host = strings.ToLower(parsed.Host)
+	parsed.Fragment = ""
+	if parsed.Path != "/" {
+		parsed.Path = strings.TrimSuffix(parsed.Path, "/")
+	}
+	return parsed.String()
+}
+
+func (a *tinyFishRecordAdapter) IsDemoMode() bool { return a.inner.IsDemoMode() }
+
+func (a *tinyFishRecordAdapter) CreateSession(ctx context.Context, runID string) (string, error) {
+	return a.inner.CreateSession(ctx, runID)
+}
+
+func (a *tinyFishRecordAdapter) RunSteps(ctx context.Context, sessionID string, steps []TinyFishStep) (map[string]any, error) {
+	resp, err := a.inner.RunSteps(ctx, sessionID, steps)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.fixtures.Record(steps, resp); err != nil {
+		return nil, fmt.Errorf("tinyfish record fixture: %w", err)
+	}
+	return resp, nil
+}
+
+func (a *tinyFishRecordAdapter) CloseSession(ctx context.Context, sessionID string) error {
+	return a.inner.CloseSession(ctx, sessionID)
+}
+
+func (a *tinyFishRecordAdapter) SessionAlive(ctx context.Context, sessionID string) (bool, error) {
+	return a.inner.SessionAlive(ctx, sessionID)
+}
+
+func (a *tinyFishReplayAdapter) IsDemoMode() bool { return true }
+
+func (a *tinyFishReplayAdapter) CreateSession(ctx context.Context, runID string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return replaySessionPrefix + runID, nil
+}
+
+func (a *tinyFishReplayAdapter) RunSteps(ctx context.Context, _ string, steps []TinyFishStep) (map[string]any, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	resp, ok := a.fixtures.Lookup(steps)
+	if !ok {
+		return nil, fmt.Errorf("tinyfish replay: no fixture for steps (key %s) in %s", tinyFishStepsKey(steps), a.fixtures.path)
+	}
+	return resp, nil
+}
+
+func (a *tinyFishReplayAdapter) CloseSession(_ context.Context, _ string) error { return nil }
+
+func (a *tinyFishReplayAdapter) SessionAlive(_ context.Context, sessionID string) (bool, error) {
+	return strings.HasPrefix(sessionID, replaySessionPrefix), nil
+}
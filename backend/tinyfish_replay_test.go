@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTinyFishRecordThenReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixtures.json")
+	store, err := loadTinyFishFixtureStore(path)
+	if err != nil {
+		t.Fatalf("load empty store: %v", err)
+	}
+	recorder := &tinyFishRecordAdapter{inner: &tinyFishMockAdapter{}, fixtures: store}
+
+	steps := []TinyFishStep{
+		{Action: TinyFishActionGoto, URL: "https://Example.com/pricing/#plans"},
+		{Action: TinyFishActionWait, MS: 500},
+		{Action: TinyFishActionExtract, Fields: map[string]string{"title": "h1"}},
+	}
+	recorded, err := recorder.RunSteps(context.Background(), "s1", steps)
+	if err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	// A fresh store reads the file back, as a replay process would.
+	delete(tinyFishFixtureStores, path)
+	reloaded, err := loadTinyFishFixtureStore(path)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	replay := &tinyFishReplayAdapter{fixtures: reloaded}
+
+	// Waits, host case, fragments and trailing slashes do not change the key.
+	lookup := []TinyFishStep{
+		{Action: TinyFishActionGoto, URL: "https://example.com/pricing"},
+		{Action: TinyFishActionExtract, Fields: map[string]string{"title": "h1"}},
+	}
+	replayed, err := replay.RunSteps(context.Background(), "replay-session-r1", lookup)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if !reflect.DeepEqual(replayed, cloneFixtureResponse(recorded)) {
+		t.Fatalf("replayed response differs:\n got %v\nwant %v", replayed, recorded)
+	}
+
+	if _, err := replay.RunSteps(context.Background(), "replay-session-r1", []TinyFishStep{{Action: TinyFishActionGoto, URL: "https://other.example"}}); err == nil {
+		t.Fatal("expected a miss for unrecorded steps")
+	}
+}
+
+func TestTinyFishFixtureStoreIsShared(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixtures.json")
+	first, err := loadTinyFishFixtureStore(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	second, err := loadTinyFishFixtureStore(path)
+	if err != nil {
+		t.Fatalf("load again: %v", err)
+	}
+	if first != second {
+		t.Fatal("expected one store per path")
+	}
+
+	a := &tinyFishRecordAdapter{inner: &tinyFishMockAdapter{}, fixtures: first}
+	b := &tinyFishRecordAdapter{inner: &tinyFishMockAdapter{}, fixtures: second}
+	if _, err := a.RunSteps(context.Background(), "s", []TinyFishStep{{Action: TinyFishActionGoto, URL: "https://a.example"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.RunSteps(context.Background(), "s", []TinyFishStep{{Action: TinyFishActionGoto, URL: "https://b.example"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	delete(tinyFishFixtureStores, path)
+	reloaded, err := loadTinyFishFixtureStore(path)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if got := reloaded.Count(); got != 2 {
+		t.Fatalf("expected both recordings on disk, got %d", got)
+	}
+}
+
+func TestTinyFishCorruptFixturesAreNotOverwritten(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixtures.json")
+	corrupt := []byte(`{"version": 1, "fixtures": [`)
+	if err := os.WriteFile(path, corrupt, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := loadTinyFishFixtureStore(path)
+	if err == nil || !strings.Contains(err.Error(), "parse tinyfish fixtures") {
+		t.Fatalf("expected a parse error, got %v", err)
+	}
+	if _, again := loadTinyFishFixtureStore(path); again == nil {
+		t.Fatal("expected the cached store to keep its load error")
+	}
+
+	recorder := &tinyFishRecordAdapter{inner: &tinyFishMockAdapter{}, fixtures: store}
+	if _, err := recorder.RunSteps(context.Background(), "s", []TinyFishStep{{Action: TinyFishActionGoto, URL: "https://a.example"}}); err == nil {
+		t.Fatal("expected recording to be refused")
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != string(corrupt) {
+		t.Fatalf("fixture file was rewritten: %s", content)
+	}
+}
+
+func TestInitTinyFishFixturesFailsOnCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixtures.json")
+	if err := os.WriteFile(path, []byte("not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	prevMode, prevPath := tinyFishMode, tinyFishFixturesPath
+	t.Cleanup(func() { tinyFishMode, tinyFishFixturesPath = prevMode, prevPath })
+
+	tinyFishMode, tinyFishFixturesPath = tinyFishModeRecord, path
+	if err := initTinyFishFixtures(); err == nil {
+		t.Fatal("expected startup to fail in record mode")
+	}
+	tinyFishMode = tinyFishModeLive
+	if err := initTinyFishFixtures(); err != nil {
+		t.Fatalf("live mode should not load fixtures: %v", err)
+	}
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTinyFishMaxRetries       = 3
+	defaultTinyFishRetryBaseDelay   = 500 * time.Millisecond
+	defaultTinyFishRetryMaxDelay    = 10 * time.Second
+	defaultTinyFishBreakerThreshold = 5
+	defaultTinyFishBreakerCooldown  = 30 * time.Second
+
+	breakerStateClosed   = "closed"
+	breakerStateOpen     = "open"
+	breakerStateHalfOpen = "half_open"
+)
+
+var errTinyFishCircuitOpen = errors.New("tinyfish circuit breaker is open")
+
+type tinyFishStatusError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration
+}
+
+func (e *tinyFishStatusError) Error() string {
+	return fmt.Sprintf("tinyfish %s %s failed (%d): %s", e.Method, e.Path, e.StatusCode, e.Body)
+}
+
+type circuitBreaker struct {
+	mu            sync.Mutex
+	threshold     int
+	cooldown      time.Duration
+	state         string
+	failures      []time.Time
+	openedAt      time.Time
+	probeInFlight bool
+	lastError     string
+}
+
+type circuitBreakerView struct {
+	State          string `json:"state"`
+	RecentFailures int    `json:"recent_failures"`
+	Threshold      int    `json:"threshold"`
+	Cooldown       string `json:"cooldown"`
+	OpenedAt       string `json:"opened_at,omitempty"`
+	RetryAt        string `json:"retry_at,omitempty"`
+	LastError      string `json:"last_error,omitempty"`
+}
+
+var (
+	tinyFishBreakerOnce sync.Once
+	tinyFishBreaker     *circuitBreaker
+)
+
+func sharedTinyFishBreaker() *circuitBreaker {
+	tinyFishBreakerOnce.Do(func() {
+		tinyFishBreaker = newCircuitBreaker(tinyFishBreakerThreshold, tinyFishBreakerCooldown)
+	})
+	return tinyFishBreaker
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	if threshold <= 0 {
+		threshold = defaultTinyFishBreakerThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = defaultTinyFishBreakerCooldown
+	}
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		state:     breakerStateClosed,
+	}
+}
+
+func (b *circuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerStateOpen:
+		retryAt := b.openedAt.Add(b.cooldown)
+		if time.Now().Before(retryAt) {
+			return fmt.Errorf("%w until %s: %s", errTinyFishCircuitOpen, retryAt.UTC().Format(time.RFC3339), b.lastError)
+		}
+		b.state = breakerStateHalfOpen
+		b.probeInFlight = true
+		return nil
+	case breakerStateHalfOpen:
+		if b.probeInFlight {
+			return fmt.Errorf("%w: probe request in flight", errTinyFishCircuitOpen)
+		}
+		b.probeInFlight = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+func (b *circuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probeInFlight = false
+	if b.state == breakerStateHalfOpen {
+		b.state = breakerStateClosed
+		b.failures = nil
+		b.lastError = ""
+	}
+}
+
+func (b *circuitBreaker) Failure(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now().UTC()
+	b.pruneLocked(now)
+	b.failures = append(b.failures, now)
+	b.probeInFlight = false
+	if err != nil {
+		b.lastError = err.Error()
+	}
+	if b.state == breakerStateHalfOpen || len(b.failures) >= b.threshold {
+		b.state = breakerStateOpen
+		b.openedAt = now
+	}
+}
+
+func (b *circuitBreaker) pruneLocked(now time.Time) {
+	kept := b.failures[:0]
+	for _, at := range b.failures {
+		if now.Sub(at) <= b.cooldown {
+			kept = append(kept, at)
+		}
+	}
+	b.failures = kept
+}
+
+func (b *circuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probeInFlight = false
+}
+
+func (b *circuitBreaker) IsOpen() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state == breakerStateOpen && time.Now().Before(b.openedAt.Add(b.cooldown))
+}
+
+func (b *circuitBreaker) View() circuitBreakerView {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.pruneLocked(time.Now().UTC())
+	view := circuitBreakerView{
+		State:          b.state,
+		RecentFailures: len(b.failures),
+		Threshold:      b.threshold,
+		Cooldown:       b.cooldown.String(),
+		LastError:      b.lastError,
+	}
+	if b.state != breakerStateClosed && !b.openedAt.IsZero() {
+		view.OpenedAt = b.openedAt.Format(time.RFC3339)
+		view.RetryAt = b.openedAt.Add(b.cooldown).Format(time.RFC3339)
+	}
+	return view
+}
+
+// tinyFishCallerCancelled reports whether ctx ended for reasons that say
+// nothing about TinyFish: the run was cancelled or hit its own deadline. A
+// step deadline means TinyFish hung and counts against the breaker.
+func tinyFishCallerCancelled(ctx context.Context) bool {
+	err := ctx.Err()
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) {
+		return true
+	}
+	return !errors.Is(context.Cause(ctx), context.DeadlineExceeded)
+}
+
+func isRetryableTinyFishError(ctx context.Context, err error) bool {
+	if err == nil || tinyFishCallerCancelled(ctx) {
+		return false
+	}
+	if ctx.Err() != nil {
+		return true
+	}
+	var statusErr *tinyFishStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= http.StatusInternalServerError
+	}
+	var transportErr *url.Error
+	return errors.As(err, &transportErr)
+}
+
+func recordTinyFishOutcome(ctx context.Context, breaker *circuitBreaker, err error) {
+	switch {
+	case err == nil:
+		breaker.Success()
+	case isRetryableTinyFishError(ctx, err):
+		breaker.Failure(err)
+	case tinyFishCallerCancelled(ctx):
+		breaker.Release()
+	default:
+		breaker.Success()
+	}
+}
+
+func tinyFishBackoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		if retryAfter > tinyFishRetryMaxDelay {
+			return tinyFishRetryMaxDelay
+		}
+		return retryAfter
+	}
+
+	ceiling := tinyFishRetryBaseDelay << attempt
+	if ceiling <= 0 || ceiling > tinyFishRetryMaxDelay {
+		ceiling = tinyFishRetryMaxDelay
+	}
+	half := ceiling / 2
+	return half + time.Duration(rand.Int64N(int64(half)+1))
+}
+
+func parseRetryAfter(raw string, now time.Time) time.Duration {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(raw); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(raw); err == nil {
+		if delay := at.Sub(now); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
+
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestTinyFishAdapter(t *testing.T, handler http.HandlerFunc, breaker *circuitBreaker) *tinyFishHTTPAdapter {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return &tinyFishHTTPAdapter{
+		baseURL:    server.URL,
+		client:     server.Client(),
+		maxRetries: 2,
+		breaker:    breaker,
+	}
+}
+
+// hangingTinyFish never answers. The body is drained so the server notices
+// when the client gives up and cancels the handler's context.
+func hangingTinyFish(w http.ResponseWriter, r *http.Request) {
+	_, _ = io.Copy(io.Discard, r.Body)
+	<-r.Context().Done()
+}
+
+func TestCircuitBreakerOpensAndHalfOpens(t *testing.T) {
+	breaker := newCircuitBreaker(3, 40*time.Millisecond)
+	failure := errors.New("boom")
+
+	for i := 0; i < 2; i++ {
+		if err := breaker.Allow(); err != nil {
+			t.Fatalf("attempt %d rejected while closed: %v", i, err)
+		}
+		breaker.Failure(failure)
+	}
+	if got := breaker.View().State; got != breakerStateClosed {
+		t.Fatalf("state after 2 failures = %s, want closed", got)
+	}
+	breaker.Failure(failure)
+	if !breaker.IsOpen() {
+		t.Fatal("breaker should open at the threshold")
+	}
+	if err := breaker.Allow(); !errors.Is(err, errTinyFishCircuitOpen) {
+		t.Fatalf("Allow while open = %v, want errTinyFishCircuitOpen", err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("probe after cooldown rejected: %v", err)
+	}
+	if got := breaker.View().State; got != breakerStateHalfOpen {
+		t.Fatalf("state after cooldown = %s, want half_open", got)
+	}
+	if err := breaker.Allow(); !errors.Is(err, errTinyFishCircuitOpen) {
+		t.Fatalf("second request during probe = %v, want errTinyFishCircuitOpen", err)
+	}
+
+	// A failed probe reopens immediately.
+	breaker.Failure(failure)
+	if !breaker.IsOpen() {
+		t.Fatal("failed probe should reopen the breaker")
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("second probe rejected: %v", err)
+	}
+	breaker.Success()
+	if view := breaker.View(); view.State != breakerStateClosed || view.RecentFailures != 0 {
+		t.Fatalf("successful probe left %+v, want closed with no failures", view)
+	}
+}
+
+func TestTinyFishStepTimeoutOpensBreaker(t *testing.T) {
+	adapter := newTestTinyFishAdapter(t, hangingTinyFish, newCircuitBreaker(2, time.Minute))
+
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		_, err := adapter.RunSteps(ctx, "s1", []TinyFishStep{{Action: TinyFishActionGoto, URL: "https://example.com"}})
+		cancel()
+		if err == nil {
+			t.Fatal("expected a timeout")
+		}
+	}
+	if !adapter.breaker.IsOpen() {
+		t.Fatalf("hanging TinyFish should open the breaker, got %+v", adapter.breaker.View())
+	}
+
+	started := time.Now()
+	_, err := adapter.RunSteps(context.Background(), "s1", []TinyFishStep{{Action: TinyFishActionGoto, URL: "https://example.com"}})
+	if !errors.Is(err, errTinyFishCircuitOpen) {
+		t.Fatalf("expected fast failure from the open breaker, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 10*time.Millisecond {
+		t.Fatalf("open breaker still waited %s", elapsed)
+	}
+}
+
+func TestTinyFishCallerCancellationDoesNotCount(t *testing.T) {
+	adapter := newTestTinyFishAdapter(t, hangingTinyFish, newCircuitBreaker(1, time.Minute))
+
+	runCtx, cancelRun := context.WithCancel(context.Background())
+	stepCtx, cancelStep := context.WithTimeout(runCtx, time.Minute)
+	defer cancelStep()
+	time.AfterFunc(20*time.Millisecond, cancelRun)
+	if _, err := adapter.RunSteps(stepCtx, "s1", nil); err == nil {
+		t.Fatal("expected cancellation error")
+	}
+
+	runCtx, stopRun := context.WithTimeoutCause(context.Background(), 20*time.Millisecond, errAgentRunTimedOut)
+	defer stopRun()
+	stepCtx, cancelStep = context.WithTimeout(runCtx, time.Minute)
+	defer cancelStep()
+	if _, err := adapter.RunSteps(stepCtx, "s1", nil); err == nil {
+		t.Fatal("expected run deadline error")
+	}
+
+	if view := adapter.breaker.View(); view.State != breakerStateClosed || view.RecentFailures != 0 {
+		t.Fatalf("caller cancellation was counted: %+v", view)
+	}
+	if err := adapter.breaker.Allow(); err != nil {
+		t.Fatalf("breaker should still allow requests: %v", err)
+	}
+}
+
+func TestTinyFishRetriesIdempotentRequestsOnly(t *testing.T) {
+	var creates, runs atomic.Int32
+	adapter := newTestTinyFishAdapter(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/sessions" {
+			creates.Add(1)
+		} else {
+			runs.Add(1)
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, newCircuitBreaker(10, time.Minute))
+
+	if _, err := adapter.CreateSession(context.Background(), "run-1"); err == nil {
+		t.Fatal("expected create to fail")
+	}
+	if got := creates.Load(); got != 1 {
+		t.Fatalf("POST /sessions attempts = %d, want 1", got)
+	}
+
+	if _, err := adapter.RunSteps(context.Background(), "s1", nil); err == nil {
+		t.Fatal("expected run to fail")
+	}
+	if got := runs.Load(); got != 3 {
+		t.Fatalf("run attempts = %d, want 3 (1 + 2 retries)", got)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		raw  string
+		want time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{"-1", 0},
+		{now.Add(5 * time.Second).Format(http.TimeFormat), 5 * time.Second},
+		{now.Add(-5 * time.Second).Format(http.TimeFormat), 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.raw, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.raw, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+type TinyFishAction string
+
+const (
+	TinyFishActionGoto        TinyFishAction = "goto"
+	TinyFishActionType        TinyFishAction = "type"
+	TinyFishActionClick       TinyFishAction = "click"
+	TinyFishActionScroll      TinyFishAction = "scroll"
+	TinyFishActionWait        TinyFishAction = "wait"
+	TinyFishActionExtract     TinyFishAction = "extract"
+	TinyFishActionExtractList TinyFishAction = "extractList"
+
+	maxTinyFishStepsPerBatch = 25
+	maxTinyFishTextLength    = 500
+	maxTinyFishWaitMS        = 30000
+	maxTinyFishScrolls       = 10
+	maxTinyFishListLimit     = 50
+	maxTinyFishFields        = 20
+)
+
+type tinyFishStepRule struct {
+	requiresURL      bool
+	requiresSelector bool
+	allowsSelector   bool
+	requiresText     bool
+	requiresMS       bool
+	requiresScrolls  bool
+	requiresLimit    bool
+	requiresFields   bool
+}
+
+var tinyFishStepRules = map[TinyFishAction]tinyFishStepRule{
+	TinyFishActionGoto:        {requiresURL: true},
+	TinyFishActionType:        {requiresSelector: true, allowsSelector: true, requiresText: true},
+	TinyFishActionClick:       {requiresSelector: true, allowsSelector: true},
+	TinyFishActionScroll:      {allowsSelector: true, requiresScrolls: true},
+	TinyFishActionWait:        {allowsSelector: true, requiresMS: true},
+	TinyFishActionExtract:     {allowsSelector: true, requiresFields: true},
+	TinyFishActionExtractList: {requiresSelector: true, allowsSelector: true, requiresLimit: true, requiresFields: true},
+}
+
+func (s TinyFishStep) Validate() error {
+	rule, ok := tinyFishStepRules[s.Action]
+	if !ok {
+		return fmt.Errorf("unknown action %q", s.Action)
+	}
+
+	problems := make([]string, 0, 4)
+	check := func(present bool, required bool, allowed bool, field string) {
+		switch {
+		case required && !present:
+			problems = append(problems, field+" is required")
+		case !allowed && present:
+			problems = append(problems, field+" is not accepted")
+		}
+	}
+
+	check(strings.TrimSpace(s.URL) != "", rule.requiresURL, rule.requiresURL, "url")
+	check(strings.TrimSpace(s.Selector) != "", rule.requiresSelector, rule.allowsSelector, "selector")
+	check(strings.TrimSpace(s.Text) != "", rule.requiresText, rule.requiresText, "text")
+	check(s.MS != 0, rule.requiresMS, rule.requiresMS, "ms")
+	check(s.MaxScrolls != 0, rule.requiresScrolls, rule.requiresScrolls, "maxScrolls")
+	check(s.Limit != 0, rule.requiresLimit, rule.requiresLimit, "limit")
+	check(len(s.Fields) > 0, rule.requiresFields, rule.requiresFields, "fields")
+
+	if rule.requiresURL && strings.TrimSpace(s.URL) != "" {
+		parsed, err := url.Parse(strings.TrimSpace(s.URL))
+		if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			problems = append(problems, "url must be an absolute http(s) URL")
+		}
+	}
+	if rule.requiresText && len([]rune(s.Text)) > maxTinyFishTextLength {
+		problems = append(problems, fmt.Sprintf("text exceeds %d characters", maxTinyFishTextLength))
+	}
+	if rule.requiresMS && (s.MS < 0 || s.MS > maxTinyFishWaitMS) {
+		problems = append(problems, fmt.Sprintf("ms must be between 1 and %d", maxTinyFishWaitMS))
+	}
+	if rule.requiresScrolls && (s.MaxScrolls < 0 || s.MaxScrolls > maxTinyFishScrolls) {
+		problems = append(problems, fmt.Sprintf("maxScrolls must be between 1 and %d", maxTinyFishScrolls))
+	}
+	if rule.requiresLimit && (s.Limit < 0 || s.Limit > maxTinyFishListLimit) {
+		problems = append(problems, fmt.Sprintf("limit must be between 1 and %d", maxTinyFishListLimit))
+	}
+	if rule.requiresFields {
+		if len(s.Fields) > maxTinyFishFields {
+			problems = append(problems, fmt.Sprintf("fields exceeds %d entries", maxTinyFishFields))
+		}
+		for name, selector := range s.Fields {
+			if strings.TrimSpace(name) == "" || strings.TrimSpace(selector) == "" {
+				problems = append(problems, "fields must map non-empty names to non-empty selectors")
+				break
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s: %s", s.Action, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func validateTinyFishSteps(steps []TinyFishStep) error {
+	if len(steps) == 0 {
+		return errors.New("tinyfish step batch is empty")
+	}
+	if len(steps) > maxTinyFishStepsPerBatch {
+		return fmt.Errorf("tinyfish step batch exceeds %d steps", maxTinyFishStepsPerBatch)
+	}
+	for idx, step := range steps {
+		if err := step.Validate(); err != nil {
+			return fmt.Errorf("invalid tinyfish step %d: %w", idx+1, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTinyFishStepValidate(t *testing.T) {
+	fields := map[string]string{"title": "h1"}
+	tests := []struct {
+		name    string
+		step    TinyFishStep
+		wantErr string
+	}{
+		{"goto", TinyFishStep{Action: TinyFishActionGoto, URL: "https://example.com"}, ""},
+		{"goto relative url", TinyFishStep{Action: TinyFishActionGoto, URL: "/pricing"}, "absolute http(s) URL"},
+		{"goto missing url", TinyFishStep{Action: TinyFishActionGoto}, "url is required"},
+		{"type", TinyFishStep{Action: TinyFishActionType, Selector: "input", Text: "dark mode"}, ""},
+		{"type empty text", TinyFishStep{Action: TinyFishActionType, Selector: "input"}, "text is required"},
+		{"type whitespace text", TinyFishStep{Action: TinyFishActionType, Selector: "input", Text: " \t\n"}, "text is required"},
+		{"type long text", TinyFishStep{Action: TinyFishActionType, Selector: "input", Text: strings.Repeat("a", maxTinyFishTextLength+1)}, "text exceeds"},
+		{"click with text", TinyFishStep{Action: TinyFishActionClick, Selector: "button", Text: "x"}, "text is not accepted"},
+		{"wait too long", TinyFishStep{Action: TinyFishActionWait, MS: maxTinyFishWaitMS + 1}, "ms must be between"},
+		{"scroll", TinyFishStep{Action: TinyFishActionScroll, MaxScrolls: 2}, ""},
+		{"extract", TinyFishStep{Action: TinyFishActionExtract, Fields: fields}, ""},
+		{"extract blank selector", TinyFishStep{Action: TinyFishActionExtract, Fields: map[string]string{"title": " "}}, "non-empty selectors"},
+		{"extractList without limit", TinyFishStep{Action: TinyFishActionExtractList, Selector: "li", Fields: fields}, "limit is required"},
+		{"unknown action", TinyFishStep{Action: "hover"}, "unknown action"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.step.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
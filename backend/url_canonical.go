@@ -0,0 +1,151 @@
+package main
+
+import (
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const ampCacheHostSuffix = ".cdn.ampproject.org"
+
+var trackingQueryParams = map[string]struct{}{
+	"fbclid":  {},
+	"gclid":   {},
+	"dclid":   {},
+	"msclkid": {},
+	"yclid":   {},
+	"igshid":  {},
+	"mc_cid":  {},
+	"mc_eid":  {},
+	"_hsenc":  {},
+	"_hsmi":   {},
+	"_ga":     {},
+	"_gl":     {},
+	"ref":     {},
+	"ref_src": {},
+	"ref_url": {},
+	"si":      {},
+	"spm":     {},
+	"amp":     {},
+}
+
+// canonicalizeURL maps the many spellings of one page onto a single key:
+// bare lowercase host without its default port, no fragment, tracking
+// parameters or trailing slash, sorted query, and the non-AMP variant of AMP
+// pages. The scheme and any other port are kept. The key is only for
+// grouping; it may not be a URL that serves the page, so evidence keeps the
+// URL that was actually visited.
+func canonicalizeURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" {
+		return raw
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return raw
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+	path := parsed.EscapedPath()
+	if strings.HasSuffix(host, ampCacheHostSuffix) {
+		if origin, ok := ampCacheOrigin(path); ok {
+			return canonicalizeURL(origin)
+		}
+	}
+	host = strings.TrimPrefix(host, "amp.")
+	if strings.HasPrefix(host, "m.") && strings.Count(host, ".") >= 2 {
+		host = strings.TrimPrefix(host, "m.")
+	}
+
+	path = stripAMPPath(path)
+	if path != "/" {
+		path = strings.TrimRight(path, "/")
+	}
+
+	query := parsed.Query()
+	for key := range query {
+		lower := strings.ToLower(key)
+		if _, tracking := trackingQueryParams[lower]; tracking || strings.HasPrefix(lower, "utm_") {
+			query.Del(key)
+		}
+		if lower == "output" && strings.EqualFold(query.Get(key), "amp") {
+			query.Del(key)
+		}
+	}
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, url.QueryEscape(key)+"="+url.QueryEscape(value))
+		}
+	}
+
+	port := parsed.Port()
+	if (parsed.Scheme == "http" && port == "80") || (parsed.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	switch {
+	case port != "":
+		host = net.JoinHostPort(host, port)
+	case strings.Contains(host, ":"):
+		host = "[" + host + "]"
+	}
+	canonical := parsed.Scheme + "://" + host + path
+	if len(pairs) > 0 {
+		canonical += "?" + strings.Join(pairs, "&")
+	}
+	return canonical
+}
+
+// ampCacheOrigin unwraps Google AMP cache paths such as
+// /c/s/example.com/article/amp into https://example.com/article/amp.
+func ampCacheOrigin(path string) (string, bool) {
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(segments) < 2 {
+		return "", false
+	}
+	scheme := "http://"
+	switch segments[0] {
+	case "c", "v", "i":
+		segments = segments[1:]
+	default:
+		return "", false
+	}
+	if len(segments) > 0 && segments[0] == "s" {
+		scheme = "https://"
+		segments = segments[1:]
+	}
+	if len(segments) == 0 || !strings.Contains(segments[0], ".") {
+		return "", false
+	}
+	return scheme + strings.Join(segments, "/"), true
+}
+
+func stripAMPPath(path string) string {
+	switch {
+	case path == "/amp" || path == "/amp/":
+		return "/"
+	case strings.HasPrefix(path, "/amp/"):
+		path = strings.TrimPrefix(path, "/amp")
+	}
+	trimmed := strings.TrimRight(path, "/")
+	switch {
+	case strings.HasSuffix(trimmed, "/amp"):
+		path = strings.TrimSuffix(trimmed, "/amp")
+	case strings.HasSuffix(trimmed, ".amp"):
+		path = strings.TrimSuffix(trimmed, ".amp")
+	case strings.HasSuffix(trimmed, ".amp.html"):
+		path = strings.TrimSuffix(trimmed, ".amp.html") + ".html"
+	}
+	if path == "" {
+		return "/"
+	}
+	return path
+}
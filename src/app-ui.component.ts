@@ -124,7 +124,7 @@ interface WorkspaceOption {
   name: string;
 }
 
-type FeatureResearchStatus = 'queued' | 'running' | 'failed' | 'completed';
+type FeatureResearchStatus = 'queued' | 'running' | 'failed' | 'completed' | 'cancelled';
 
 interface FeatureResearchProgress {
   pagesVisited: number;
@@ -716,7 +716,7 @@ export class AppUiComponent implements OnInit, OnDestroy {
         this.featureResearchArtifacts = await artifactResp.json();
       }
 
-      if (this.featureResearchRun?.status === 'completed' || this.featureResearchRun?.status === 'failed') {
+      if (this.featureResearchRun?.status === 'completed' || this.featureResearchRun?.status === 'failed' || this.featureResearchRun?.status === 'cancelled') {
         this.stopFeatureResearchPolling();
       }
     } catch {
@@ -6,12 +6,20 @@ import { RouterLink } from '@angular/router';
 type DecisionRunSummary = {
   id: string;
   feature_name: string;
-  status: 'queued' | 'running' | 'failed' | 'completed' | 'needs_user_action';
+  status: 'queued' | 'running' | 'failed' | 'completed' | 'cancelled' | 'needs_user_action';
   current_step?: string | null;
   created_at?: string;
   updated_at?: string;
 };
 
+type DecisionSink = 'jira' | 'linear';
+
+type DecisionRunPage = {
+  runs: DecisionRunSummary[];
+  next_cursor?: string;
+  total?: number;
+};
+
 type Artifact = {
   id?: string;
   type: string;
@@ -25,6 +33,7 @@ type DecisionRunDetail = {
   feature_name: string;
   status: DecisionRunSummary['status'];
   current_step?: string | null;
+  sink?: DecisionSink;
   error?: string | null;
   artifacts: Artifact[];
   created_at?: string;
@@ -40,7 +49,13 @@ type DecisionRunDetail = {
 })
 export class DecisionOperatorComponent implements OnInit, OnDestroy {
   featureName = 'Dark Mode';
+  runSink: DecisionSink = 'jira';
   runs: DecisionRunSummary[] = [];
+  runsTotal = 0;
+  nextCursor = '';
+  searchText = '';
+  statusFilter = '';
+  readonly pageSize = 25;
   selectedRun: DecisionRunDetail | null = null;
 
   operatorHealth = 'unknown';
@@ -51,9 +66,17 @@ export class DecisionOperatorComponent implements OnInit, OnDestroy {
   creatingRun = false;
   importingSlack = false;
   connectingJira = false;
+  runActionPending = '';
 
   jiraBaseUrl = 'https://your-org.atlassian.net';
+  jiraEmail = '';
+  jiraApiToken = '';
   jiraSessionCookie = '';
+  jiraProjectKey = '';
+  linearApiKey = '';
+  linearTeamKey = '';
+  linearTarget: 'issue' | 'project' = 'issue';
+  connectingLinear = false;
 
   private pollHandle: any = null;
 
@@ -92,12 +115,10 @@ export class DecisionOperatorComponent implements OnInit, OnDestroy {
     this.loadingRuns = true;
     this.error = '';
     try {
-      const response = await fetch('/api/operator/decision-runs');
-      if (!response.ok) {
-        throw new Error(`Failed to load runs (${response.status})`);
-      }
-      const data = await response.json();
-      this.runs = Array.isArray(data) ? data : [];
+      const page = await this.fetchRunPage(Math.max(this.pageSize, this.runs.length));
+      this.runs = page.runs;
+      this.runsTotal = page.total ?? page.runs.length;
+      this.nextCursor = page.next_cursor || '';
       if (this.selectedRun?.id) {
         const exists = this.runs.find(item => item.id === this.selectedRun?.id);
         if (!exists) {
@@ -111,6 +132,45 @@ export class DecisionOperatorComponent implements OnInit, OnDestroy {
     }
   }
 
+  async loadMoreRuns() {
+    if (!this.nextCursor || this.loadingRuns) return;
+    this.loadingRuns = true;
+    this.error = '';
+    try {
+      const page = await this.fetchRunPage(this.pageSize, this.nextCursor);
+      this.runs = [...this.runs, ...page.runs];
+      this.nextCursor = page.next_cursor || '';
+    } catch (err) {
+      this.error = err instanceof Error ? err.message : 'Unable to load decision runs';
+    } finally {
+      this.loadingRuns = false;
+    }
+  }
+
+  async applyRunFilters() {
+    this.runs = [];
+    this.nextCursor = '';
+    await this.refreshRuns();
+  }
+
+  private async fetchRunPage(limit: number, cursor = ''): Promise<DecisionRunPage> {
+    const params = new URLSearchParams({ limit: String(Math.min(limit, 200)) });
+    if (cursor) params.set('cursor', cursor);
+    if (this.statusFilter) params.set('status', this.statusFilter);
+    if (this.searchText.trim()) params.set('q', this.searchText.trim());
+
+    const response = await fetch(`/api/operator/decision-runs?${params.toString()}`);
+    const data = await response.json().catch(() => null);
+    if (!response.ok) {
+      throw new Error(data?.detail || data?.error || `Failed to load runs (${response.status})`);
+    }
+    // Operators that predate pagination return a bare array.
+    if (Array.isArray(data)) {
+      return { runs: data };
+    }
+    return { runs: Array.isArray(data?.runs) ? data.runs : [], next_cursor: data?.next_cursor, total: data?.total };
+  }
+
   async createRun() {
     const featureName = this.featureName.trim();
     if (!featureName || this.creatingRun) {
@@ -124,7 +184,7 @@ export class DecisionOperatorComponent implements OnInit, OnDestroy {
       const response = await fetch('/api/operator/decision-runs', {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
-        body: JSON.stringify({ feature_name: featureName })
+        body: JSON.stringify({ feature_name: featureName, sink: this.runSink })
       });
       const data = await response.json().catch(() => ({}));
       if (!response.ok) {
@@ -160,6 +220,77 @@ export class DecisionOperatorComponent implements OnInit, OnDestroy {
     }
   }
 
+  canCancel(run: DecisionRunDetail): boolean {
+    return run.status === 'queued' || run.status === 'running';
+  }
+
+  canRetry(run: DecisionRunDetail): boolean {
+    return run.status === 'failed' || run.status === 'cancelled';
+  }
+
+  async runAction(action: 'cancel' | 'retry' | 'rerun' | 'delete') {
+    const runID = this.selectedRun?.id;
+    if (!runID || this.runActionPending) return;
+
+    this.runActionPending = action;
+    this.notice = '';
+    this.error = '';
+    try {
+      const response = action === 'delete'
+        ? await fetch(`/api/operator/decision-runs/${runID}`, { method: 'DELETE' })
+        : await fetch(`/api/operator/decision-runs/${runID}/${action}`, {
+          method: 'POST',
+          headers: { 'Content-Type': 'application/json' },
+          body: JSON.stringify({})
+        });
+      const data = await response.json().catch(() => ({}));
+      if (!response.ok) {
+        throw new Error(data?.detail || data?.error || `Failed to ${action} run (${response.status})`);
+      }
+
+      if (action === 'delete') {
+        this.selectedRun = null;
+        this.notice = `Deleted run ${runID}.`;
+        await this.refreshRuns();
+        return;
+      }
+      const nextRunID = action === 'rerun' && data?.decision_run_id ? String(data.decision_run_id) : runID;
+      this.notice = action === 'rerun' ? `Rerun queued as ${nextRunID}.` : `Run ${action === 'cancel' ? 'cancelled' : 'queued for retry'}.`;
+      await this.refreshRuns();
+      await this.loadRun(nextRunID, false);
+    } catch (err) {
+      this.error = err instanceof Error ? err.message : `Failed to ${action} run`;
+    } finally {
+      this.runActionPending = '';
+    }
+  }
+
+  async openGitHubIssue() {
+    const runID = this.selectedRun?.id;
+    if (!runID || this.runActionPending) return;
+
+    this.runActionPending = 'github-issue';
+    this.notice = '';
+    this.error = '';
+    try {
+      const response = await fetch(`/api/operator/decision-runs/${runID}/github-issue`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({})
+      });
+      const data = await response.json().catch(() => ({}));
+      if (!response.ok) {
+        throw new Error(data?.error || `Failed to open GitHub issue (${response.status})`);
+      }
+      this.notice = `GitHub issue ${data.repo}#${data.number} opened.`;
+      await this.loadRun(runID, false);
+    } catch (err) {
+      this.error = err instanceof Error ? err.message : 'Failed to open GitHub issue';
+    } finally {
+      this.runActionPending = '';
+    }
+  }
+
   async importSlackState() {
     if (this.importingSlack) return;
     this.importingSlack = true;
@@ -198,9 +329,12 @@ export class DecisionOperatorComponent implements OnInit, OnDestroy {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({
-          auth_type: 'cookie_session',
+          auth_type: this.jiraApiToken.trim() ? 'api_token' : 'cookie_session',
+          project_key: this.jiraProjectKey.trim(),
           credential_blob: {
             base_url: this.jiraBaseUrl.trim(),
+            email: this.jiraEmail.trim(),
+            api_token: this.jiraApiToken.trim(),
             session_cookie: this.jiraSessionCookie.trim()
           }
         })
@@ -209,7 +343,7 @@ export class DecisionOperatorComponent implements OnInit, OnDestroy {
       if (!response.ok) {
         throw new Error(data?.detail || data?.error || `Jira connection failed (${response.status})`);
       }
-      this.notice = 'Jira connection saved.';
+      this.notice = data?.project_key ? `Jira connected to project ${data.project_key}.` : 'Jira connection saved.';
     } catch (err) {
       this.error = err instanceof Error ? err.message : 'Jira connection failed';
     } finally {
@@ -217,6 +351,35 @@ export class DecisionOperatorComponent implements OnInit, OnDestroy {
     }
   }
 
+  async connectLinear() {
+    if (this.connectingLinear) return;
+
+    this.connectingLinear = true;
+    this.notice = '';
+    this.error = '';
+    try {
+      const response = await fetch('/api/operator/connections/linear', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({
+          api_key: this.linearApiKey.trim(),
+          team_key: this.linearTeamKey.trim(),
+          target: this.linearTarget
+        })
+      });
+      const data = await response.json().catch(() => ({}));
+      if (!response.ok) {
+        throw new Error(data?.error || `Linear connection failed (${response.status})`);
+      }
+      this.linearApiKey = '';
+      this.notice = `Linear connected to team ${data.team_key}.`;
+    } catch (err) {
+      this.error = err instanceof Error ? err.message : 'Linear connection failed';
+    } finally {
+      this.connectingLinear = false;
+    }
+  }
+
   trackRun(_index: number, run: DecisionRunSummary): string {
     return run.id;
   }
@@ -225,6 +388,7 @@ export class DecisionOperatorComponent implements OnInit, OnDestroy {
     const normalized = (status || '').toLowerCase();
     if (normalized === 'completed') return 'tone-success';
     if (normalized === 'failed' || normalized === 'needs_user_action') return 'tone-danger';
+    if (normalized === 'cancelled') return 'tone-muted';
     return 'tone-info';
   }
 